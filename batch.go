@@ -0,0 +1,218 @@
+package halfpike
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BatchInput is a content to parse with ParseAll() or ParseStream().
+type BatchInput struct {
+	// ID identifies the input in results and errors, such as the name of the device the content came from.
+	ID string
+	// Content is the content to parse.
+	Content string
+}
+
+// BatchResult is the result of parsing a BatchInput.
+type BatchResult struct {
+	// Index is the index of the BatchInput in the inputs.
+	Index int
+	// ID is the BatchInput.ID.
+	ID string
+	// Object is the ParseObject the content was parsed into. This is nil if Err != nil.
+	Object ParseObject
+	// Provenance is the Provenance recorded for Object. This is nil unless BatchOptions.Provenance is set.
+	Provenance Provenance
+	// Err is the error parsing the content, if any.
+	Err error
+}
+
+// BatchOptions are options for ParseAll() and ParseStream().
+type BatchOptions struct {
+	// Workers is the maximum number of contents to parse at the same time. If <= 0, this is runtime.GOMAXPROCS(0).
+	Workers int
+	// Timeout is the maximum time to parse a single content. If 0, there is no timeout other than
+	// what is set on the Context.
+	Timeout time.Duration
+	// Provenance causes each BatchResult to have its own Provenance.
+	Provenance bool
+	// ParseOptions are passed to each call to Parse(). These are applied to every Parser, so they must
+	// not share mutable state between Parsers, such as a Provenance passed to WithProvenance().
+	// Use BatchOptions.Provenance instead.
+	ParseOptions []Option
+}
+
+// InputError is an error parsing a single BatchInput.
+type InputError struct {
+	// Index is the index of the BatchInput in the inputs.
+	Index int
+	// ID is the BatchInput.ID.
+	ID string
+	// Err is the error returned while parsing.
+	Err error
+}
+
+// Error implements error.Error().
+func (i *InputError) Error() string {
+	return fmt.Sprintf("input %d(%s): %s", i.Index, i.ID, i.Err)
+}
+
+// Unwrap returns the underlying error.
+func (i *InputError) Unwrap() error {
+	return i.Err
+}
+
+// BatchError is returned by ParseAll() when one or more inputs failed to parse.
+type BatchError struct {
+	// Errors are the errors for each input that failed, in the order of the inputs.
+	Errors []*InputError
+}
+
+// Error implements error.Error().
+func (b *BatchError) Error() string {
+	switch len(b.Errors) {
+	case 0:
+		return "no errors"
+	case 1:
+		return b.Errors[0].Error()
+	}
+	s := make([]string, 0, len(b.Errors))
+	for _, e := range b.Errors {
+		s = append(s, e.Error())
+	}
+	return fmt.Sprintf("%d inputs had errors: %s", len(b.Errors), strings.Join(s, "; "))
+}
+
+// ParseAll parses each input into a new ParseObject created by "factory", parsing up to
+// BatchOptions.Workers inputs concurrently. Each Parse() call receives its own Parser and
+// ParseObject, so "factory" must return a new ParseObject on every call.
+//
+// The results are in the same order as the inputs. If any input fails, the error is a *BatchError
+// detailing each failure, which are also recorded in BatchResult.Err. If ctx is cancelled,
+// inputs that were not parsed have ctx.Err() as their error.
+func ParseAll(ctx context.Context, inputs []BatchInput, factory func() ParseObject, opts BatchOptions) ([]BatchResult, error) {
+	results := make([]BatchResult, len(inputs))
+	done := make([]bool, len(inputs))
+
+	runBatch(ctx, inputs, factory, opts, func(r BatchResult) {
+		results[r.Index] = r
+		done[r.Index] = true
+	})
+
+	bErr := &BatchError{}
+	for i, r := range results {
+		if !done[i] {
+			r = BatchResult{Index: i, ID: inputs[i].ID, Err: ctx.Err()}
+			results[i] = r
+		}
+		if r.Err != nil {
+			bErr.Errors = append(bErr.Errors, &InputError{Index: r.Index, ID: r.ID, Err: r.Err})
+		}
+	}
+	if len(bErr.Errors) > 0 {
+		return results, bErr
+	}
+	return results, nil
+}
+
+// ParseStream is like ParseAll() except that results are sent on the returned channel as each input
+// finishes, which is not the order of the inputs. The channel is closed once there is a result for
+// every input. If ctx is cancelled, inputs that were not parsed are sent with ctx.Err() as their
+// error, so every input has a result. The channel has room for every result, so a caller that stops
+// receiving does not block ParseStream.
+func ParseStream(ctx context.Context, inputs []BatchInput, factory func() ParseObject, opts BatchOptions) <-chan BatchResult {
+	out := make(chan BatchResult, len(inputs))
+
+	go func() {
+		defer close(out)
+
+		mu := sync.Mutex{}
+		done := make([]bool, len(inputs))
+		runBatch(ctx, inputs, factory, opts, func(r BatchResult) {
+			mu.Lock()
+			done[r.Index] = true
+			mu.Unlock()
+			out <- r
+		})
+
+		for i, d := range done {
+			if !d {
+				out <- BatchResult{Index: i, ID: inputs[i].ID, Err: ctx.Err()}
+			}
+		}
+	}()
+	return out
+}
+
+// runBatch parses the inputs with a pool of workers and calls emit() with each result. emit() may
+// be called concurrently, but never twice for the same input. runBatch returns once all workers have
+// exited. Inputs that have not started when ctx is cancelled are not emitted.
+func runBatch(ctx context.Context, inputs []BatchInput, factory func() ParseObject, opts BatchOptions, emit func(BatchResult)) {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(inputs) {
+		workers = len(inputs)
+	}
+
+	work := make(chan int)
+	wg := sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range work {
+				emit(parseInput(ctx, index, inputs[index], factory, opts))
+			}
+		}()
+	}
+
+	defer wg.Wait()
+	defer close(work)
+	for i := range inputs {
+		select {
+		case <-ctx.Done():
+			return
+		case work <- i:
+		}
+	}
+}
+
+// parseInput parses a single input with its own Parser, ParseObject and Provenance.
+func parseInput(ctx context.Context, index int, in BatchInput, factory func() ParseObject, opts BatchOptions) (r BatchResult) {
+	r = BatchResult{Index: index, ID: in.ID}
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	// A panic in a ParseFn should not take down every other parse in the batch.
+	defer func() {
+		if rec := recover(); rec != nil {
+			r.Object, r.Provenance = nil, nil
+			r.Err = fmt.Errorf("panic while parsing: %v", rec)
+		}
+	}()
+
+	options := opts.ParseOptions
+	if opts.Provenance {
+		r.Provenance = Provenance{}
+		options = append(options[:len(options):len(options)], WithProvenance(r.Provenance))
+	}
+
+	obj := factory()
+	if err := Parse(ctx, in.Content, obj, options...); err != nil {
+		r.Provenance = nil
+		r.Err = err
+		return r
+	}
+	r.Object = obj
+	return r
+}
@@ -0,0 +1,210 @@
+package halfpike
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// batchObj counts the "Address:" lines in a content. It fails on a "bad" line and panics on a "panic" line.
+type batchObj struct {
+	addrs int
+
+	// running tracks how many batchObj are parsing at the same time.
+	running, maxRunning *int32
+	delay               time.Duration
+}
+
+func (b *batchObj) Start(ctx context.Context, p *Parser) ParseFn {
+	if b.running != nil {
+		n := atomic.AddInt32(b.running, 1)
+		defer atomic.AddInt32(b.running, -1)
+		for {
+			m := atomic.LoadInt32(b.maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(b.maxRunning, m, n) {
+				break
+			}
+		}
+		time.Sleep(b.delay)
+	}
+
+	for {
+		line := p.Next()
+		switch {
+		case p.EOF(line):
+			return nil
+		case p.IsAtStart(line, []string{"bad"}):
+			return p.Errorf("[Line %d]: found bad line", line.LineNum)
+		case p.IsAtStart(line, []string{"panic"}):
+			panic("found panic line")
+		case p.IsAtStart(line, []string{"Address:", Skip}):
+			p.SetSource(fmt.Sprintf("addr[%d]", b.addrs), line, 1)
+			b.addrs++
+		}
+	}
+}
+
+func (b *batchObj) Validate() error {
+	return nil
+}
+
+func TestParseAll(t *testing.T) {
+	inputs := []BatchInput{}
+	for i := 0; i < 100; i++ {
+		content := strings.Repeat("Address: 10.0.0.1\n", i%5)
+		switch i {
+		case 7:
+			content += "bad\n"
+		case 42:
+			content += "panic\n"
+		}
+		inputs = append(inputs, BatchInput{ID: fmt.Sprintf("device-%d", i), Content: content})
+	}
+
+	results, err := ParseAll(context.Background(), inputs, func() ParseObject { return &batchObj{} }, BatchOptions{Workers: 8, Provenance: true})
+	bErr := &BatchError{}
+	if !errors.As(err, &bErr) {
+		t.Fatalf("TestParseAll: got err == %v, want *BatchError", err)
+	}
+	if len(bErr.Errors) != 2 || bErr.Errors[0].ID != "device-7" || bErr.Errors[1].ID != "device-42" {
+		t.Fatalf("TestParseAll: got errors %v, want errors for device-7 and device-42", bErr)
+	}
+
+	if len(results) != len(inputs) {
+		t.Fatalf("TestParseAll: got %d results, want %d", len(results), len(inputs))
+	}
+	for i, r := range results {
+		if r.Index != i || r.ID != inputs[i].ID {
+			t.Errorf("TestParseAll: result %d: got (%d, %s), want (%d, %s)", i, r.Index, r.ID, i, inputs[i].ID)
+			continue
+		}
+		if i == 7 || i == 42 {
+			if r.Err == nil || r.Object != nil {
+				t.Errorf("TestParseAll: result %d: got (Err == %v, Object == %v), want an error and no Object", i, r.Err, r.Object)
+			}
+			continue
+		}
+		if r.Err != nil {
+			t.Errorf("TestParseAll: result %d: got err == %s", i, r.Err)
+			continue
+		}
+		if got := r.Object.(*batchObj).addrs; got != i%5 {
+			t.Errorf("TestParseAll: result %d: got %d addresses, want %d", i, got, i%5)
+		}
+		// Each result must have its own Provenance.
+		if len(r.Provenance) != i%5 {
+			t.Errorf("TestParseAll: result %d: got %d Provenance entries, want %d", i, len(r.Provenance), i%5)
+		}
+	}
+}
+
+func TestParseAllWorkers(t *testing.T) {
+	var running, maxRunning int32
+	inputs := make([]BatchInput, 20)
+	factory := func() ParseObject {
+		return &batchObj{running: &running, maxRunning: &maxRunning, delay: 5 * time.Millisecond}
+	}
+
+	if _, err := ParseAll(context.Background(), inputs, factory, BatchOptions{Workers: 3}); err != nil {
+		t.Fatalf("TestParseAllWorkers: got err == %s", err)
+	}
+	if maxRunning > 3 {
+		t.Errorf("TestParseAllWorkers: got %d concurrent parses, want <= 3", maxRunning)
+	}
+}
+
+// slowObj never finishes parsing on its own.
+type slowObj struct{}
+
+func (s *slowObj) Start(ctx context.Context, p *Parser) ParseFn {
+	var fn ParseFn
+	fn = func(ctx context.Context, p *Parser) ParseFn {
+		time.Sleep(time.Millisecond)
+		return fn
+	}
+	return fn
+}
+
+func (s *slowObj) Validate() error {
+	return nil
+}
+
+func TestParseAllTimeout(t *testing.T) {
+	inputs := []BatchInput{{ID: "a", Content: "hello\n"}, {ID: "b", Content: "world\n"}}
+
+	results, err := ParseAll(context.Background(), inputs, func() ParseObject { return &slowObj{} }, BatchOptions{Timeout: 20 * time.Millisecond})
+	if err == nil {
+		t.Fatalf("TestParseAllTimeout: got err == nil, want err != nil")
+	}
+	for _, r := range results {
+		if !errors.Is(r.Err, context.DeadlineExceeded) {
+			t.Errorf("TestParseAllTimeout(%s): got err == %v, want context.DeadlineExceeded", r.ID, r.Err)
+		}
+	}
+}
+
+func TestParseAllCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	inputs := []BatchInput{{ID: "a", Content: "hello\n"}, {ID: "b", Content: "world\n"}}
+	results, err := ParseAll(ctx, inputs, func() ParseObject { return &batchObj{} }, BatchOptions{Workers: 1})
+	bErr := &BatchError{}
+	if !errors.As(err, &bErr) || len(bErr.Errors) != 2 {
+		t.Fatalf("TestParseAllCancel: got err == %v, want *BatchError with 2 errors", err)
+	}
+	for _, r := range results {
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("TestParseAllCancel(%s): got err == %v, want context.Canceled", r.ID, r.Err)
+		}
+	}
+}
+
+func TestParseStreamCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	inputs := []BatchInput{{ID: "a", Content: "hello\n"}, {ID: "b", Content: "world\n"}, {ID: "c", Content: "!\n"}}
+	seen := map[int]bool{}
+	for r := range ParseStream(ctx, inputs, func() ParseObject { return &batchObj{} }, BatchOptions{Workers: 1}) {
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("TestParseStreamCancel(%s): got err == %v, want context.Canceled", r.ID, r.Err)
+		}
+		if r.ID != inputs[r.Index].ID {
+			t.Errorf("TestParseStreamCancel(%s): got Index %d, which is input %s", r.ID, r.Index, inputs[r.Index].ID)
+		}
+		seen[r.Index] = true
+	}
+	if len(seen) != len(inputs) {
+		t.Errorf("TestParseStreamCancel: got %d results, want %d", len(seen), len(inputs))
+	}
+}
+
+func TestParseStream(t *testing.T) {
+	inputs := []BatchInput{}
+	for i := 0; i < 50; i++ {
+		inputs = append(inputs, BatchInput{ID: fmt.Sprintf("device-%d", i), Content: strings.Repeat("Address: 10.0.0.1\n", i%3)})
+	}
+
+	seen := map[int]bool{}
+	for r := range ParseStream(context.Background(), inputs, func() ParseObject { return &batchObj{} }, BatchOptions{Workers: 4}) {
+		if r.Err != nil {
+			t.Errorf("TestParseStream(%s): got err == %s", r.ID, r.Err)
+			continue
+		}
+		if got := r.Object.(*batchObj).addrs; got != r.Index%3 {
+			t.Errorf("TestParseStream(%s): got %d addresses, want %d", r.ID, got, r.Index%3)
+		}
+		if seen[r.Index] {
+			t.Errorf("TestParseStream(%s): got result twice", r.ID)
+		}
+		seen[r.Index] = true
+	}
+	if len(seen) != len(inputs) {
+		t.Errorf("TestParseStream: got %d results, want %d", len(seen), len(inputs))
+	}
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// field is a value marked in the sample with {{name:type}}.
+type field struct {
+	// Name is the name in the marker.
+	Name string
+	// GoName is the exported name of the struct field.
+	GoName string
+	// Type is the type in the marker.
+	Type string
+	// Index is the Item index of the value in its line.
+	Index int
+	// Prefix and Suffix are text in the same word as the marker, such as the "," in "{{mtu:int}},".
+	Prefix, Suffix string
+}
+
+// GoType returns the Go type of the struct field.
+func (f field) GoType() string {
+	return fieldTypes[f.Type].goType
+}
+
+// IndexConst returns the name of the constant holding the Item index.
+func (f field) IndexConst() string {
+	return lowerFirst(f.GoName) + "Index"
+}
+
+// Value returns the code for the value's text.
+func (f field) Value() string {
+	v := fmt.Sprintf("line.Items[%s].Val", f.IndexConst())
+	if f.Prefix != "" {
+		v = fmt.Sprintf("strings.TrimPrefix(%s, %q)", v, f.Prefix)
+	}
+	if f.Suffix != "" {
+		v = fmt.Sprintf("strings.TrimSuffix(%s, %q)", v, f.Suffix)
+	}
+	return v
+}
+
+// Convert returns the code that converts the value's text to the field's type, returning "v, err".
+// For a string field, this is "".
+func (f field) Convert() string {
+	if fieldTypes[f.Type].convert == "" {
+		return ""
+	}
+	return fmt.Sprintf(fieldTypes[f.Type].convert, f.Value())
+}
+
+// sampleLine is a line in the sample that has markers.
+type sampleLine struct {
+	// Num is the line number in the sample, starting at 1.
+	Num int
+	// Text is the line as written in the sample.
+	Text string
+	// Pattern is the FindStart() pattern for the line, with "" for each marker.
+	Pattern []string
+	// Fields are the fields marked in the line.
+	Fields []field
+}
+
+// FuncName is the name of the ParseFn for the line.
+func (s sampleLine) FuncName() string {
+	return "find" + s.Fields[0].GoName
+}
+
+// PatternVar is the name of the variable holding the line's FindStart() pattern.
+func (s sampleLine) PatternVar() string {
+	return lowerFirst(s.Fields[0].GoName) + "Line"
+}
+
+// PatternCode returns the Go code for the pattern.
+func (s sampleLine) PatternCode() string {
+	items := make([]string, 0, len(s.Pattern))
+	for _, p := range s.Pattern {
+		if p == "" {
+			items = append(items, "halfpike.Skip")
+			continue
+		}
+		items = append(items, strconv.Quote(p))
+	}
+	return "[]string{" + strings.Join(items, ", ") + "}"
+}
+
+type fieldType struct {
+	goType  string
+	convert string
+	imports []string
+}
+
+var fieldTypes = map[string]fieldType{
+	"string": {goType: "string"},
+	"int":    {goType: "int", convert: "strconv.Atoi(%s)", imports: []string{"strconv"}},
+	"int64":  {goType: "int64", convert: "strconv.ParseInt(%s, 10, 64)", imports: []string{"strconv"}},
+	"float":  {goType: "float64", convert: "strconv.ParseFloat(%s, 64)", imports: []string{"strconv"}},
+	"bool":   {goType: "bool", convert: "strconv.ParseBool(%s)", imports: []string{"strconv"}},
+	"ip":     {goType: "net.IP", convert: "parseIP(%s)", imports: []string{"net"}},
+}
+
+var (
+	markerRE = regexp.MustCompile(`^([^{]*)\{\{([a-zA-Z][a-zA-Z0-9_]*):([a-z0-9]+)\}\}([^{}]*)$`)
+	// initialisms are name parts that are all capitals in Go names.
+	initialisms = map[string]bool{
+		"as": true, "asn": true, "bgp": true, "cpu": true, "dns": true, "id": true, "ip": true,
+		"mac": true, "mtu": true, "os": true, "snmp": true, "url": true, "vlan": true, "vrf": true,
+	}
+)
+
+// parseSample finds the lines in "sample" with markers.
+func parseSample(sample string) ([]sampleLine, error) {
+	var (
+		lines   []sampleLine
+		names   = map[string]int{}
+		goNames = map[string]string{}
+	)
+
+	for i, text := range strings.Split(sample, "\n") {
+		text = strings.TrimRight(text, "\r")
+		if !strings.Contains(text, "{{") {
+			continue
+		}
+
+		l := sampleLine{Num: i + 1, Text: text}
+		hasLiteral := false
+		for index, word := range strings.Fields(text) {
+			if !strings.Contains(word, "{{") {
+				l.Pattern = append(l.Pattern, word)
+				hasLiteral = true
+				continue
+			}
+
+			m := markerRE.FindStringSubmatch(word)
+			if m == nil {
+				return nil, fmt.Errorf("line %d: %q must have one marker of the form {{name:type}}, separated by spaces from other markers", l.Num, word)
+			}
+			name, typ := m[2], m[3]
+			if _, ok := fieldTypes[typ]; !ok {
+				return nil, fmt.Errorf("line %d: marker %q has unsupported type %q", l.Num, name, typ)
+			}
+			if prev, ok := names[name]; ok {
+				return nil, fmt.Errorf("line %d: marker %q is already used on line %d", l.Num, name, prev)
+			}
+			names[name] = l.Num
+			gn := goName(name)
+			if prev, ok := goNames[gn]; ok {
+				return nil, fmt.Errorf("line %d: marker %q has the same Go name %q as marker %q", l.Num, name, gn, prev)
+			}
+			goNames[gn] = name
+
+			l.Pattern = append(l.Pattern, "")
+			l.Fields = append(l.Fields, field{Name: name, GoName: gn, Type: typ, Index: index, Prefix: m[1], Suffix: m[4]})
+		}
+		if !hasLiteral {
+			return nil, fmt.Errorf("line %d: must have text other than markers to find the line with", l.Num)
+		}
+		lines = append(lines, l)
+	}
+
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("no {{name:type}} markers found")
+	}
+	return lines, nil
+}
+
+// goName converts a marker name like "local_as" to an exported Go name like "LocalAS".
+func goName(name string) string {
+	b := strings.Builder{}
+	for _, part := range strings.Split(name, "_") {
+		switch {
+		case part == "":
+		case initialisms[strings.ToLower(part)]:
+			b.WriteString(strings.ToUpper(part))
+		default:
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	return b.String()
+}
+
+// lowerFirst makes the first word of a Go name lower case, so "MTU" is "mtu" and "LocalAS" is "localAS".
+func lowerFirst(s string) string {
+	n := 0
+	for n < len(s) && s[n] >= 'A' && s[n] <= 'Z' {
+		n++
+	}
+	switch {
+	case n == len(s):
+		return strings.ToLower(s)
+	case n > 1:
+		// "IPAddr" is "ipAddr".
+		n--
+	}
+	return strings.ToLower(s[:n]) + s[n:]
+}
+
+// generate returns the formatted Go file for "lines".
+func generate(pkg, typ, sampleFile string, lines []sampleLine) ([]byte, error) {
+	imports := map[string]bool{"context": true, "fmt": true}
+	for _, l := range lines {
+		for _, f := range l.Fields {
+			for _, imp := range fieldTypes[f.Type].imports {
+				imports[imp] = true
+			}
+			if f.Prefix != "" || f.Suffix != "" {
+				imports["strings"] = true
+			}
+		}
+	}
+
+	args := struct {
+		Pkg, Type, Sample string
+		Imports           map[string]bool
+		Lines             []sampleLine
+	}{pkg, typ, filepath.Base(sampleFile), imports, lines}
+
+	buf := &bytes.Buffer{}
+	if err := genTmpl.Execute(buf, args); err != nil {
+		return nil, err
+	}
+	b, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated code does not format (this is a bug): %w\n%s", err, buf.Bytes())
+	}
+	return b, nil
+}
+
+var genTmpl = template.Must(template.New("gen").Funcs(template.FuncMap{
+	"next": func(lines []sampleLine, i int) string {
+		if i+1 < len(lines) {
+			return "o." + lines[i+1].FuncName()
+		}
+		return "nil"
+	},
+}).Parse(`// Code scaffolded by "halfpike gen" from {{.Sample}}. Edit as needed.
+
+package {{.Pkg}}
+
+import (
+	{{range $imp, $_ := .Imports}}"{{$imp}}"
+	{{end}}
+	"github.com/johnsiilver/halfpike"
+)
+
+// {{.Type}} holds the values decoded from the output.
+type {{.Type}} struct {
+	{{- range .Lines}}{{range .Fields}}
+	{{.GoName}} {{.GoType}}{{end}}{{end}}
+
+	// found records the fields that were decoded, for Validate().
+	found map[string]bool
+}
+
+// Start implements halfpike.ParseObject.Start().
+func (o *{{.Type}}) Start(ctx context.Context, p *halfpike.Parser) halfpike.ParseFn {
+	o.found = map[string]bool{}
+	return o.{{(index .Lines 0).FuncName}}
+}
+{{range $i, $l := .Lines}}
+// {{$l.PatternVar}} finds sample line {{$l.Num}}:
+//
+//	{{$l.Text}}
+var {{$l.PatternVar}} = {{$l.PatternCode}}
+
+// Item indexes of the values in {{$l.PatternVar}}.
+const (
+	{{- range $l.Fields}}
+	{{.IndexConst}} = {{.Index}}{{end}}
+)
+
+func (o *{{$.Type}}) {{$l.FuncName}}(ctx context.Context, p *halfpike.Parser) halfpike.ParseFn {
+	line, err := p.FindStart({{$l.PatternVar}})
+	if err != nil {
+		return p.Errorf("did not find sample line {{$l.Num}}: %s", err)
+	}
+{{range $l.Fields}}{{if .Convert}}
+	{
+		v, err := {{.Convert}}
+		if err != nil {
+			return p.Errorf("[Line %d]: {{.Name}}: %s", line.LineNum, err)
+		}
+		o.{{.GoName}} = v
+	}
+{{- else}}
+	o.{{.GoName}} = {{.Value}}
+{{- end}}
+	o.found[{{printf "%q" .Name}}] = true
+{{end}}
+	return {{next $.Lines $i}}
+}
+{{end}}
+// Validate implements halfpike.Validator.
+func (o *{{.Type}}) Validate() error {
+	for _, name := range []string{ {{- range .Lines}}{{range .Fields}}{{printf "%q" .Name}}, {{end}}{{end}} } {
+		if !o.found[name] {
+			return fmt.Errorf("%s was not found", name)
+		}
+	}
+	return nil
+}
+{{if index .Imports "net"}}
+func parseIP(s string) (net.IP, error) {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("%q is not an IP address", s)
+	}
+	return ip, nil
+}
+{{end}}`))
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+var update = flag.Bool("update", false, "Update the golden files in testdata.")
+
+func TestParseSample(t *testing.T) {
+	tests := []struct {
+		desc   string
+		sample string
+		want   []sampleLine
+		err    bool
+	}{
+		{
+			desc:   "Markers with punctuation",
+			sample: "header\n  MTU: {{mtu:int}}, Local AS: {{local_as:int64}}\n",
+			want: []sampleLine{
+				{
+					Num:     2,
+					Text:    "  MTU: {{mtu:int}}, Local AS: {{local_as:int64}}",
+					Pattern: []string{"MTU:", "", "Local", "AS:", ""},
+					Fields: []field{
+						{Name: "mtu", GoName: "MTU", Type: "int", Index: 1, Suffix: ","},
+						{Name: "local_as", GoName: "LocalAS", Type: "int64", Index: 4},
+					},
+				},
+			},
+		},
+		{
+			desc:   "Prefix",
+			sample: "Peer: ({{peer:ip}})\n",
+			want: []sampleLine{
+				{
+					Num:     1,
+					Text:    "Peer: ({{peer:ip}})",
+					Pattern: []string{"Peer:", ""},
+					Fields:  []field{{Name: "peer", GoName: "Peer", Type: "ip", Index: 1, Prefix: "(", Suffix: ")"}},
+				},
+			},
+		},
+		{
+			desc:   "No markers",
+			sample: "MTU: 1500\n",
+			err:    true,
+		},
+		{
+			desc:   "Two markers in a word",
+			sample: "Peer: {{addr:ip}}+{{port:int}}\n",
+			err:    true,
+		},
+		{
+			desc:   "Unsupported type",
+			sample: "MTU: {{mtu:uint8}}\n",
+			err:    true,
+		},
+		{
+			desc:   "Duplicate name",
+			sample: "MTU: {{mtu:int}}\nIP MTU: {{mtu:int}}\n",
+			err:    true,
+		},
+		{
+			desc:   "Duplicate Go name",
+			sample: "Local AS: {{local_as:int}}\nPeer AS: {{local_AS:int}}\n",
+			err:    true,
+		},
+		{
+			desc:   "Only markers",
+			sample: "{{name:string}} {{mtu:int}}\n",
+			err:    true,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := parseSample(test.sample)
+		switch {
+		case err == nil && test.err:
+			t.Errorf("TestParseSample(%s): got err == nil, want err != nil", test.desc)
+			continue
+		case err != nil && !test.err:
+			t.Errorf("TestParseSample(%s): got err == %s", test.desc, err)
+			continue
+		case err != nil:
+			continue
+		}
+		if diff := pretty.Compare(test.want, got); diff != "" {
+			t.Errorf("TestParseSample(%s): -want/+got:\n%s", test.desc, diff)
+		}
+	}
+}
+
+func TestGoName(t *testing.T) {
+	tests := []struct {
+		name, want, lower string
+	}{
+		{"mtu", "MTU", "mtu"},
+		{"local_as", "LocalAS", "localAS"},
+		{"ip_address", "IPAddress", "ipAddress"},
+		{"description", "Description", "description"},
+		{"inputBytes", "InputBytes", "inputBytes"},
+	}
+	for _, test := range tests {
+		got := goName(test.name)
+		if got != test.want {
+			t.Errorf("TestGoName(%s): got %q, want %q", test.name, got, test.want)
+		}
+		if lower := lowerFirst(got); lower != test.lower {
+			t.Errorf("TestGoName(%s): lowerFirst() got %q, want %q", test.name, lower, test.lower)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	const sample, golden = "testdata/interface.txt", "testdata/interface.golden"
+
+	b, err := os.ReadFile(sample)
+	if err != nil {
+		t.Fatalf("TestGenerate: got err == %s", err)
+	}
+	lines, err := parseSample(string(b))
+	if err != nil {
+		t.Fatalf("TestGenerate: got err == %s", err)
+	}
+	got, err := generate("sample", "Interface", sample, lines)
+	if err != nil {
+		t.Fatalf("TestGenerate: got err == %s", err)
+	}
+
+	if *update {
+		if err := os.WriteFile(golden, got, 0644); err != nil {
+			t.Fatalf("TestGenerate: got err == %s", err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("TestGenerate: got err == %s", err)
+	}
+	if diff := pretty.Compare(string(want), string(got)); diff != "" {
+		t.Errorf("TestGenerate: -want/+got:\n%s", diff)
+	}
+}
+
+// runMain is the main package the generated parser is run with in TestGenerateRun.
+const runMain = `package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/johnsiilver/halfpike"
+)
+
+func main() {
+	b, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		panic(err)
+	}
+	o := &Interface{}
+	if err := halfpike.Parse(context.Background(), string(b), o); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	json.NewEncoder(os.Stdout).Encode(o)
+}
+`
+
+// TestGenerateRun compiles the parser generated from testdata/interface.txt and runs it on output
+// matching the sample.
+func TestGenerateRun(t *testing.T) {
+	if testing.Short() {
+		t.Skip("TestGenerateRun: skipped in short mode")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("TestGenerateRun: go command not found")
+	}
+
+	const output = `Physical interface: ge-0/0/1 Enabled, Physical link is Up
+  Interface index: 148, SNMP ifIndex: 526
+  Link-level type: Ethernet, MTU: 1514, Speed: 1000mbps
+  Load: 0.25 Up: true
+  Local: 10.0.0.1
+`
+
+	b, err := os.ReadFile("testdata/interface.txt")
+	if err != nil {
+		t.Fatalf("TestGenerateRun: got err == %s", err)
+	}
+	lines, err := parseSample(string(b))
+	if err != nil {
+		t.Fatalf("TestGenerateRun: got err == %s", err)
+	}
+	code, err := generate("main", "Interface", "testdata/interface.txt", lines)
+	if err != nil {
+		t.Fatalf("TestGenerateRun: got err == %s", err)
+	}
+
+	// The package is written inside this module so that it builds against this version of halfpike.
+	dir, err := os.MkdirTemp("testdata", "run")
+	if err != nil {
+		t.Fatalf("TestGenerateRun: got err == %s", err)
+	}
+	defer os.RemoveAll(dir)
+	for name, content := range map[string][]byte{"interface.go": code, "main.go": []byte(runMain)} {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			t.Fatalf("TestGenerateRun: got err == %s", err)
+		}
+	}
+
+	cmd := exec.Command(goBin, "run", ".")
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(output)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	got, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("TestGenerateRun: got err == %s\n%s", err, stderr)
+	}
+
+	want := `{"Name":"ge-0/0/1","Index":148,"SNMPIndex":526,"MTU":1514,"Load":0.25,"Up":true,"LocalIP":"10.0.0.1"}` + "\n"
+	if diff := pretty.Compare(want, string(got)); diff != "" {
+		t.Errorf("TestGenerateRun: -want/+got:\n%s", diff)
+	}
+}
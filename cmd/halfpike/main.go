@@ -0,0 +1,74 @@
+/*
+The halfpike command provides tools for writing halfpike parsers.
+
+	halfpike gen [flags] sample.txt
+
+gen scaffolds a parser from a sample of a command's output in which the values to decode are
+replaced with markers of the form {{name:type}}, such as:
+
+	Physical interface: {{name:string}}, Enabled, Physical link is Up
+	  MTU: {{mtu:int}}, Speed: {{speed:string}}
+
+A marker must be a whole word of the line. Supported types are string, int, int64, float, bool and ip.
+The output is a Go file with a struct holding each value, a ParseFn per marked line that finds the line
+using FindStart() with the line's text and Skip for each marker, constants for each value's Item index
+and a Validate() method that requires every value to be found. Lines without markers are ignored.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: halfpike gen [flags] sample.txt\n\nflags:\n")
+	genFlags.PrintDefaults()
+}
+
+var (
+	genFlags = flag.NewFlagSet("gen", flag.ExitOnError)
+	pkgName  = genFlags.String("pkg", "parser", "The package name of the generated file.")
+	typeName = genFlags.String("type", "Output", "The name of the generated struct type.")
+	outFile  = genFlags.String("o", "", "The file to write. If not set, the file is written to stdout.")
+)
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] != "gen" {
+		usage()
+		os.Exit(2)
+	}
+	genFlags.Usage = usage
+	genFlags.Parse(os.Args[2:])
+	if genFlags.NArg() != 1 {
+		usage()
+		os.Exit(2)
+	}
+
+	if err := gen(genFlags.Arg(0)); err != nil {
+		fmt.Fprintln(os.Stderr, "halfpike gen:", err)
+		os.Exit(1)
+	}
+}
+
+func gen(path string) error {
+	sample, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	lines, err := parseSample(string(sample))
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	b, err := generate(*pkgName, *typeName, path, lines)
+	if err != nil {
+		return err
+	}
+
+	if *outFile == "" {
+		_, err = os.Stdout.Write(b)
+		return err
+	}
+	return os.WriteFile(*outFile, b, 0644)
+}
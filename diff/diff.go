@@ -0,0 +1,648 @@
+// Package diff compares two parsed results, such as the output of the same command captured before
+// and after a change, and reports what was added, removed or changed.
+//
+// Results can be structs (or pointers to them), slices, maps, *halfpike.Record or *halfpike.RecordSet.
+// Lists are compared by position unless their elements have identity fields, declared with WithKey()
+// for structs or WithRecordKey() for Records, in which case elements with the same identity are
+// compared to each other wherever they are in the list:
+//
+//	changes, err := diff.Compare(
+//		before, after,
+//		diff.WithKey(junos.BGPNeighbor{}, "PeerIP"),
+//		diff.WithProvenance(beforeProv, afterProv),
+//	)
+//	if err != nil {
+//		// Do something
+//	}
+//	fmt.Print(changes.Report())
+//
+// Each Change records the line numbers in the two captures that the values came from. For a Record
+// these come from Value.LineNum. For other types they come from the halfpike.Provenance passed with
+// WithProvenance(), whose paths must use the Go field names (in any case) and list indexes, such as
+// "[0].PeerAS" or "Peers[1].state".
+package diff
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/johnsiilver/halfpike"
+)
+
+// Op is the kind of a Change.
+type Op uint8
+
+const (
+	// Added is a value that is only in the after result.
+	Added Op = 1
+	// Removed is a value that is only in the before result.
+	Removed Op = 2
+	// Changed is a value that is in both results, but is not equal.
+	Changed Op = 3
+)
+
+var opNames = map[Op]string{
+	Added:   "added",
+	Removed: "removed",
+	Changed: "changed",
+}
+
+// String implements fmt.Stringer.
+func (o Op) String() string {
+	if s, ok := opNames[o]; ok {
+		return s
+	}
+	return fmt.Sprintf("Op(%d)", uint8(o))
+}
+
+// MarshalText implements encoding.TextMarshaler, so an Op is output in JSON as its name.
+func (o Op) MarshalText() ([]byte, error) {
+	return []byte(o.String()), nil
+}
+
+// Change is a single difference between two results.
+type Change struct {
+	// Op is the kind of change.
+	Op Op `json:"op"`
+	// Path is the path to the value, such as "Peers[PeerIP=10.0.0.1].State". A list element with
+	// identity fields is shown as [Field=value,...], other list elements by their index.
+	Path string `json:"path"`
+	// Before is the value in the before result. It is nil when Op is Added.
+	Before interface{} `json:"before,omitempty"`
+	// After is the value in the after result. It is nil when Op is Removed.
+	After interface{} `json:"after,omitempty"`
+	// BeforeLine is the line number in the before capture that Before came from, or 0 if not known.
+	BeforeLine int `json:"beforeLine,omitempty"`
+	// AfterLine is the line number in the after capture that After came from, or 0 if not known.
+	AfterLine int `json:"afterLine,omitempty"`
+}
+
+// String implements fmt.Stringer. It is a line of Report().
+func (c Change) String() string {
+	var lines []string
+	if c.BeforeLine > 0 {
+		lines = append(lines, fmt.Sprintf("before line %d", c.BeforeLine))
+	}
+	if c.AfterLine > 0 {
+		lines = append(lines, fmt.Sprintf("after line %d", c.AfterLine))
+	}
+	at := ""
+	if len(lines) > 0 {
+		at = " (" + strings.Join(lines, ", ") + ")"
+	}
+
+	path := c.Path
+	if path == "" {
+		path = "."
+	}
+	switch c.Op {
+	case Added:
+		return fmt.Sprintf("+ %s: %s%s", path, format(c.After), at)
+	case Removed:
+		return fmt.Sprintf("- %s: %s%s", path, format(c.Before), at)
+	}
+	return fmt.Sprintf("~ %s: %s -> %s%s", path, format(c.Before), format(c.After), at)
+}
+
+// ChangeSet is the list of Changes between two results, in the order the values appear in them.
+// It can be output as JSON with encoding/json.
+type ChangeSet []Change
+
+// Report returns a human readable report with a line for each Change, such as:
+//
+//	~ Peers[PeerIP=10.0.0.1].State: Established -> Active (before line 12, after line 14)
+//	+ Peers[PeerIP=10.0.0.3]: {...} (after line 20)
+//	- Peers[PeerIP=10.0.0.2]: {...} (before line 8)
+func (c ChangeSet) Report() string {
+	if len(c) == 0 {
+		return "no changes\n"
+	}
+	b := strings.Builder{}
+	for _, ch := range c {
+		b.WriteString(ch.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Option is an optional argument to Compare().
+type Option func(d *differ)
+
+// WithKey declares that list elements of the type of "v", a struct or pointer to a struct, are
+// identified by "fields", such as WithKey(BGPNeighbor{}, "PeerIP"). The fields are compared as text.
+func WithKey(v interface{}, fields ...string) Option {
+	return func(d *differ) {
+		t := reflect.TypeOf(v)
+		for t != nil && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		d.keys[t] = fields
+	}
+}
+
+// WithRecordKey declares that the Records in the list field "list" are identified by "fields", such
+// as WithRecordKey("neighbors", "peer_ip"). Use "" for the Records of a RecordSet.
+func WithRecordKey(list string, fields ...string) Option {
+	return func(d *differ) {
+		d.recordKeys[list] = fields
+	}
+}
+
+// WithProvenance provides the halfpike.Provenance recorded when parsing the before and after results,
+// which is used to find the line numbers of values that are not in a Record. Either may be nil.
+func WithProvenance(before, after halfpike.Provenance) Option {
+	return func(d *differ) {
+		d.prov = [2]halfpike.Provenance{before, after}
+	}
+}
+
+// Compare returns the changes from "before" to "after", which must be the same type.
+func Compare(before, after interface{}, opts ...Option) (ChangeSet, error) {
+	d := &differ{keys: map[reflect.Type][]string{}, recordKeys: map[string][]string{}}
+	for _, o := range opts {
+		o(d)
+	}
+	for t, fields := range d.keys {
+		if t == nil || t.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("diff.WithKey(): must be passed a struct or pointer to a struct, not %v", t)
+		}
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("diff.WithKey(%v): must have at least one field", t)
+		}
+		for _, f := range fields {
+			sf, ok := t.FieldByName(f)
+			if !ok || sf.PkgPath != "" {
+				return nil, fmt.Errorf("diff.WithKey(%v): has no exported field %q", t, f)
+			}
+		}
+	}
+	for list, fields := range d.recordKeys {
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("diff.WithRecordKey(%s): must have at least one field", list)
+		}
+	}
+
+	a, b := reflect.ValueOf(before), reflect.ValueOf(after)
+	if a.IsValid() && b.IsValid() && a.Type() != b.Type() {
+		return nil, fmt.Errorf("diff.Compare(): cannot compare a %T to a %T", before, after)
+	}
+	if err := d.compare("", "", node{v: a}, node{v: b}); err != nil {
+		return nil, err
+	}
+	return d.changes, nil
+}
+
+var (
+	recordType    = reflect.TypeOf(&halfpike.Record{})
+	recordSetType = reflect.TypeOf(&halfpike.RecordSet{})
+	valuesType    = reflect.TypeOf([]halfpike.Value{})
+	stringerType  = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+	marshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// node is a value in one of the results.
+type node struct {
+	v reflect.Value
+	// path is the path to the value using list indexes, which is how it is found in a Provenance.
+	path string
+	// line is the line the value came from, if known without a Provenance.
+	line int
+}
+
+// differ holds the state of a Compare() call.
+type differ struct {
+	keys       map[reflect.Type][]string
+	recordKeys map[string][]string
+	prov       [2]halfpike.Provenance
+
+	changes ChangeSet
+}
+
+// compare compares "a" and "b", found at "path". "name" is the name of the field holding them, which
+// is used to find the keys for a list of Records.
+func (d *differ) compare(path, name string, a, b node) error {
+	a.v, b.v = deref(a.v), deref(b.v)
+
+	switch {
+	case !a.v.IsValid() && !b.v.IsValid():
+		return nil
+	case !a.v.IsValid():
+		d.add(Added, path, a, b)
+		return nil
+	case !b.v.IsValid():
+		d.add(Removed, path, a, b)
+		return nil
+	case a.v.Type() != b.v.Type() || isLeaf(a.v.Type()):
+		if !equal(a.v, b.v) {
+			d.add(Changed, path, a, b)
+		}
+		return nil
+	}
+
+	switch t := a.v.Type(); {
+	case t == recordType:
+		return d.compareRecords(path, a, b)
+	case t == recordSetType:
+		as, bs := a.v.Interface().(*halfpike.RecordSet), b.v.Interface().(*halfpike.RecordSet)
+		return d.compareLists(path, "", recordNodes(a, as.Records), recordNodes(b, bs.Records))
+	case t == valuesType:
+		return d.compareLists(path, name, valueNodes(a), valueNodes(b))
+	}
+
+	switch a.v.Kind() {
+	case reflect.Struct:
+		t := a.v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if sf.PkgPath != "" || !diffable(sf.Type) {
+				continue
+			}
+			err := d.compare(
+				joinPath(path, sf.Name),
+				sf.Name,
+				node{v: a.v.Field(i), path: joinPath(a.path, sf.Name)},
+				node{v: b.v.Field(i), path: joinPath(b.path, sf.Name)},
+			)
+			if err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		return d.compareLists(path, name, elemNodes(a), elemNodes(b))
+	case reflect.Map:
+		return d.compareMaps(path, a, b)
+	}
+	return nil
+}
+
+func (d *differ) compareRecords(path string, a, b node) error {
+	ar, br := a.v.Interface().(*halfpike.Record), b.v.Interface().(*halfpike.Record)
+
+	var names []string
+	seen := map[string]bool{}
+	for _, rec := range []*halfpike.Record{ar, br} {
+		for _, f := range rec.Fields() {
+			if !seen[f.Name] {
+				seen[f.Name] = true
+				names = append(names, f.Name)
+			}
+		}
+	}
+
+	for _, name := range names {
+		av, _ := ar.Get(name)
+		bv, _ := br.Get(name)
+		err := d.compare(
+			joinPath(path, name),
+			name,
+			node{v: reflect.ValueOf(av.V), path: joinPath(a.path, name), line: av.LineNum},
+			node{v: reflect.ValueOf(bv.V), path: joinPath(b.path, name), line: bv.LineNum},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compareLists compares lists by their keys or, if they have none, by position.
+func (d *differ) compareLists(path, name string, a, b []node) error {
+	fields := d.listKeys(name, a, b)
+	if fields == nil {
+		for i := 0; i < len(a) || i < len(b); i++ {
+			var an, bn node
+			if i < len(a) {
+				an = a[i]
+			}
+			if i < len(b) {
+				bn = b[i]
+			}
+			if err := d.compare(fmt.Sprintf("%s[%d]", path, i), name, an, bn); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	aKeys, aByKey, err := keyNodes(path, "before", fields, a)
+	if err != nil {
+		return err
+	}
+	bKeys, bByKey, err := keyNodes(path, "after", fields, b)
+	if err != nil {
+		return err
+	}
+
+	for _, k := range aKeys {
+		if err := d.compare(fmt.Sprintf("%s[%s]", path, k), name, aByKey[k], bByKey[k]); err != nil {
+			return err
+		}
+	}
+	for _, k := range bKeys {
+		if _, ok := aByKey[k]; ok {
+			continue
+		}
+		if err := d.compare(fmt.Sprintf("%s[%s]", path, k), name, node{}, bByKey[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listKeys returns the identity fields of the elements of a list, or nil if they have none.
+func (d *differ) listKeys(name string, a, b []node) []string {
+	for _, n := range append(a, b...) {
+		v := deref(n.v)
+		if !v.IsValid() {
+			continue
+		}
+		if v.Type() == recordType {
+			return d.recordKeys[name]
+		}
+		return d.keys[v.Type()]
+	}
+	return nil
+}
+
+func (d *differ) compareMaps(path string, a, b node) error {
+	keys := map[string]reflect.Value{}
+	for _, v := range []reflect.Value{a.v, b.v} {
+		for _, k := range v.MapKeys() {
+			keys[fmt.Sprint(k.Interface())] = k
+		}
+	}
+	names := make([]string, 0, len(keys))
+	for k := range keys {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	for _, k := range names {
+		p := fmt.Sprintf("%s[%s]", path, k)
+		err := d.compare(
+			p,
+			"",
+			node{v: a.v.MapIndex(keys[k]), path: fmt.Sprintf("%s[%s]", a.path, k)},
+			node{v: b.v.MapIndex(keys[k]), path: fmt.Sprintf("%s[%s]", b.path, k)},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// add adds a Change for "a" and "b" at "path".
+func (d *differ) add(op Op, path string, a, b node) {
+	c := Change{Op: op, Path: path}
+	if op != Added {
+		c.Before = value(a.v)
+		c.BeforeLine = d.line(0, a)
+	}
+	if op != Removed {
+		c.After = value(b.v)
+		c.AfterLine = d.line(1, b)
+	}
+	d.changes = append(d.changes, c)
+}
+
+// line returns the line number "n" came from in the before (0) or after (1) capture.
+func (d *differ) line(which int, n node) int {
+	if n.line > 0 {
+		return n.line
+	}
+	if n.v.IsValid() && n.v.Type() == recordType {
+		if l := recordLine(n.v.Interface().(*halfpike.Record)); l > 0 {
+			return l
+		}
+	}
+
+	prov := d.prov[which]
+	if src, ok := prov[n.path]; ok {
+		return src.LineNum
+	}
+	// Paths are matched without regard to case, as Parser.MatchInto() records a field at the name
+	// of its submatch, which only needs to match the field name without regard to case.
+	path := strings.ToLower(n.path)
+	for p, src := range prov {
+		if strings.ToLower(p) == path {
+			return src.LineNum
+		}
+	}
+	// A value that was not recorded itself, such as a struct, starts at the first line any of
+	// the values within it came from.
+	min := 0
+	for p, src := range prov {
+		p = strings.ToLower(p)
+		if path != "" && !strings.HasPrefix(p, path+".") && !strings.HasPrefix(p, path+"[") {
+			continue
+		}
+		if src.LineNum > 0 && (min == 0 || src.LineNum < min) {
+			min = src.LineNum
+		}
+	}
+	return min
+}
+
+// recordLine returns the first line the fields of "rec" came from.
+func recordLine(rec *halfpike.Record) int {
+	min := 0
+	for _, f := range rec.Fields() {
+		if f.LineNum > 0 && (min == 0 || f.LineNum < min) {
+			min = f.LineNum
+		}
+	}
+	return min
+}
+
+// keyNodes returns the keys of the elements in a list in order and a map of key to element.
+func keyNodes(path, which string, fields []string, nodes []node) ([]string, map[string]node, error) {
+	var keys []string
+	byKey := map[string]node{}
+	for i, n := range nodes {
+		k, err := key(fields, deref(n.v))
+		if err != nil {
+			return nil, nil, fmt.Errorf("diff.Compare(): %s[%d] in %s: %w", path, i, which, err)
+		}
+		if _, ok := byKey[k]; ok {
+			return nil, nil, fmt.Errorf("diff.Compare(): %s[%d] in %s: more than one element has key [%s]", path, i, which, k)
+		}
+		keys = append(keys, k)
+		byKey[k] = n
+	}
+	return keys, byKey, nil
+}
+
+// key returns the identity of "v", such as "PeerIP=10.0.0.1".
+func key(fields []string, v reflect.Value) (string, error) {
+	if !v.IsValid() {
+		return "", fmt.Errorf("element is nil")
+	}
+
+	parts := make([]string, 0, len(fields))
+	for _, f := range fields {
+		var fv interface{}
+		if v.Type() == recordType {
+			val, ok := v.Interface().(*halfpike.Record).Get(f)
+			if !ok {
+				return "", fmt.Errorf("Record has no key field %q", f)
+			}
+			fv = val.V
+		} else if fieldV := deref(v.FieldByName(f)); fieldV.IsValid() {
+			fv = fieldV.Interface()
+		}
+		parts = append(parts, fmt.Sprintf("%s=%v", f, fv))
+	}
+	return strings.Join(parts, ","), nil
+}
+
+func recordNodes(parent node, recs []*halfpike.Record) []node {
+	nodes := make([]node, 0, len(recs))
+	for i, rec := range recs {
+		nodes = append(nodes, node{v: reflect.ValueOf(rec), path: fmt.Sprintf("%s[%d]", parent.path, i)})
+	}
+	return nodes
+}
+
+func valueNodes(parent node) []node {
+	vals := parent.v.Interface().([]halfpike.Value)
+	nodes := make([]node, 0, len(vals))
+	for i, v := range vals {
+		nodes = append(nodes, node{v: reflect.ValueOf(v.V), path: fmt.Sprintf("%s[%d]", parent.path, i), line: v.LineNum})
+	}
+	return nodes
+}
+
+func elemNodes(parent node) []node {
+	nodes := make([]node, 0, parent.v.Len())
+	for i := 0; i < parent.v.Len(); i++ {
+		nodes = append(nodes, node{v: parent.v.Index(i), path: fmt.Sprintf("%s[%d]", parent.path, i)})
+	}
+	return nodes
+}
+
+// deref returns the value that "v" points to, or an invalid Value if it is nil. A *Record or
+// *RecordSet is not dereferenced.
+func deref(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		if v.Type() == recordType || v.Type() == recordSetType {
+			return v
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// isLeaf reports if values of type "t" are compared as a whole instead of by what is in them.
+func isLeaf(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Struct:
+		if t == recordType.Elem() || t == recordSetType.Elem() {
+			return false
+		}
+		// Such as time.Time.
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).PkgPath == "" {
+				return false
+			}
+		}
+		return true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		// Such as net.IP.
+		if t.Implements(stringerType) || t.Implements(marshalerType) {
+			return true
+		}
+		return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+	case reflect.Ptr:
+		return t != recordType && t != recordSetType
+	}
+	return true
+}
+
+// diffable reports if a field of type "t" holds data that can be compared.
+func diffable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return false
+	}
+	return true
+}
+
+// equal reports if "a" and "b" are equal. A type with an Equal() method, such as time.Time or net.IP,
+// is compared with it.
+func equal(a, b reflect.Value) bool {
+	if a.Type() != b.Type() {
+		return false
+	}
+	if m := a.MethodByName("Equal"); m.IsValid() {
+		mt := m.Type()
+		if mt.NumIn() == 1 && mt.In(0) == b.Type() && mt.NumOut() == 1 && mt.Out(0).Kind() == reflect.Bool {
+			return m.Call([]reflect.Value{b})[0].Bool()
+		}
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
+
+// value returns the value stored in a Change for "v".
+func value(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Type() == valuesType {
+		return plain(v.Interface().([]halfpike.Value))
+	}
+	return v.Interface()
+}
+
+// plain converts a Record list into a []interface{}, so that it is output as a JSON array of values.
+func plain(vals []halfpike.Value) []interface{} {
+	out := make([]interface{}, 0, len(vals))
+	for _, v := range vals {
+		if l, ok := v.V.([]halfpike.Value); ok {
+			out = append(out, plain(l))
+			continue
+		}
+		out = append(out, v.V)
+	}
+	return out
+}
+
+// format formats a value in a Change for Report(). Values that hold other values are not shown,
+// as their changes are listed separately or the path says what they are.
+func format(v interface{}) string {
+	if v == nil {
+		return "nil"
+	}
+	if s, ok := v.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	rv := deref(reflect.ValueOf(v))
+	switch {
+	case !rv.IsValid():
+		return "nil"
+	case rv.Type() == recordType || rv.Type() == recordSetType:
+		return "{...}"
+	case !isLeaf(rv.Type()):
+		if rv.Kind() == reflect.Struct || rv.Kind() == reflect.Map {
+			return "{...}"
+		}
+		return "[...]"
+	}
+	return fmt.Sprint(v)
+}
+
+func joinPath(path, name string) string {
+	switch {
+	case path == "":
+		return name
+	case name == "":
+		return path
+	}
+	return path + "." + name
+}
@@ -0,0 +1,314 @@
+package diff
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/johnsiilver/halfpike"
+	"github.com/kylelemons/godebug/pretty"
+)
+
+type table struct {
+	Name     string
+	Received int
+}
+
+type peer struct {
+	PeerIP  net.IP
+	PeerAS  int
+	State   string
+	Flapped time.Time
+	Tables  []*table
+	Options map[string]bool
+}
+
+type peers struct {
+	Peers []*peer
+
+	// unexported is not compared.
+	unexported int
+}
+
+func newPeers() *peers {
+	return &peers{
+		Peers: []*peer{
+			{
+				PeerIP:  net.ParseIP("10.0.0.1"),
+				PeerAS:  65001,
+				State:   "Established",
+				Flapped: time.Date(2026, 10, 16, 12, 53, 4, 0, time.UTC),
+				Tables:  []*table{{Name: "inet.0", Received: 10}},
+				Options: map[string]bool{"Preference": true},
+			},
+			{
+				PeerIP: net.ParseIP("10.0.0.2"),
+				PeerAS: 65002,
+				State:  "Active",
+			},
+		},
+	}
+}
+
+func TestCompare(t *testing.T) {
+	keys := []Option{WithKey(peer{}, "PeerIP"), WithKey(&table{}, "Name")}
+
+	tests := []struct {
+		desc   string
+		change func(p *peers)
+		opts   []Option
+		want   ChangeSet
+	}{
+		{
+			desc:   "No changes",
+			change: func(p *peers) { p.unexported = 1 },
+			opts:   keys,
+		},
+		{
+			desc: "Reordered with keys is not a change",
+			change: func(p *peers) {
+				p.Peers[0], p.Peers[1] = p.Peers[1], p.Peers[0]
+			},
+			opts: keys,
+		},
+		{
+			desc: "Equal times in another location are not a change",
+			change: func(p *peers) {
+				p.Peers[0].Flapped = p.Peers[0].Flapped.In(time.FixedZone("PDT", -7*3600))
+			},
+			opts: keys,
+		},
+		{
+			desc: "Changed fields",
+			change: func(p *peers) {
+				p.Peers[1].State = "Established"
+				p.Peers[0].Tables[0].Received = 0
+				p.Peers[0].Options["Preference"] = false
+			},
+			opts: keys,
+			want: ChangeSet{
+				{Op: Changed, Path: "Peers[PeerIP=10.0.0.1].Tables[Name=inet.0].Received", Before: 10, After: 0},
+				{Op: Changed, Path: "Peers[PeerIP=10.0.0.1].Options[Preference]", Before: true, After: false},
+				{Op: Changed, Path: "Peers[PeerIP=10.0.0.2].State", Before: "Active", After: "Established"},
+			},
+		},
+		{
+			desc: "Added and removed",
+			change: func(p *peers) {
+				p.Peers[0].Tables = append(p.Peers[0].Tables, &table{Name: "inet6.0"})
+				p.Peers[0].Options["Multipath"] = true
+				p.Peers[1] = &peer{PeerIP: net.ParseIP("10.0.0.3"), PeerAS: 65003}
+			},
+			opts: keys,
+			want: ChangeSet{
+				{Op: Added, Path: "Peers[PeerIP=10.0.0.1].Tables[Name=inet6.0]", After: table{Name: "inet6.0"}},
+				{Op: Added, Path: "Peers[PeerIP=10.0.0.1].Options[Multipath]", After: true},
+				{Op: Removed, Path: "Peers[PeerIP=10.0.0.2]", Before: peer{PeerIP: net.ParseIP("10.0.0.2"), PeerAS: 65002, State: "Active"}},
+				{Op: Added, Path: "Peers[PeerIP=10.0.0.3]", After: peer{PeerIP: net.ParseIP("10.0.0.3"), PeerAS: 65003}},
+			},
+		},
+		{
+			desc: "Without keys, lists are compared by position",
+			change: func(p *peers) {
+				p.Peers = p.Peers[1:]
+			},
+			want: ChangeSet{
+				{Op: Changed, Path: "Peers[0].PeerIP", Before: net.ParseIP("10.0.0.1"), After: net.ParseIP("10.0.0.2")},
+				{Op: Changed, Path: "Peers[0].PeerAS", Before: 65001, After: 65002},
+				{Op: Changed, Path: "Peers[0].State", Before: "Established", After: "Active"},
+				{Op: Changed, Path: "Peers[0].Flapped", Before: time.Date(2026, 10, 16, 12, 53, 4, 0, time.UTC), After: time.Time{}},
+				{Op: Removed, Path: "Peers[0].Tables[0]", Before: table{Name: "inet.0", Received: 10}},
+				{Op: Removed, Path: "Peers[0].Options[Preference]", Before: true},
+				{Op: Removed, Path: "Peers[1]", Before: peer{PeerIP: net.ParseIP("10.0.0.2"), PeerAS: 65002, State: "Active"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		after := newPeers()
+		test.change(after)
+
+		got, err := Compare(newPeers(), after, test.opts...)
+		if err != nil {
+			t.Errorf("TestCompare(%s): got err == %s", test.desc, err)
+			continue
+		}
+		if diff := pretty.Compare(test.want, got); diff != "" {
+			t.Errorf("TestCompare(%s): -want/+got:\n%s", test.desc, diff)
+		}
+	}
+}
+
+func TestCompareErrors(t *testing.T) {
+	dup := newPeers()
+	dup.Peers[1].PeerIP = dup.Peers[0].PeerIP
+
+	tests := []struct {
+		desc          string
+		before, after interface{}
+		opts          []Option
+	}{
+		{desc: "Different types", before: newPeers(), after: peers{}},
+		{desc: "Key is not a struct", before: newPeers(), after: newPeers(), opts: []Option{WithKey(1, "PeerIP")}},
+		{desc: "Key has no fields", before: newPeers(), after: newPeers(), opts: []Option{WithKey(peer{})}},
+		{desc: "Key field does not exist", before: newPeers(), after: newPeers(), opts: []Option{WithKey(peer{}, "IP")}},
+		{desc: "Duplicate key", before: newPeers(), after: dup, opts: []Option{WithKey(peer{}, "PeerIP")}},
+		{desc: "Record has no key field", before: records(t), after: records(t), opts: []Option{WithRecordKey("", "ifname")}},
+	}
+
+	for _, test := range tests {
+		if _, err := Compare(test.before, test.after, test.opts...); err == nil {
+			t.Errorf("TestCompareErrors(%s): got err == nil, want err != nil", test.desc)
+		}
+	}
+}
+
+// records returns a RecordSet for the output of a command, where each interface record was found
+// at line 10*(interface number), such as ge-0/0/1 at line 10.
+func records(t *testing.T, change ...func(rs *halfpike.RecordSet)) *halfpike.RecordSet {
+	t.Helper()
+
+	rs := &halfpike.RecordSet{}
+	for i := 1; i <= 2; i++ {
+		line := i * 10
+		rec := rs.New()
+		must(t, rec.Set("name", fmt.Sprintf("ge-0/0/%d", i), line))
+		must(t, rec.Set("mtu", 1514, line+1))
+		unit := halfpike.NewRecord()
+		must(t, unit.Set("unit", 0, line+2))
+		must(t, unit.Set("family", "inet", line+3))
+		must(t, rec.Append("units", unit, line+2))
+	}
+	for _, c := range change {
+		c(rs)
+	}
+	return rs
+}
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCompareRecords(t *testing.T) {
+	keys := []Option{WithRecordKey("", "name"), WithRecordKey("units", "unit")}
+
+	after := records(t, func(rs *halfpike.RecordSet) {
+		rs.Records[0], rs.Records[1] = rs.Records[1], rs.Records[0]
+		must(t, rs.Records[1].Set("mtu", 9192, 25))
+		unit := halfpike.NewRecord()
+		must(t, unit.Set("unit", 100, 28))
+		must(t, rs.Records[1].Append("units", unit, 28))
+		rs.Records = rs.Records[1:]
+
+		rec := rs.New()
+		must(t, rec.Set("name", "ge-0/0/3", 30))
+		must(t, rec.Append("addresses", "10.0.0.1", 31))
+	})
+
+	got, err := Compare(records(t), after, keys...)
+	if err != nil {
+		t.Fatalf("TestCompareRecords: got err == %s", err)
+	}
+
+	want := []string{
+		"~ [name=ge-0/0/1].mtu: 1514 -> 9192 (before line 11, after line 25)",
+		"+ [name=ge-0/0/1].units[unit=100]: {...} (after line 28)",
+		"- [name=ge-0/0/2]: {...} (before line 20)",
+		"+ [name=ge-0/0/3]: {...} (after line 30)",
+	}
+	var gotLines []string
+	for _, c := range got {
+		gotLines = append(gotLines, c.String())
+	}
+	if diff := pretty.Compare(want, gotLines); diff != "" {
+		t.Errorf("TestCompareRecords: -want/+got:\n%s", diff)
+	}
+
+	b, err := json.Marshal(got[:2])
+	if err != nil {
+		t.Fatalf("TestCompareRecords: json.Marshal() got err == %s", err)
+	}
+	wantJSON := `[{"op":"changed","path":"[name=ge-0/0/1].mtu","before":1514,"after":9192,"beforeLine":11,"afterLine":25},` +
+		`{"op":"added","path":"[name=ge-0/0/1].units[unit=100]","after":{"unit":100},"afterLine":28}]`
+	if string(b) != wantJSON {
+		t.Errorf("TestCompareRecords(JSON): got:\n%s\nwant:\n%s", b, wantJSON)
+	}
+}
+
+type captured struct {
+	Peers []*capturedPeer
+}
+
+type capturedPeer struct {
+	PeerIP string
+	PeerAS int
+	State  string
+}
+
+var peerRE = regexp.MustCompile(`^Peer: (?P<peerip>\S+) AS (?P<peeras>\d+) State: (?P<state>\S+)`)
+
+func (c *captured) Start(ctx context.Context, p *halfpike.Parser) halfpike.ParseFn {
+	for {
+		line := p.Next()
+		if p.EOF(line) {
+			return nil
+		}
+		if !peerRE.MatchString(line.Raw) {
+			continue
+		}
+		cp := &capturedPeer{}
+		if err := p.MatchInto(fmt.Sprintf("Peers[%d]", len(c.Peers)), peerRE, line, cp); err != nil {
+			return p.Errorf("%s", err)
+		}
+		c.Peers = append(c.Peers, cp)
+	}
+}
+
+func (c *captured) Validate() error {
+	return nil
+}
+
+func TestCompareProvenance(t *testing.T) {
+	before := `
+Peer: 10.0.0.1 AS 65001 State: Established
+Peer: 10.0.0.2 AS 65002 State: Active
+`
+	after := `
+Peer: 10.0.0.2 AS 65002 State: Established
+
+Peer: 10.0.0.3 AS 65003 State: Active
+`
+
+	var parsed [2]*captured
+	var prov [2]halfpike.Provenance
+	for i, content := range []string{before, after} {
+		parsed[i], prov[i] = &captured{}, halfpike.Provenance{}
+		if err := halfpike.Parse(context.Background(), content, parsed[i], halfpike.WithProvenance(prov[i])); err != nil {
+			t.Fatalf("TestCompareProvenance: got err == %s", err)
+		}
+	}
+
+	got, err := Compare(parsed[0], parsed[1], WithKey(capturedPeer{}, "PeerIP"), WithProvenance(prov[0], prov[1]))
+	if err != nil {
+		t.Fatalf("TestCompareProvenance: got err == %s", err)
+	}
+
+	want := `- Peers[PeerIP=10.0.0.1]: {...} (before line 1)
+~ Peers[PeerIP=10.0.0.2].State: "Active" -> "Established" (before line 2, after line 1)
++ Peers[PeerIP=10.0.0.3]: {...} (after line 3)
+`
+	if diff := pretty.Compare(want, got.Report()); diff != "" {
+		t.Errorf("TestCompareProvenance: -want/+got:\n%s", diff)
+	}
+
+	if got, _ := Compare(parsed[0], parsed[0]); got.Report() != "no changes\n" {
+		t.Errorf("TestCompareProvenance(no changes): got %q, want %q", got.Report(), "no changes\n")
+	}
+}
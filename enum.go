@@ -0,0 +1,156 @@
+package halfpike
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ErrUnknownValue is returned (wrapped) by an Enum when text is not one of its values.
+var ErrUnknownValue = errors.New("not a known value")
+
+// Enum maps the text in device output to values of T, such as "Established" to a BGPState. It replaces
+// the map[string]T lookups and the hand written "not a known state" errors that parsers need for every
+// enumerated value. An Enum is built once, usually in a package variable, and is then safe for
+// concurrent use:
+//
+//	var toState = halfpike.NewEnum[BGPState]("BGP state").
+//		Fold().
+//		TrimPunct().
+//		Add("Established", NSEstablished).
+//		Add("Active", NSActive).
+//		Add("OpenSent", NSOpenSent, "Open-Sent")
+//
+//	state, err := toState.Item(line, 3) // "Established," works
+//
+// The first name added for a value is its canonical name, which String() returns so that the value
+// can be written back out as text.
+type Enum[T comparable] struct {
+	kind    string
+	fold    bool
+	punct   bool
+	entries []enumEntry[T]
+	values  map[string]T
+	names   map[T]string
+}
+
+type enumEntry[T comparable] struct {
+	name string
+	v    T
+}
+
+// NewEnum creates an Enum. "kind" describes the values, such as "interface state", and is used in errors.
+func NewEnum[T comparable](kind string) *Enum[T] {
+	return &Enum[T]{kind: kind, values: map[string]T{}, names: map[T]string{}}
+}
+
+// Fold causes lookups to ignore case.
+func (e *Enum[T]) Fold() *Enum[T] {
+	e.fold = true
+	e.rebuild()
+	return e
+}
+
+// TrimPunct causes lookups to ignore punctuation and symbols at the start and end of text, such as
+// the trailing comma in "Enabled," or the brackets in "<Sync>".
+func (e *Enum[T]) TrimPunct() *Enum[T] {
+	e.punct = true
+	e.rebuild()
+	return e
+}
+
+// Add adds a value with its canonical name and any aliases. It panics if a name is already in the Enum,
+// as this is a programming error, like a bad regexp.MustCompile().
+func (e *Enum[T]) Add(name string, v T, aliases ...string) *Enum[T] {
+	e.add(name, v)
+	for _, a := range aliases {
+		e.add(a, v)
+	}
+	return e
+}
+
+func (e *Enum[T]) add(name string, v T) {
+	key := e.key(name)
+	if _, ok := e.values[key]; ok {
+		panic(fmt.Sprintf("halfpike.Enum(%s): %q was added twice", e.kind, name))
+	}
+	e.entries = append(e.entries, enumEntry[T]{name: name, v: v})
+	e.values[key] = v
+	if _, ok := e.names[v]; !ok {
+		e.names[v] = name
+	}
+}
+
+// rebuild re-keys the Enum after an option has changed how text is matched.
+func (e *Enum[T]) rebuild() {
+	entries := e.entries
+	e.entries = nil
+	e.values = map[string]T{}
+	e.names = map[T]string{}
+	for _, ent := range entries {
+		e.add(ent.name, ent.v)
+	}
+}
+
+func (e *Enum[T]) key(s string) string {
+	if e.punct {
+		s = strings.TrimFunc(s, func(r rune) bool { return unicode.IsPunct(r) || unicode.IsSymbol(r) })
+	}
+	if e.fold {
+		s = strings.ToLower(s)
+	}
+	return s
+}
+
+// Lookup returns the value for "s". If "s" is not in the Enum, the error wraps ErrUnknownValue and
+// lists the valid values.
+func (e *Enum[T]) Lookup(s string) (T, error) {
+	if v, ok := e.values[e.key(s)]; ok {
+		return v, nil
+	}
+	var zero T
+	return zero, fmt.Errorf("%s %q is %w, valid values are %s", e.kind, s, ErrUnknownValue, e.valid())
+}
+
+// Item returns the value for line.Items[index]. Errors include the position of the Item.
+func (e *Enum[T]) Item(line Line, index int) (T, error) {
+	if index < 0 || index >= len(line.Items) {
+		var zero T
+		return zero, fmt.Errorf("[Line %d, Item %d]: no Item for %s, line has %d Items", line.LineNum, index, e.kind, len(line.Items))
+	}
+	v, err := e.Lookup(line.Items[index].Val)
+	if err != nil {
+		return v, fmt.Errorf("[Line %d, Item %d]: %w", line.LineNum, index, err)
+	}
+	return v, nil
+}
+
+// String returns the canonical name of "v". If "v" is not in the Enum, "kind(v)" is returned. This
+// can implement fmt.Stringer for T:
+//
+//	func (b BGPState) String() string { return toState.String(b) }
+func (e *Enum[T]) String(v T) string {
+	if name, ok := e.names[v]; ok {
+		return name
+	}
+	// %#v, as %v would call T.String() if it is implemented with this method.
+	return fmt.Sprintf("%s(%#v)", e.kind, v)
+}
+
+// Names returns the names in the Enum, including aliases, in the order they were added.
+func (e *Enum[T]) Names() []string {
+	names := make([]string, 0, len(e.entries))
+	for _, ent := range e.entries {
+		names = append(names, ent.name)
+	}
+	return names
+}
+
+func (e *Enum[T]) valid() string {
+	names := e.Names()
+	for i, n := range names {
+		names[i] = fmt.Sprintf("%q", n)
+	}
+	return "[" + strings.Join(names, ", ") + "]"
+}
@@ -0,0 +1,136 @@
+package halfpike
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type enumState int
+
+const (
+	esUnknown enumState = iota
+	esUp
+	esDown
+	esTesting
+)
+
+var esEnum = NewEnum[enumState]("state").
+	Add("Up", esUp).
+	Add("Down", esDown, "Dn").
+	Add("Testing", esTesting)
+
+func (e enumState) String() string {
+	return esEnum.String(e)
+}
+
+func TestEnumLookup(t *testing.T) {
+	tests := []struct {
+		desc    string
+		enum    *Enum[enumState]
+		in      string
+		want    enumState
+		wantErr bool
+	}{
+		{desc: "Exact", enum: esEnum, in: "Up", want: esUp},
+		{desc: "Alias", enum: esEnum, in: "Dn", want: esDown},
+		{desc: "Case is strict by default", enum: esEnum, in: "up", wantErr: true},
+		{desc: "Punctuation is strict by default", enum: esEnum, in: "Up,", wantErr: true},
+		{desc: "Unknown", enum: esEnum, in: "Dormant", wantErr: true},
+		{
+			desc: "Fold",
+			enum: NewEnum[enumState]("state").Add("Up", esUp).Fold(),
+			in:   "UP",
+			want: esUp,
+		},
+		{
+			desc: "TrimPunct",
+			enum: NewEnum[enumState]("state").TrimPunct().Add("Up", esUp),
+			in:   "<Up>,",
+			want: esUp,
+		},
+		{
+			desc: "Fold and TrimPunct",
+			enum: NewEnum[enumState]("state").Fold().TrimPunct().Add("Testing", esTesting),
+			in:   "testing,",
+			want: esTesting,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := test.enum.Lookup(test.in)
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestEnumLookup(%s): got err == nil, want err != nil", test.desc)
+			continue
+		case err != nil && !test.wantErr:
+			t.Errorf("TestEnumLookup(%s): got err == %s, want err == nil", test.desc, err)
+			continue
+		case err != nil:
+			if !errors.Is(err, ErrUnknownValue) {
+				t.Errorf("TestEnumLookup(%s): got err == %s, want ErrUnknownValue", test.desc, err)
+			}
+			continue
+		}
+		if got != test.want {
+			t.Errorf("TestEnumLookup(%s): got %v, want %v", test.desc, got, test.want)
+		}
+	}
+}
+
+func TestEnumItem(t *testing.T) {
+	line := firstLine(t, "Physical interface: ge-3/0/2, Enabled, Physical link is Dormant\n")
+	e := NewEnum[enumState]("interface status").Add("Up", esUp).Add("Down", esDown)
+
+	_, err := e.Item(line, 7)
+	if err == nil {
+		t.Fatalf("TestEnumItem: got err == nil, want err != nil")
+	}
+	for _, want := range []string{"[Line 0, Item 7]", `"Dormant"`, `"Up", "Down"`, "interface status"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("TestEnumItem: got err == %s, want it to contain %s", err, want)
+		}
+	}
+
+	if _, err := e.Item(line, 20); err == nil {
+		t.Errorf("TestEnumItem(index out of range): got err == nil, want err != nil")
+	}
+}
+
+func TestEnumString(t *testing.T) {
+	tests := []struct {
+		v    enumState
+		want string
+	}{
+		{esUp, "Up"},
+		{esDown, "Down"},
+		{esUnknown, "state(0)"},
+	}
+
+	for _, test := range tests {
+		if got := test.v.String(); got != test.want {
+			t.Errorf("TestEnumString(%d): got %q, want %q", int(test.v), got, test.want)
+		}
+	}
+
+	// Values must round trip through their text.
+	for _, v := range []enumState{esUp, esDown, esTesting} {
+		got, err := esEnum.Lookup(v.String())
+		if err != nil || got != v {
+			t.Errorf("TestEnumString(round trip %s): got (%v, %v)", v, got, err)
+		}
+	}
+
+	if diff := strings.Join(esEnum.Names(), ","); diff != "Up,Down,Dn,Testing" {
+		t.Errorf("TestEnumString: Names() got %s, want Up,Down,Dn,Testing", diff)
+	}
+}
+
+func TestEnumDuplicate(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("TestEnumDuplicate: Add() of a duplicate did not panic")
+		}
+	}()
+	NewEnum[enumState]("state").Add("Up", esUp).Add("up", esDown).Fold()
+}
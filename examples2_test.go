@@ -141,15 +141,15 @@ func (i *Interfaces) findInterface(ctx context.Context, p *Parser) ParseFn {
 	return i.phyInter
 }
 
-var toInterState = map[string]InterState{
-	"Enabled,":  IStateEnabled,
-	"Disabled,": IStateDisabled,
-}
+// TrimPunct() lets "Enabled," match "Enabled".
+var toInterState = NewEnum[InterState]("interface state").
+	TrimPunct().
+	Add("Enabled", IStateEnabled).
+	Add("Disabled", IStateDisabled)
 
-var toStatus = map[string]InterStatus{
-	"Up":   IStatUp,
-	"Down": IStatDown,
-}
+var toStatus = NewEnum[InterStatus]("interface status").
+	Add("Up", IStatUp).
+	Add("Down", IStatDown)
 
 // Physical interface: ge-3/0/2, Enabled, Physical link is Up
 func (i *Interfaces) phyInter(ctx context.Context, p *Parser) ParseFn {
@@ -166,25 +166,25 @@ func (i *Interfaces) phyInter(ctx context.Context, p *Parser) ParseFn {
 		return i.errorf("error parsing the name into blade/pic/port: %s", err)
 	}
 
-	state, ok := toInterState[line.Items[stateIndex].Val]
-	if !ok {
-		return i.errorf("error parsing the interface state, got %s is not a known state", line.Items[stateIndex].Val)
+	state, err := toInterState.Item(line, stateIndex)
+	if err != nil {
+		return i.errorf("error parsing the interface state: %s", err)
 	}
 	i.current().State = state
 
-	status, ok := toStatus[line.Items[statusIndex].Val]
-	if !ok {
-		return i.errorf("error parsing the interface status, got %s which is not a known status", line.Items[statusIndex].Val)
+	status, err := toStatus.Item(line, statusIndex)
+	if err != nil {
+		return i.errorf("error parsing the interface status: %s", err)
 	}
 	i.current().Status = status
 	return i.findLinkLevel
 }
 
-var toLinkLevel = map[string]LinkLevel{
-	"52,":       LL52,
-	"ppp,":      LLPPP,
-	"ethernet,": LLEthernet,
-}
+var toLinkLevel = NewEnum[LinkLevel]("link level type").
+	TrimPunct().
+	Add("52", LL52).
+	Add("ppp", LLPPP).
+	Add("ethernet", LLEthernet)
 
 // Link-level type: 52, MTU: 1522, Speed: 1000mbps, Loopback: Disabled,
 func (i *Interfaces) findLinkLevel(ctx context.Context, p *Parser) ParseFn {
@@ -202,9 +202,9 @@ func (i *Interfaces) findLinkLevel(ctx context.Context, p *Parser) ParseFn {
 		return i.errorf("did not find Link-level before finding the next interface")
 	}
 
-	ll, ok := toLinkLevel[line.Items[llTypeIndex].Val]
-	if !ok {
-		return i.errorf("unknown link level type: %s", line.Items[llTypeIndex].Val)
+	ll, err := toLinkLevel.Item(line, llTypeIndex)
+	if err != nil {
+		return i.errorf("%s", err)
 	}
 	i.current().LinkLevel = ll
 
@@ -286,28 +286,28 @@ func (i *Interfaces) current() *Interface {
 // Interface is a brief decription of a network interface.
 type Interface struct {
 	// VendorDesc is the name a vendor gives the interface, like ge-10/2/1.
-	VendorDesc string
+	VendorDesc string `hp:"required"`
 	// Blade is the blade in the routing chassis.
-	Blade int
+	Blade int `hp:"min=0"`
 	// Pic is the pic position on the blade.
-	Pic int
+	Pic int `hp:"min=0"`
 	// Port is the port in the pic.
-	Port int
+	Port int `hp:"min=0"`
 	// State is the interface's current state.
-	State InterState
+	State InterState `hp:"required"`
 	// Status is the interface's current status.
-	Status InterStatus
+	Status InterStatus `hp:"required"`
 	// LinkLevel is the type of encapsulation used on the link.
-	LinkLevel LinkLevel
+	LinkLevel LinkLevel `hp:"required"`
 	// MTU is the maximum amount of bytes that can be sent on the frame.
-	MTU int
+	MTU int `hp:"min=0"`
 	// Speed is the interface's speed in bits per second.
-	Speed int
+	Speed int `hp:"min=0"`
 
 	initCalled bool
 }
 
-// init initializes Interface.
+// init initializes Interface. The -1 values let Validate() detect fields that were not set.
 func (i *Interface) init() {
 	i.Blade = -1
 	i.Pic = -1
@@ -322,32 +322,5 @@ func (i *Interface) Validate() error {
 	if !i.initCalled {
 		return fmt.Errorf("an Interface did not have init() called before storing data")
 	}
-
-	if i.VendorDesc == "" {
-		return fmt.Errorf("an Interface did not have VendorDesc assigned")
-	}
-
-	switch -1 {
-	case i.Blade:
-		return fmt.Errorf("Interface(%s): Blade was not set", i.VendorDesc)
-	case i.Pic:
-		return fmt.Errorf("Interface(%s): Pic was not set", i.VendorDesc)
-	case i.Port:
-		return fmt.Errorf("Interface(%s): Port was not set", i.VendorDesc)
-	case i.MTU:
-		return fmt.Errorf("Interface(%s): MTU was not set", i.VendorDesc)
-	case i.Speed:
-		return fmt.Errorf("Interface(%s): Speed was not set", i.VendorDesc)
-	}
-
-	switch {
-	case i.State == IStateUnknown:
-		return fmt.Errorf("Interface(%s): State was not set", i.VendorDesc)
-	case i.Status == IStatUnknown:
-		return fmt.Errorf("Interface(%s): Status was not set", i.VendorDesc)
-	case i.LinkLevel == LLUnknown:
-		return fmt.Errorf("Interface(%s): LinkLevel was not set", i.VendorDesc)
-	}
-
-	return nil
+	return ValidateStruct(i)
 }
@@ -280,21 +280,19 @@ func (b *BGPNeighbors) findPeer(ctx context.Context, p *Parser) ParseFn {
 }
 
 // toPeerType converts a string representing the peer type to an enumerated value.
-var toPeerType = map[string]PeerType{
-	"Internal": PTInternal,
-	"External": PTExternal,
-}
+var toPeerType = NewEnum[PeerType]("peer type").
+	Add("Internal", PTInternal).
+	Add("External", PTExternal)
 
 // toState converts a string representing the a BGP state to an enumerated value.
-var toState = map[string]BGPState{
-	"Active":                 NSActive,
-	"Connect":                NSConnect,
-	"Established":            NSEstablished,
-	"Idle":                   NSIdle,
-	"OpenConfirm":            NSOpenConfirm,
-	"OpenSent":               NSOpenSent,
-	"route reflector client": NSRRClient,
-}
+var toState = NewEnum[BGPState]("BGP state").
+	Add("Active", NSActive).
+	Add("Connect", NSConnect).
+	Add("Established", NSEstablished).
+	Add("Idle", NSIdle).
+	Add("OpenConfirm", NSOpenConfirm).
+	Add("OpenSent", NSOpenSent).
+	Add("route reflector client", NSRRClient)
 
 // Type: External    State: Established    Flags: <Sync>
 func (b *BGPNeighbors) typeState(ctx context.Context, p *Parser) ParseFn {
@@ -311,15 +309,15 @@ func (b *BGPNeighbors) typeState(ctx context.Context, p *Parser) ParseFn {
 		return b.errorf("did not have the expected 'Type' and 'State' declarations following peer line")
 	}
 
-	t, ok := toPeerType[line.Items[peerType].Val]
-	if !ok {
-		return b.errorf("Type was not 'Internal' or 'External', was %s", line.Items[peerType].Val)
+	t, err := toPeerType.Item(line, peerType)
+	if err != nil {
+		return b.errorf("%s", err)
 	}
 	rec.Type = t
 
-	s, ok := toState[line.Items[state].Val]
-	if !ok {
-		return b.errorf("BGP State was not one of the accepted types (Active, Connect, ...), was %s", line.Items[state].Val)
+	s, err := toState.Item(line, state)
+	if err != nil {
+		return b.errorf("%s", err)
 	}
 	rec.State = s
 
@@ -336,9 +334,9 @@ func (b *BGPNeighbors) lastState(ctx context.Context, p *Parser) ParseFn {
 		return b.errorf("did not have the expected 'Last State:', got %#+v", line)
 	}
 
-	s, ok := toState[line.Items[2].Val]
-	if !ok {
-		return b.errorf("BGP last state was not one of the accepted types (Active, Connect, ...), was %s", line.Items[2].Val)
+	s, err := toState.Item(line, 2)
+	if err != nil {
+		return b.errorf("last state: %s", err)
 	}
 	rec.LastState = s
 	return b.holdTimePref
@@ -444,31 +442,31 @@ func (b *BGPNeighbors) errorf(s string, a ...interface{}) ParseFn {
 // BGPNeighbor provides information about a router's BGP Neighbor.
 type BGPNeighbor struct {
 	// PeerIP is the IP address of the neighbor.
-	PeerIP net.IP
+	PeerIP net.IP `hp:"required"`
 	// PeerPort is the IP port of the peer.
-	PeerPort uint32
+	PeerPort uint32 `hp:"required"`
 	// PeerAS is the peers autonomous system number.
-	PeerAS int
+	PeerAS int `hp:"min=0"`
 	// LocalIP is the IP address on this router the neighbor connects to.
-	LocalIP net.IP
+	LocalIP net.IP `hp:"required"`
 	// LocaPort is the IP port on this router the neighbor connects to.
-	LocalPort uint32
+	LocalPort uint32 `hp:"required"`
 	// LocalAS is the local autonomous system number.
-	LocalAS int
+	LocalAS int `hp:"min=0"`
 	// Type is the type of peer.
-	Type PeerType
+	Type PeerType `hp:"required"`
 	// State is the current state of the BGP peer.
-	State BGPState
+	State BGPState `hp:"required"`
 	// LastState is the previous state of the BGP peer.
-	LastState BGPState
+	LastState BGPState `hp:"required"`
 	// HoldTime is how long to consider the neighbor valid after not hearing a keep alive.
 	HoldTime time.Duration
 	// Preference is the BGP preference value.
-	Preference int
+	Preference int `hp:"min=0"`
 	// PeerID is the ID the peer uses to identify itself.
-	PeerID net.IP
+	PeerID net.IP `hp:"required"`
 	// LocalID is the ID the local router uses to identify itself.
-	LocalID   net.IP
+	LocalID   net.IP `hp:"required"`
 	InetStats map[int]*InetStats
 
 	initCalled bool
@@ -488,61 +486,20 @@ func (b *BGPNeighbor) Validate() error {
 	if !b.initCalled {
 		return fmt.Errorf("internal error: BGPNeighbor.init() was not called")
 	}
-
-	switch {
-	case b.PeerIP == nil:
-		return fmt.Errorf("PeerIP was nil")
-	case b.LocalIP == nil:
-		return fmt.Errorf("LocalIP was nil")
-	case b.PeerID == nil:
-		return fmt.Errorf("PeerID was nil")
-	case b.LocalID == nil:
-		return fmt.Errorf("LocalID was nil")
-	}
-
-	switch uint32(0) {
-	case b.PeerPort:
-		return fmt.Errorf("PeerPort was 0")
-	case b.LocalPort:
-		return fmt.Errorf("LocalPort was 0")
-	}
-
-	switch 0 {
-	case int(b.Type):
-		return fmt.Errorf("Type was not set")
-	case int(b.LastState):
-		return fmt.Errorf("LastState was not set")
-	case int(b.State):
-		return fmt.Errorf("State was not set")
-	}
-
-	switch -1 {
-	case b.Preference:
-		return fmt.Errorf("Preference was not set")
-	case b.LocalAS:
-		return fmt.Errorf("LocalAS was not set")
-	case b.PeerAS:
-		return fmt.Errorf("PeerAS was not set")
-	}
-
-	for _, v := range b.InetStats {
-		if err := v.Validate(); err != nil {
-			return err
-		}
-	}
-	return nil
+	// InetStats are validated with their own Validate().
+	return ValidateStruct(b)
 }
 
 // InetStats contains information about the route table.
 type InetStats struct {
 	ID                 int
-	Bit                int
-	RIBState           RIBState
-	SendState          SendState
-	ActivePrefixes     int
+	Bit                int       `hp:"min=0"`
+	RIBState           RIBState  `hp:"required"`
+	SendState          SendState `hp:"required"`
+	ActivePrefixes     int       `hp:"min=0"`
 	RecvPrefixes       int
-	AcceptPrefixes     int
-	SurpressedPrefixes int
+	AcceptPrefixes     int `hp:"min=0"`
+	SurpressedPrefixes int `hp:"min=0"`
 	AdvertisedPrefixes int
 }
 
@@ -562,24 +519,7 @@ func (b *InetStats) init() {
 
 // Validate implements Validator.
 func (b *InetStats) Validate() error {
-	switch -1 {
-	case b.Bit:
-		return fmt.Errorf("InetStats: Bit was not parsed from the input")
-	case b.ActivePrefixes:
-		return fmt.Errorf("InetStats(Bit==%d): ActivePrefixes was not parsed from the input", b.Bit)
-	case b.AcceptPrefixes:
-		return fmt.Errorf("InetStats(Bit==%d): AcceptPrefixes was not parsed from the input", b.Bit)
-	case b.SurpressedPrefixes:
-		return fmt.Errorf("InetStats(Bit==%d): SurpressedPrefixes was not parsed from the input", b.Bit)
-	}
-
-	switch {
-	case b.RIBState == RSUnknown:
-		return fmt.Errorf("InetStats(Bit==%d): RIBState was unknown, which indicates the parser is broken on input", b.Bit)
-	case b.SendState == RSSendUnknown:
-		return fmt.Errorf("InetStats(Bit==%d): SendState was unknown, which indicates the parser is broken on input", b.Bit)
-	}
-	return nil
+	return ValidateStruct(b)
 }
 
 /*
@@ -635,10 +575,9 @@ func (t *tableStats) start(ctx context.Context, p *Parser) ParseFn {
 	return t.ribState
 }
 
-var toRIBState = map[string]RIBState{
-	"restart is complete": RSComplete,
-	"estart in progress":  RSInProgress,
-}
+var toRIBState = NewEnum[RIBState]("RIB state").
+	Add("restart is complete", RSComplete).
+	Add("estart in progress", RSInProgress)
 
 // RIB State: BGP restart is complete
 func (t *tableStats) ribState(ctx context.Context, p *Parser) ParseFn {
@@ -651,20 +590,19 @@ func (t *tableStats) ribState(ctx context.Context, p *Parser) ParseFn {
 	}
 
 	s := ItemJoin(line, begin, -1)
-	v, ok := toRIBState[s]
-	if !ok {
-		return t.errorf("did not have a valid RIB State, had: %q", s)
+	v, err := toRIBState.Lookup(s)
+	if err != nil {
+		return t.errorf("%s", err)
 	}
 
 	t.stats.RIBState = v
 	return t.sendState
 }
 
-var toSendState = map[string]SendState{
-	"in sync":         RSSendSync,
-	"not in sync":     RSSendNotSync,
-	"not advertising": RSSendNoAdvertise,
-}
+var toSendState = NewEnum[SendState]("send state").
+	Add("in sync", RSSendSync).
+	Add("not in sync", RSSendNotSync).
+	Add("not advertising", RSSendNoAdvertise)
 
 // Send state: in sync
 func (t *tableStats) sendState(ctx context.Context, p *Parser) ParseFn {
@@ -677,9 +615,9 @@ func (t *tableStats) sendState(ctx context.Context, p *Parser) ParseFn {
 	}
 
 	s := ItemJoin(line, begin, -1)
-	v, ok := toSendState[s]
-	if !ok {
-		return t.errorf("did not have recognized Send state, had %s", s)
+	v, err := toSendState.Lookup(s)
+	if err != nil {
+		return t.errorf("%s", err)
 	}
 
 	t.stats.SendState = v
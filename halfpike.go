@@ -20,8 +20,11 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 	"unicode/utf8"
+
+	"github.com/johnsiilver/halfpike/units"
 )
 
 const (
@@ -67,13 +70,16 @@ const (
 type Line struct {
 	// Items are the Item(s) that make up a line.
 	Items []Item
-	// LineNum is the line number in the content this represents, starting at 1.
+	// LineNum is the line number in the content this represents. The first line is 0.
 	LineNum int
 	// Raw is the actual raw string that made up the line.
 	Raw string
 }
 
 // Item represents a token created by the Lexer.
+//
+// The unit conversions, ToBitRate(), ToByteSize(), ToDuration(), ToUptime() and ToPercent(), ignore a
+// trailing comma, as values in device output are often followed by one, such as "Speed: 1000mbps,".
 type Item struct {
 	// Type is the type of item that is stored in .Val.
 	Type ItemType
@@ -120,6 +126,36 @@ func (i Item) ToFloat() (float64, error) {
 	return f, nil
 }
 
+// ToBitRate returns the value as a bit rate, such as "1000mbps" or "10Gbps".
+// See units.ParseBitRate().
+func (i Item) ToBitRate() (units.BitRate, error) {
+	return units.ParseBitRate(strings.TrimSuffix(i.Val, ","))
+}
+
+// ToByteSize returns the value as a size in bytes, such as "1.5K" or "512MB".
+// See units.ParseByteSize().
+func (i Item) ToByteSize() (units.ByteSize, error) {
+	return units.ParseByteSize(strings.TrimSuffix(i.Val, ","))
+}
+
+// ToDuration returns the value as a duration, such as "90s" or "2w3d".
+// See units.ParseDuration().
+func (i Item) ToDuration() (time.Duration, error) {
+	return units.ParseDuration(strings.TrimSuffix(i.Val, ","))
+}
+
+// ToUptime returns the value as an uptime, such as "2w3d" or "04:05:06".
+// For an uptime that spans Items, such as "2w3d 04:05:06", use units.ParseUptime() with ItemJoin().
+func (i Item) ToUptime() (time.Duration, error) {
+	return units.ParseUptime(strings.TrimSuffix(i.Val, ","))
+}
+
+// ToPercent returns the value as a percentage, such as "45%" or "255/255".
+// See units.ParsePercent().
+func (i Item) ToPercent() (float64, error) {
+	return units.ParsePercent(strings.TrimSuffix(i.Val, ","))
+}
+
 // ItemJoin takes a line, the inclusive beginning index and the non-inclusive ending index and
 // joins all the values with a single space between them. -1 for start or end means from the absolute
 // begin or end of the line slice. This will automatically remove the carriage return or EOF items.
@@ -160,6 +196,13 @@ type lexer struct {
 	width   int       // width of last rune read from input.
 	items   chan Item // channel of scanned items.
 	startFn stateFn
+
+	// stop is set when ctx is done, which causes the lexer to stop at the next rune.
+	stop bool
+	// done is closed when run() returns.
+	done chan struct{}
+	// raw holds the raw text of the current line. It is reused between lines and inputs.
+	raw []byte
 }
 
 // newLexer is the constructor for Lexer.
@@ -168,7 +211,7 @@ func newLexer(ctx context.Context, s string, start stateFn) *lexer {
 		panic("start cannot be nil")
 	}
 
-	return &lexer{ctx: ctx, input: s, items: make(chan Item, 10), startFn: start}
+	return &lexer{ctx: ctx, input: s, items: make(chan Item, 10), startFn: start, done: make(chan struct{})}
 }
 
 // Reset resets the Lexer lex argument "s".
@@ -178,10 +221,13 @@ func (l *lexer) reset(s string) {
 	l.pos = 0
 	l.width = 0
 	l.items = make(chan Item, 10)
+	l.stop = false
+	l.done = make(chan struct{})
 }
 
 // run lexes the input by executing state functions until the state is nil.
 func (l *lexer) run() {
+	defer close(l.done)
 	for state := l.startFn; state != nil; {
 		state = state(l)
 	}
@@ -216,8 +262,8 @@ func (l *lexer) addItemsChannel(item Item) {
 	item.raw = strings.TrimLeft(item.raw, "\n")
 	select {
 	case <-l.ctx.Done():
-		// This simply causes the lexer to continue and finish off the content without
-		// blocking on any channel.
+		// No one is receiving, so stop lexing instead of blocking on the channel.
+		l.stop = true
 		return
 	case l.items <- item:
 	}
@@ -245,7 +291,7 @@ func (l *lexer) backup() {
 
 // next returns the next rune in the input.
 func (l *lexer) next() rune {
-	if l.pos >= len(l.input) {
+	if l.stop || l.pos >= len(l.input) {
 		l.width = 0
 		return eof
 	}
@@ -262,12 +308,12 @@ type rawInfo struct {
 
 func untilEOF(l *lexer) stateFn {
 	lineNum := 0
-	raw := strings.Builder{}
+	l.raw = l.raw[:0]
 
 	last := ItemUnknown
 
 	for r := l.next(); true; r = l.next() {
-		raw.WriteRune(r)
+		l.raw = utf8.AppendRune(l.raw, r)
 
 		switch {
 		case r == '\n':
@@ -292,8 +338,8 @@ func untilEOF(l *lexer) stateFn {
 
 			// Emit the carriage return.
 			l.next()
-			last = l.emit(ItemEOL, rawInfo{raw.String(), lineNum})
-			raw.Reset()
+			last = l.emit(ItemEOL, rawInfo{string(l.raw), lineNum})
+			l.raw = l.raw[:0]
 
 			lineNum++
 		case r == eof:
@@ -313,9 +359,9 @@ func untilEOF(l *lexer) stateFn {
 
 			// Emit the EOF.
 			l.next()
-			raw.Reset()
-			l.emit(ItemEOF, rawInfo{raw.String(), lineNum})
-			raw.Reset()
+			l.raw = l.raw[:0]
+			l.emit(ItemEOF, rawInfo{string(l.raw), lineNum})
+			l.raw = l.raw[:0]
 			return nil
 		case unicode.IsSpace(r):
 			switch last {
@@ -344,15 +390,35 @@ func untilEOF(l *lexer) stateFn {
 }
 
 func isInt(s string) bool {
+	if s == "" || !maybeNumber(s[0]) {
+		return false
+	}
 	_, err := strconv.Atoi(s)
 	return err == nil
 }
 
 func isFloat(s string) bool {
+	if s == "" || !maybeNumber(s[0]) {
+		return false
+	}
 	_, err := strconv.ParseFloat(s, 64)
 	return err == nil
 }
 
+// maybeNumber reports if "b" can start a string that strconv parses as a number, including "Inf" and "NaN".
+// Most text fails this test, which avoids the cost of strconv returning an error.
+func maybeNumber(b byte) bool {
+	switch {
+	case b >= '0' && b <= '9':
+		return true
+	}
+	switch b {
+	case '+', '-', '.', 'i', 'I', 'n', 'N':
+		return true
+	}
+	return false
+}
+
 // Validator provides methods to validate that a data type is okay.
 type Validator interface {
 	// Validate indicates if the type validates or not.
@@ -370,27 +436,76 @@ type ParseObject interface {
 	Validator
 }
 
+// Option is an optional argument to Parse().
+type Option func(p *Parser)
+
+// WithProvenance causes the Parser to record where each value assigned through the Parser's
+// helper methods came from into "prov". This can be included in logs and audit trails.
+// See Parser.Provenance() for more information.
+func WithProvenance(prov Provenance) Option {
+	return func(p *Parser) {
+		p.prov = prov
+	}
+}
+
 // Parse starts a lexer that being sending items to a Parser instance. The function or method represented
 // by "start" is called and passed the Parser instance to begin decoding into whatever form you want until
 // a ParseFn returns ParseFn == nil.  If err == nil,
 // the Validator object passed to Parser should have .Validate() called to ensure all data is correct.
-func Parse(ctx context.Context, content string, parseObject ParseObject) error {
-	p, err := newParser(content)
-	if err != nil {
+// If ctx is cancelled or its deadline passes, Parse stops and returns ctx.Err(). No goroutine started
+// by Parse outlives it.
+// Before lexing, a byte order mark is removed, UTF-16 content is decoded and "\r\n" and "\r" line endings
+// are converted to "\n". Content that is not valid UTF-8 returns a *PosError.
+// To reuse a Parser between contents, use NewParser() and Parser.Parse().
+func Parse(ctx context.Context, content string, parseObject ParseObject, options ...Option) error {
+	return NewParser(options...).Parse(ctx, content, parseObject)
+}
+
+// Parse parses "content" into "parseObject" the same as the Parse() function, using the options
+// the Parser was created with. Parse may be called any number of times, but not concurrently. Each
+// call discards the lines, position and error from the previous call while keeping its buffers.
+func (p *Parser) Parse(ctx context.Context, content string, parseObject ParseObject) error {
+	if err := p.load(ctx, content); err != nil {
 		return err
 	}
-	go p.lex.run()
-
-	defer p.cancel()
+	p.start()
+	defer p.Close()
 
+	guard := loopGuard{}
 	for state := parseObject.Start; state != nil; {
-		state = state(ctx, p)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		next, err := p.step(ctx, state)
+		if err != nil {
+			return err
+		}
+		if next != nil {
+			if err := guard.check(p, state); err != nil {
+				return err
+			}
+		}
+		state = next
+	}
+	// A ParseFn may have stopped because cancellation ended the input early.
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
 	if err := p.HasError(); err != nil {
 		return err
 	}
 
+	if p.requireSet {
+		unset, err := p.Unset(parseObject)
+		if err != nil {
+			return err
+		}
+		if len(unset) > 0 {
+			return &UnsetError{Fields: unset}
+		}
+	}
+
 	if err := parseObject.Validate(); err != nil {
 		return err
 	}
@@ -400,9 +515,9 @@ func Parse(ctx context.Context, content string, parseObject ParseObject) error {
 // Parser parses items coming from the Lexer and puts the values into *struct that must satisfy the Validator interface.
 // It provides helper methods for recording an Item directory to a field handling text conversions.  More complex types
 // such as conversion to time.Time or custom objects are not covered. The Parser is created internally
-// when calling the Parse() function.
+// when calling the Parse() function, or can be created with NewParser() to be reused.
 type Parser struct {
-	// ctx is simply used for cancelation and is not derived from anywhere.
+	// ctx is derived from the Context passed to Parse() and is used to stop the lexer.
 	ctx    context.Context
 	cancel context.CancelFunc
 
@@ -413,28 +528,129 @@ type Parser struct {
 	lex       *lexer
 	Validator Validator
 	err       error
-}
 
-// newParser is the constructor for Parser.
-func newParser(input string) (*Parser, error) {
-	l := newLexer(context.Background(), input, untilEOF)
+	// prov records where values were decoded from. This is nil unless WithProvenance() is used.
+	prov Provenance
+
+	// pre are run on the content before it is lexed. lineMap maps the line numbers of the
+	// preprocessed content back to the original content.
+	pre     []Preprocessor
+	lineMap LineMap
+
+	// latin1 decodes the content as Latin-1 instead of UTF-8. See WithLatin1().
+	latin1 bool
+	// lineOffset is added to every Line.LineNum. See WithLineOffset().
+	lineOffset int
+
+	// maxSteps and noProgress are the limits used to detect a looping ParseFn. eofReads is the number
+	// of consecutive times Next() returned the EOF line. inStep is set while step() runs a ParseFn,
+	// as only then are EOF reads counted. loopErr is set when Next() detects a loop and is returned
+	// by step(). See loop.go.
+	maxSteps   int
+	noProgress int
+	eofReads   int
+	inStep     bool
+	loopErr    *LoopError
+
+	// running is set when the lexer goroutine has been started and Close() has not waited for it.
+	running bool
+	// items is a buffer used by pull() to collect a line's Items.
+	items []Item
+	// stepFunc is the function run by the current ParseFn step when the ParseFn is a wrapper, such
+	// as for a StateFn. Its name is only looked up for a *LoopError. See Parser.stepName().
+	stepFunc interface{}
+
+	// assigned holds the pointers to fields that were set. requireSet causes Parse() to fail if a
+	// required field is not in assigned. See set.go.
+	assigned   map[interface{}]bool
+	requireSet bool
+}
+
+// NewParser creates a Parser that can parse many contents with Parser.Parse(). "options" apply to
+// every call to Parser.Parse(). A Parser is not safe for concurrent use, but once Parser.Parse() returns
+// it holds no goroutines, so it may be stored in a sync.Pool. Options that hold state, such as
+// WithProvenance(), share that state between every call.
+func NewParser(options ...Option) *Parser {
+	p := &Parser{noProgress: DefaultNoProgressLimit}
+	for _, o := range options {
+		o(p)
+	}
+	return p
+}
+
+// newParser is the constructor for a Parser that is ready to lex "input". The lexer is not started.
+func newParser(ctx context.Context, input string, options ...Option) (*Parser, error) {
+	p := NewParser(options...)
+	if err := p.load(ctx, input); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// load prepares the Parser to lex "input", stopping the lexer for any previous input and discarding
+// its lines, position and error. The Parser's Context is derived from ctx. The lexer is not started.
+func (p *Parser) load(ctx context.Context, input string) error {
+	p.Close()
+
+	// Clear the previous Lines so their Items can be garbage collected, but keep the slice.
+	for i := range p.lines {
+		p.lines[i] = Line{}
+	}
+	p.lines = p.lines[:0]
+	p.pos = 0
+	p.err = nil
+	p.eofReads = 0
+	p.loopErr = nil
+	p.lineMap = nil
+	p.assigned = nil
+
+	input, err := decode(input, p.latin1)
+	if err != nil {
+		if pErr, ok := err.(*PosError); ok {
+			pErr.LineNum += p.lineOffset
+		}
+		return err
+	}
+	if len(p.pre) > 0 {
+		input, p.lineMap = Preprocess(input, p.pre...)
+	}
+	// Line endings are normalized after preprocessing so that Preprocessors can apply a "\r" within a line.
+	input = normalizeLineEndings(input)
+
+	p.ctx, p.cancel = context.WithCancel(ctx)
+	if p.lex == nil {
+		p.lex = newLexer(p.ctx, input, untilEOF)
+	} else {
+		p.lex.ctx = p.ctx
+		p.lex.reset(input)
+	}
+	p.recv = p.lex.items
+	return nil
+}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	return &Parser{
-		ctx:    ctx,
-		cancel: cancel,
-		lex:    l,
-		recv:   l.items,
-	}, nil
+// start starts the lexer goroutine.
+func (p *Parser) start() {
+	p.running = true
+	go p.lex.run()
 }
 
-// Close closes the Parser. This must be called to prevent a goroutine leak.
+// Close stops the lexer and waits for its goroutine to exit. This must be called after Reset() to
+// prevent a goroutine leak. Parse() calls this before returning.
 func (p *Parser) Close() {
-	p.cancel()
+	if p.cancel != nil {
+		p.cancel()
+	}
+	if p.running {
+		<-p.lex.done
+		p.running = false
+	}
 }
 
 func (p *Parser) pull() Line {
 	line := Line{}
+	// Items are collected in p.items, which is reused between lines, so that line.Items
+	// is allocated once at its final size.
+	p.items = p.items[:0]
 	func() {
 		for item := range p.recv {
 			switch item.Type {
@@ -442,16 +658,20 @@ func (p *Parser) pull() Line {
 				// The last Item records the raw and line value. Extract these from the item
 				// and move them to the Line entries.
 				line.Raw = item.raw
-				line.LineNum = item.lineNum
+				line.LineNum = p.lineMap.Original(item.lineNum) + p.lineOffset
 				item.raw = ""
 				item.lineNum = 0
-				line.Items = append(line.Items, item)
+				p.items = append(p.items, item)
 				return
 			}
 			item.raw = ""
-			line.Items = append(line.Items, item)
+			p.items = append(p.items, item)
 		}
+		// The lexer was stopped before it sent the EOF, so the input ends here.
+		p.items = append(p.items, Item{Type: ItemEOF})
 	}()
+	line.Items = make([]Item, len(p.items))
+	copy(line.Items, p.items)
 	return line
 }
 
@@ -467,12 +687,14 @@ func (p *Parser) Errorf(str string, args ...interface{}) ParseFn {
 	return nil
 }
 
-// Reset will reset the Parsers internal attributes for parsing new input "s" into "val".
+// Reset stops lexing any previous input, discards the Parser's lines, position and error and starts
+// lexing "s". This is for driving a Parser by hand with Next() and friends; Close() must be called when
+// done. Parser.Parse() does this for you.
 func (p *Parser) Reset(s string) error {
-	p.lex.reset(s)
-	p.lines = p.lines[:]
-	p.recv = p.lex.items
-
+	if err := p.load(context.Background(), s); err != nil {
+		return err
+	}
+	p.start()
 	return nil
 }
 
@@ -493,6 +715,16 @@ func (p *Parser) EOF(line Line) bool {
 // Next moves to the next Line sent from the Lexer. That Line is returned. If we haven't
 // received the next Line, the Parser will block until that Line has been received.
 func (p *Parser) Next() Line {
+	line := p.next()
+	if p.loopErr != nil {
+		// A loop was detected, so the ParseFn only gets the EOF line until it returns.
+		line = p.lines[len(p.lines)-1]
+	}
+	p.eofRead(p.EOF(line))
+	return line
+}
+
+func (p *Parser) next() Line {
 	// We don't have any items, so grab the next item.
 	if len(p.lines) == 0 {
 		p.lines = append(p.lines, p.pull())
@@ -539,7 +771,7 @@ func (p *Parser) FindStart(find []string) (Line, error) {
 		}
 
 		if p.EOF(line) {
-			return Line{}, fmt.Errorf("end of file reached without finding items: %#+v", find)
+			return Line{}, eofError("FindStart", line, FromStrings(find))
 		}
 	}
 	panic("FindStart() escaped for loop without returning")
@@ -560,7 +792,7 @@ func (p *Parser) FindUntil(find []string, until []string) (matchFound Line, unti
 		}
 
 		if p.EOF(line) {
-			return Line{}, false, fmt.Errorf("end of file reached without finding items: %#+v", find)
+			return Line{}, false, eofError("FindUntil", line, FromStrings(find))
 		}
 	}
 	panic("FindUntil() escaped for loop without returning")
@@ -602,7 +834,7 @@ func (p *Parser) FindREStart(find []*regexp.Regexp) (Line, error) {
 		}
 
 		if p.EOF(line) {
-			return Line{}, fmt.Errorf("FindStart: end of file reached without finding items: %#+v", find)
+			return Line{}, eofError("FindREStart", line, reStrings(find))
 		}
 	}
 	panic("FindREStart() escaped for loop without returning")
@@ -627,28 +859,26 @@ func (p *Parser) IsREStart(line Line, find []*regexp.Regexp) bool {
 	return true
 }
 
-// Match returns matches of the regex with keys set to the submatch names.
-// If these are not named submatches (aka `(?P<name>regex)`) this will probably panic.
-// A match that is empty string will cause an error to return.
+// Match returns matches of the regex with keys set to the submatch names. Submatches that are not
+// named (aka `(?P<name>regex)`) are ignored. A named submatch that matched an empty string is set to "",
+// while one that did not participate in the match (such as `(?P<name>x)?`) is not in the map. If the
+// regex does not match or no named submatch participated, an error wrapping ErrNoMatch is returned.
+// Use MatchInto() to decode the submatches into a struct.
 func Match(re *regexp.Regexp, s string) (map[string]string, error) {
-	names := re.SubexpNames()[1:]
-
-	matches := re.FindStringSubmatch(s)
-	if len(matches) < 1 {
-		return nil, fmt.Errorf("")
+	locs := re.FindStringSubmatchIndex(s)
+	if locs == nil {
+		return nil, fmt.Errorf("%w: regex %q, input %q", ErrNoMatch, re, s)
 	}
 
-	matches = matches[1:]
 	m := map[string]string{}
-
-	for i, v := range matches {
-		if v == "" {
+	for i, name := range re.SubexpNames() {
+		if i == 0 || name == "" || locs[i*2] < 0 {
 			continue
 		}
-		m[names[i]] = v
+		m[name] = s[locs[i*2]:locs[i*2+1]]
 	}
 	if len(m) == 0 {
-		return nil, fmt.Errorf("no matches were found")
+		return nil, fmt.Errorf("%w: regex %q, input %q: no named submatch participated in the match", ErrNoMatch, re, s)
 	}
 
 	return m, nil
@@ -2,10 +2,15 @@ package halfpike
 
 import (
 	"context"
+	"errors"
 	"os"
+	"runtime"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/johnsiilver/halfpike/units"
 	"github.com/kylelemons/godebug/pretty"
 )
 
@@ -92,7 +97,7 @@ func TestNext(t *testing.T) {
 		},
 	}
 
-	p, err := newParser(str)
+	p, err := newParser(context.Background(), str)
 	if err != nil {
 		panic(err)
 	}
@@ -112,7 +117,7 @@ func TestNext(t *testing.T) {
 }
 
 func TestBackup(t *testing.T) {
-	p, err := newParser(str)
+	p, err := newParser(context.Background(), str)
 	if err != nil {
 		panic(err)
 	}
@@ -192,7 +197,7 @@ func TestFindStart(t *testing.T) {
 	for _, test := range tests {
 		if test.reset || p == nil {
 			var err error
-			p, err = newParser(showBGPNeighbor)
+			p, err = newParser(context.Background(), showBGPNeighbor)
 			if err != nil {
 				panic(err)
 			}
@@ -214,7 +219,7 @@ func TestFindStart(t *testing.T) {
 }
 
 func TestFindUntil(t *testing.T) {
-	p, err := newParser(showBGPNeighbor)
+	p, err := newParser(context.Background(), showBGPNeighbor)
 	if err != nil {
 		panic(err)
 	}
@@ -404,3 +409,328 @@ text :=`a
 		t.Errorf("TestRegressionEOLOnLastLine: -want/+got:\n%s", diff)
 	}
 }
+
+// ctxObj reads "lines" lines and then calls "after", which returns the next ParseFn.
+type ctxObj struct {
+	lines int
+	after func(ctx context.Context, p *Parser) ParseFn
+}
+
+func (c *ctxObj) Start(ctx context.Context, p *Parser) ParseFn {
+	for i := 0; i < c.lines; i++ {
+		if p.EOF(p.Next()) {
+			return nil
+		}
+	}
+	return c.after
+}
+
+func (c *ctxObj) Validate() error {
+	return nil
+}
+
+func TestParseContext(t *testing.T) {
+	// More lines than the lexer's channel can buffer, so the lexer is blocked when a parse stops early.
+	content := strings.Repeat("Address: 10.0.0.1\n", 1000)
+
+	untilEOF := func(ctx context.Context, p *Parser) ParseFn {
+		for !p.EOF(p.Next()) {
+		}
+		return nil
+	}
+
+	tests := []struct {
+		desc    string
+		ctx     func() (context.Context, context.CancelFunc)
+		obj     func(cancel context.CancelFunc) *ctxObj
+		wantErr error
+	}{
+		{
+			desc: "ParseFn stops early",
+			ctx:  func() (context.Context, context.CancelFunc) { return context.WithCancel(context.Background()) },
+			obj: func(cancel context.CancelFunc) *ctxObj {
+				return &ctxObj{lines: 1, after: func(ctx context.Context, p *Parser) ParseFn { return nil }}
+			},
+		},
+		{
+			desc: "Cancelled between ParseFns",
+			ctx:  func() (context.Context, context.CancelFunc) { return context.WithCancel(context.Background()) },
+			obj: func(cancel context.CancelFunc) *ctxObj {
+				return &ctxObj{
+					lines: 1,
+					after: func(ctx context.Context, p *Parser) ParseFn {
+						cancel()
+						return untilEOF
+					},
+				}
+			},
+			wantErr: context.Canceled,
+		},
+		{
+			desc: "Cancelled within a ParseFn",
+			ctx:  func() (context.Context, context.CancelFunc) { return context.WithCancel(context.Background()) },
+			obj: func(cancel context.CancelFunc) *ctxObj {
+				return &ctxObj{
+					lines: 1,
+					after: func(ctx context.Context, p *Parser) ParseFn {
+						cancel()
+						// Input ends early once the lexer stops, so this returns.
+						return untilEOF(ctx, p)
+					},
+				}
+			},
+			wantErr: context.Canceled,
+		},
+		{
+			desc: "Deadline exceeded",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 10*time.Millisecond)
+			},
+			obj: func(cancel context.CancelFunc) *ctxObj {
+				var wait ParseFn
+				wait = func(ctx context.Context, p *Parser) ParseFn {
+					time.Sleep(time.Millisecond)
+					return wait
+				}
+				return &ctxObj{lines: 1, after: wait}
+			},
+			wantErr: context.DeadlineExceeded,
+		},
+		{
+			desc: "Already cancelled",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx, cancel
+			},
+			obj: func(cancel context.CancelFunc) *ctxObj {
+				return &ctxObj{after: untilEOF}
+			},
+			wantErr: context.Canceled,
+		},
+	}
+
+	for _, test := range tests {
+		before := runtime.NumGoroutine()
+
+		ctx, cancel := test.ctx()
+		err := Parse(ctx, content, test.obj(cancel))
+		cancel()
+		if !errors.Is(err, test.wantErr) || (err != nil && test.wantErr == nil) {
+			t.Errorf("TestParseContext(%s): got err == %v, want %v", test.desc, err, test.wantErr)
+		}
+
+		// Parse() must not return until its goroutines have exited.
+		if after := runtime.NumGoroutine(); after > before {
+			buf := make([]byte, 1<<16)
+			t.Errorf("TestParseContext(%s): %d goroutines before Parse(), %d after:\n%s", test.desc, before, after, buf[:runtime.Stack(buf, true)])
+		}
+	}
+}
+
+func TestParserReuse(t *testing.T) {
+	p := NewParser()
+	before := runtime.NumGoroutine()
+
+	tests := []struct {
+		desc    string
+		ctx     context.Context
+		content string
+		obj     ParseObject
+		want    []string
+		err     bool
+	}{
+		{
+			desc:    "First content",
+			content: "one\ntwo\nthree\n",
+			obj:     &lineObj{},
+			want:    []string{"one", "two", "three"},
+		},
+		{
+			desc:    "Shorter content does not see old lines",
+			content: "four\n",
+			obj:     &lineObj{},
+			want:    []string{"four"},
+		},
+		{
+			desc:    "ParseFn error",
+			content: "bad\n",
+			obj:     &batchObj{},
+			err:     true,
+		},
+		{
+			desc:    "Error is not kept",
+			content: "five\nsix\n",
+			obj:     &lineObj{},
+			want:    []string{"five", "six"},
+		},
+		{
+			desc:    "Stops early",
+			content: strings.Repeat("Address: 10.0.0.1\n", 100),
+			obj:     &ctxObj{lines: 1, after: func(ctx context.Context, p *Parser) ParseFn { return nil }},
+		},
+		{
+			desc:    "After stopping early",
+			content: "seven\n",
+			obj:     &lineObj{},
+			want:    []string{"seven"},
+		},
+	}
+
+	for _, test := range tests {
+		err := p.Parse(context.Background(), test.content, test.obj)
+		switch {
+		case err == nil && test.err:
+			t.Errorf("TestParserReuse(%s): got err == nil, want err != nil", test.desc)
+			continue
+		case err != nil && !test.err:
+			t.Errorf("TestParserReuse(%s): got err == %s", test.desc, err)
+			continue
+		case err != nil:
+			continue
+		}
+		if l, ok := test.obj.(*lineObj); ok {
+			if diff := pretty.Compare(test.want, l.lines); diff != "" {
+				t.Errorf("TestParserReuse(%s): -want/+got:\n%s", test.desc, diff)
+			}
+		}
+	}
+
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("TestParserReuse: %d goroutines before, %d after", before, after)
+	}
+}
+
+func TestParserReset(t *testing.T) {
+	before := runtime.NumGoroutine()
+	p := NewParser()
+	defer p.Close()
+
+	for _, content := range []string{"one two\nthree\n", "four\n"} {
+		if err := p.Reset(content); err != nil {
+			t.Fatalf("TestParserReset: got err == %s", err)
+		}
+		want := strings.Fields(strings.Split(content, "\n")[0])[0]
+		if got := p.Next().Items[0].Val; got != want {
+			t.Errorf("TestParserReset(%q): got first Item %q, want %q", content, got, want)
+		}
+	}
+	// Reset() before the previous content was read must not leak the lexer.
+	for i := 0; i < 10; i++ {
+		if err := p.Reset(strings.Repeat("line\n", 100)); err != nil {
+			t.Fatalf("TestParserReset: got err == %s", err)
+		}
+	}
+	p.Close()
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("TestParserReset: %d goroutines before, %d after Close()", before, after)
+	}
+}
+
+func TestParserPool(t *testing.T) {
+	pool := sync.Pool{New: func() interface{} { return NewParser() }}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 50; n++ {
+				want := (i + n) % 7
+				p := pool.Get().(*Parser)
+				obj := &batchObj{}
+				err := p.Parse(context.Background(), strings.Repeat("Address: 10.0.0.1\n", want), obj)
+				pool.Put(p)
+				if err != nil {
+					t.Errorf("TestParserPool: got err == %s", err)
+					return
+				}
+				if obj.addrs != want {
+					t.Errorf("TestParserPool: got %d addresses, want %d", obj.addrs, want)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+var benchContent = strings.Repeat(showBGPNeighbor, 5)
+
+// countObj counts the lines in a content.
+type countObj struct {
+	lines int
+}
+
+func (c *countObj) Start(ctx context.Context, p *Parser) ParseFn {
+	for !p.EOF(p.Next()) {
+		c.lines++
+	}
+	return nil
+}
+
+func (c *countObj) Validate() error {
+	return nil
+}
+
+func BenchmarkParse(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := Parse(context.Background(), benchContent, &countObj{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParserReuse(b *testing.B) {
+	b.ReportAllocs()
+	p := NewParser()
+	for i := 0; i < b.N; i++ {
+		if err := p.Parse(context.Background(), benchContent, &countObj{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParserPool(b *testing.B) {
+	b.ReportAllocs()
+	pool := sync.Pool{New: func() interface{} { return NewParser() }}
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			p := pool.Get().(*Parser)
+			if err := p.Parse(context.Background(), benchContent, &countObj{}); err != nil {
+				b.Fatal(err)
+			}
+			pool.Put(p)
+		}
+	})
+}
+
+func TestItemUnits(t *testing.T) {
+	items := []Item{
+		{Type: ItemText, Val: "1000mbps,"},
+		{Type: ItemText, Val: "1.5K"},
+		{Type: ItemText, Val: "90s"},
+		{Type: ItemText, Val: "04:05:06"},
+		{Type: ItemText, Val: "45%"},
+	}
+
+	if got, err := items[0].ToBitRate(); err != nil || got != units.Gbps {
+		t.Errorf("TestItemUnits(ToBitRate): got (%v, %v), want (1Gbps, nil)", got, err)
+	}
+	if got, err := items[1].ToByteSize(); err != nil || got != 1536 {
+		t.Errorf("TestItemUnits(ToByteSize): got (%v, %v), want (1536B, nil)", got, err)
+	}
+	if got, err := items[2].ToDuration(); err != nil || got != 90*time.Second {
+		t.Errorf("TestItemUnits(ToDuration): got (%v, %v), want (90s, nil)", got, err)
+	}
+	if got, err := items[3].ToUptime(); err != nil || got != 4*time.Hour+5*time.Minute+6*time.Second {
+		t.Errorf("TestItemUnits(ToUptime): got (%v, %v), want (4h5m6s, nil)", got, err)
+	}
+	if got, err := items[4].ToPercent(); err != nil || got != 45 {
+		t.Errorf("TestItemUnits(ToPercent): got (%v, %v), want (45, nil)", got, err)
+	}
+	if _, err := items[2].ToBitRate(); err == nil {
+		t.Errorf("TestItemUnits(ToBitRate of a duration): got err == nil, want err != nil")
+	}
+}
@@ -11,7 +11,10 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
+
+	"github.com/johnsiilver/halfpike/units"
 )
 
 //go:generate stringer -type=ItemType
@@ -41,6 +44,9 @@ const (
 )
 
 // Item represents a token created by the Lexer.
+//
+// The unit conversions, ToBitRate(), ToByteSize(), ToDuration(), ToUptime() and ToPercent(), ignore a
+// trailing comma, as values in device output are often followed by one, such as "Speed: 1000mbps,".
 type Item struct {
 	// Type is the type of item that is stored in .Val.
 	Type ItemType
@@ -201,6 +207,36 @@ func (i Item) ToFloat() (float64, error) {
 	return f, nil
 }
 
+// ToBitRate returns the value as a bit rate, such as "1000mbps" or "10Gbps".
+// See units.ParseBitRate().
+func (i Item) ToBitRate() (units.BitRate, error) {
+	return units.ParseBitRate(strings.TrimSuffix(i.Val, ","))
+}
+
+// ToByteSize returns the value as a size in bytes, such as "1.5K" or "512MB".
+// See units.ParseByteSize().
+func (i Item) ToByteSize() (units.ByteSize, error) {
+	return units.ParseByteSize(strings.TrimSuffix(i.Val, ","))
+}
+
+// ToDuration returns the value as a duration, such as "90s" or "2w3d".
+// See units.ParseDuration().
+func (i Item) ToDuration() (time.Duration, error) {
+	return units.ParseDuration(strings.TrimSuffix(i.Val, ","))
+}
+
+// ToUptime returns the value as an uptime, such as "2w3d" or "04:05:06".
+// For an uptime that spans Items, such as "2w3d 04:05:06", use units.ParseUptime() with ItemJoin().
+func (i Item) ToUptime() (time.Duration, error) {
+	return units.ParseUptime(strings.TrimSuffix(i.Val, ","))
+}
+
+// ToPercent returns the value as a percentage, such as "45%" or "255/255".
+// See units.ParsePercent().
+func (i Item) ToPercent() (float64, error) {
+	return units.ParsePercent(strings.TrimSuffix(i.Val, ","))
+}
+
 func ItemJoin(items ...Item) string {
 	b := strings.Builder{}
 	for _, i := range items {
@@ -3,7 +3,9 @@ package line
 import (
 	"log"
 	"testing"
+	"time"
 
+	"github.com/johnsiilver/halfpike/units"
 	"github.com/kylelemons/godebug/pretty"
 )
 
@@ -170,3 +172,32 @@ func TestDecodeList(t *testing.T) {
 		}
 	}
 }
+
+func TestItemUnits(t *testing.T) {
+	l := New("10Gbps 4096 2w3d 255/255")
+	var items []Item
+	for item := l.Next(); item.Type != ItemEOF && item.Type != ItemEOL; item = l.Next() {
+		if item.Type != ItemSpace {
+			items = append(items, item)
+		}
+	}
+	if len(items) != 4 {
+		t.Fatalf("TestItemUnits: got %d items, want 4: %v", len(items), items)
+	}
+
+	if got, err := items[0].ToBitRate(); err != nil || got != 10*units.Gbps {
+		t.Errorf("TestItemUnits(ToBitRate): got (%v, %v), want (10Gbps, nil)", got, err)
+	}
+	if got, err := items[1].ToByteSize(); err != nil || got != 4*units.KB {
+		t.Errorf("TestItemUnits(ToByteSize): got (%v, %v), want (4KB, nil)", got, err)
+	}
+	if got, err := items[2].ToUptime(); err != nil || got != 17*24*time.Hour {
+		t.Errorf("TestItemUnits(ToUptime): got (%v, %v), want (408h, nil)", got, err)
+	}
+	if got, err := items[3].ToPercent(); err != nil || got != 100 {
+		t.Errorf("TestItemUnits(ToPercent): got (%v, %v), want (100, nil)", got, err)
+	}
+	if _, err := items[2].ToDuration(); err != nil {
+		t.Errorf("TestItemUnits(ToDuration): got err == %s, want err == nil", err)
+	}
+}
@@ -0,0 +1,160 @@
+package halfpike
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// DefaultNoProgressLimit is the number of consecutive ParseFn steps that may be taken without the
+// Parser's position changing, and the number of consecutive times Next() may return the EOF line,
+// before Parse() decides a ParseFn is looping. See WithNoProgressLimit().
+const DefaultNoProgressLimit = 1000
+
+// ErrLoop is returned (wrapped in a *LoopError) by Parse() when a ParseFn appears to be looping.
+var ErrLoop = errors.New("ParseFn is looping")
+
+// LoopError is returned by Parse() when a ParseFn appears to be stuck in a loop.
+type LoopError struct {
+	// Func is the name of the ParseFn that was running, such as "junos.(*BGPNeighbors).start".
+	Func string
+	// LineNum is the Line.LineNum the Parser was at.
+	LineNum int
+	// Reason describes how the loop was detected.
+	Reason string
+}
+
+// Error implements error.Error().
+func (l *LoopError) Error() string {
+	return fmt.Sprintf("[Line %d]: ParseFn %s: %s: %s", l.LineNum, l.Func, ErrLoop, l.Reason)
+}
+
+// Unwrap returns ErrLoop.
+func (l *LoopError) Unwrap() error {
+	return ErrLoop
+}
+
+// WithMaxSteps limits the number of ParseFns that Parse() will call to "n". If the limit is
+// reached, Parse() returns a *LoopError. By default there is no limit.
+func WithMaxSteps(n int) Option {
+	return func(p *Parser) {
+		p.maxSteps = n
+	}
+}
+
+// WithNoProgressLimit sets the number of consecutive ParseFn steps without the Parser's position
+// changing, and the number of consecutive times Next() may return the EOF line, before Parse()
+// returns a *LoopError. The latter catches a ParseFn that loops on Next() without checking EOF().
+// If "n" <= 0, this detection is disabled. The default is DefaultNoProgressLimit.
+func WithNoProgressLimit(n int) Option {
+	return func(p *Parser) {
+		p.noProgress = n
+	}
+}
+
+// loopGuard tracks the ParseFn steps taken by Parse().
+type loopGuard struct {
+	steps int
+	// still is the number of consecutive steps where pos did not change.
+	still int
+	pos   int
+}
+
+// check is called after each ParseFn step and returns a *LoopError if "fn" looks to be looping.
+func (g *loopGuard) check(p *Parser, fn ParseFn) error {
+	g.steps++
+	if p.maxSteps > 0 && g.steps >= p.maxSteps {
+		return p.loopError(fn, fmt.Sprintf("reached the maximum of %d steps", p.maxSteps))
+	}
+
+	if p.pos != g.pos {
+		g.pos = p.pos
+		g.still = 0
+		return nil
+	}
+	g.still++
+	if p.noProgress > 0 && g.still >= p.noProgress {
+		return p.loopError(fn, fmt.Sprintf("%d consecutive steps did not move the Parser", g.still))
+	}
+	return nil
+}
+
+// step runs "fn". If Next() detects a loop within "fn", the *LoopError is returned. This is checked
+// after "fn" returns, so it does not matter if "fn" recovered the panic from eofRead().
+func (p *Parser) step(ctx context.Context, fn ParseFn) (next ParseFn, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(*LoopError); !ok {
+				panic(r)
+			}
+		}
+		if p.loopErr != nil {
+			p.loopErr.Func = p.stepName(fn)
+			next, err = nil, p.loopErr
+		}
+	}()
+	p.stepFunc = nil
+	p.inStep = true
+	defer func() { p.inStep = false }()
+	return fn(ctx, p), nil
+}
+
+// eofRead is called by Next() each time it returns a Line. If a ParseFn keeps reading the EOF
+// line, this sets p.loopErr, which makes Next() keep returning the EOF line and step() return the
+// *LoopError once the ParseFn returns. A ParseFn that never acts on EOF would not return, so if it
+// reads the EOF line as many times again, this panics to break out of it. step() recovers the panic,
+// but does not rely on it, as the ParseFn may recover it first. Outside of step(), such as when
+// Next() is called after Reset(), EOF reads are not counted.
+func (p *Parser) eofRead(eof bool) {
+	if !eof {
+		p.eofReads = 0
+		return
+	}
+	if !p.inStep || p.noProgress <= 0 {
+		return
+	}
+	p.eofReads++
+	switch {
+	case p.loopErr == nil && p.eofReads >= p.noProgress:
+		p.loopErr = p.loopError(nil, fmt.Sprintf("Next() returned the EOF line %d consecutive times without EOF() being acted on", p.eofReads))
+	case p.loopErr != nil && p.eofReads >= 2*p.noProgress:
+		panic(p.loopErr)
+	}
+}
+
+func (p *Parser) loopError(fn ParseFn, reason string) *LoopError {
+	lErr := &LoopError{Reason: reason}
+	if fn != nil {
+		lErr.Func = p.stepName(fn)
+	}
+	if p.pos > 0 && p.pos <= len(p.lines) {
+		lErr.LineNum = p.lines[p.pos-1].LineNum
+	}
+	return lErr
+}
+
+// stepName returns the name of the ParseFn "fn" for a *LoopError. If "fn" wraps another function,
+// such as a StateFn, that set p.stepFunc, that function's name is used instead.
+func (p *Parser) stepName(fn ParseFn) string {
+	if p.stepFunc != nil {
+		return funcName(p.stepFunc)
+	}
+	return funcName(fn)
+}
+
+// funcName returns the name of the function "fn" without its package path, such as "junos.(*BGPNeighbors).start".
+func funcName(fn interface{}) string {
+	f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer())
+	if f == nil {
+		return "unknown"
+	}
+	name := f.Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	// Method values, such as parseObject.Start, have a "-fm" suffix.
+	return strings.TrimSuffix(name, "-fm")
+}
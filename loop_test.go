@@ -0,0 +1,197 @@
+package halfpike
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// loopObj runs the ParseFn selected by "mode".
+type loopObj struct {
+	mode string
+}
+
+func (l *loopObj) Start(ctx context.Context, p *Parser) ParseFn {
+	switch l.mode {
+	case "forgets EOF":
+		return l.forgetsEOF
+	case "no progress":
+		return l.noProgress
+	case "recovers":
+		return l.recovers
+	}
+	return l.oneLine
+}
+
+// forgetsEOF never checks for EOF, so it would loop forever.
+func (l *loopObj) forgetsEOF(ctx context.Context, p *Parser) ParseFn {
+	for {
+		line := p.Next()
+		if p.IsAtStart(line, []string{"never"}) {
+			return nil
+		}
+	}
+}
+
+// recovers is forgetsEOF with a recover() that would swallow a panic from Next(), and then
+// returns itself.
+func (l *loopObj) recovers(ctx context.Context, p *Parser) (next ParseFn) {
+	defer func() {
+		recover()
+		next = l.recovers
+	}()
+	return l.forgetsEOF(ctx, p)
+}
+
+// noProgress returns itself without moving the Parser.
+func (l *loopObj) noProgress(ctx context.Context, p *Parser) ParseFn {
+	p.Peek()
+	return l.noProgress
+}
+
+// oneLine reads a line per step until EOF.
+func (l *loopObj) oneLine(ctx context.Context, p *Parser) ParseFn {
+	if p.EOF(p.Next()) {
+		return nil
+	}
+	return l.oneLine
+}
+
+func (l *loopObj) Validate() error {
+	return nil
+}
+
+func TestParseLoops(t *testing.T) {
+	content := "line one\nline two\nline three\n"
+
+	tests := []struct {
+		desc     string
+		mode     string
+		content  string
+		options  []Option
+		wantFunc string
+		wantLine int
+		reason   string
+	}{
+		{
+			desc:     "ParseFn forgets to check EOF",
+			mode:     "forgets EOF",
+			content:  content,
+			wantFunc: "halfpike.(*loopObj).forgetsEOF",
+			wantLine: 3,
+			reason:   "EOF line",
+		},
+		{
+			desc:     "ParseFn recovers panics",
+			mode:     "recovers",
+			content:  content,
+			wantFunc: "halfpike.(*loopObj).recovers",
+			wantLine: 3,
+			reason:   "EOF line",
+		},
+		{
+			desc:     "ParseFn makes no progress",
+			mode:     "no progress",
+			content:  content,
+			options:  []Option{WithNoProgressLimit(10)},
+			wantFunc: "halfpike.(*loopObj).noProgress",
+			wantLine: 0,
+			reason:   "10 consecutive steps",
+		},
+		{
+			desc:     "Maximum steps",
+			content:  content,
+			options:  []Option{WithMaxSteps(2)},
+			wantFunc: "halfpike.(*loopObj).oneLine",
+			wantLine: 0,
+			reason:   "maximum of 2 steps",
+		},
+		{
+			desc:    "Many steps are not a loop",
+			content: strings.Repeat("line\n", DefaultNoProgressLimit*2),
+		},
+		{
+			desc:    "Detection disabled",
+			mode:    "forgets EOF",
+			content: content,
+			options: []Option{WithNoProgressLimit(0), WithMaxSteps(0)},
+		},
+	}
+
+	for _, test := range tests {
+		if test.desc == "Detection disabled" {
+			// This would loop forever, so only check that the options apply.
+			p, err := newParser(context.Background(), test.content, test.options...)
+			if err != nil {
+				t.Fatalf("TestParseLoops(%s): got err == %s", test.desc, err)
+			}
+			if p.noProgress != 0 || p.maxSteps != 0 {
+				t.Errorf("TestParseLoops(%s): got (noProgress %d, maxSteps %d), want 0s", test.desc, p.noProgress, p.maxSteps)
+			}
+			p.Close()
+			continue
+		}
+
+		err := Parse(context.Background(), test.content, &loopObj{mode: test.mode}, test.options...)
+		if test.wantFunc == "" {
+			if err != nil {
+				t.Errorf("TestParseLoops(%s): got err == %s", test.desc, err)
+			}
+			continue
+		}
+
+		lErr := &LoopError{}
+		if !errors.As(err, &lErr) || !errors.Is(err, ErrLoop) {
+			t.Errorf("TestParseLoops(%s): got err == %v, want *LoopError", test.desc, err)
+			continue
+		}
+		if lErr.Func != test.wantFunc {
+			t.Errorf("TestParseLoops(%s): got Func %q, want %q", test.desc, lErr.Func, test.wantFunc)
+		}
+		if lErr.LineNum != test.wantLine {
+			t.Errorf("TestParseLoops(%s): got LineNum %d, want %d", test.desc, lErr.LineNum, test.wantLine)
+		}
+		if !strings.Contains(lErr.Reason, test.reason) {
+			t.Errorf("TestParseLoops(%s): got Reason %q, want it to contain %q", test.desc, lErr.Reason, test.reason)
+		}
+	}
+}
+
+func TestParseLoopsOtherPanics(t *testing.T) {
+	defer func() {
+		if r := recover(); r != "found panic line" {
+			t.Errorf("TestParseLoopsOtherPanics: got recover() == %v, want the ParseFn's panic", r)
+		}
+	}()
+	Parse(context.Background(), "panic\n", &batchObj{})
+	t.Errorf("TestParseLoopsOtherPanics: Parse() did not panic")
+}
+
+func TestNextOutsideParse(t *testing.T) {
+	p := NewParser()
+	defer p.Close()
+	if err := p.Reset("line one\n"); err != nil {
+		t.Fatalf("TestNextOutsideParse: got err == %s", err)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			t.Errorf("TestNextOutsideParse: Next() panicked: %v", r)
+		}
+	}()
+	if line := p.Next(); p.EOF(line) {
+		t.Fatalf("TestNextOutsideParse: got EOF for the first line")
+	}
+	for i := 0; i < DefaultNoProgressLimit*2; i++ {
+		if line := p.Next(); !p.EOF(line) {
+			t.Fatalf("TestNextOutsideParse: Next() call %d did not return the EOF line", i)
+		}
+	}
+
+	// The limit must still apply to a Parse() that follows.
+	err := p.Parse(context.Background(), "line one\n", &loopObj{mode: "forgets EOF"})
+	if !errors.Is(err, ErrLoop) {
+		t.Errorf("TestNextOutsideParse(Parse): got err == %v, want ErrLoop", err)
+	}
+}
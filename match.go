@@ -0,0 +1,348 @@
+package halfpike
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoMatch is returned (wrapped) by Match() and MatchInto() when the regex does not match.
+var ErrNoMatch = errors.New("regex did not match")
+
+// RegexDecoder decodes the named submatches of a regex into the fields of a struct. It is created
+// with NewRegexDecoder(), which checks that every named submatch maps to a field that can hold it.
+//
+// A field is mapped to the submatch named by its `re:"name"` tag or, without a tag, to the submatch
+// with the same name as the field, ignoring case. `re:"-"` ignores a field. The tag may have options
+// after the name:
+//
+//	omitempty - a submatch that matched an empty string is treated as if it did not participate
+//	required - it is an error if the submatch does not participate in the match
+//
+// A submatch that does not participate in the match (such as `(?P<name>x)?`) leaves its field untouched,
+// so a pointer field stays nil. A submatch that matched an empty string sets a string field (or pointer
+// to a string) to "", but is an error for other types unless omitempty is set.
+//
+// Fields may be a string, bool, int, uint or float type, time.Duration, a type implementing
+// encoding.TextUnmarshaler or a pointer to one of these.
+type RegexDecoder struct {
+	re     *regexp.Regexp
+	typ    reflect.Type
+	fields []matchField
+}
+
+// matchField maps a submatch to a struct field.
+type matchField struct {
+	group     int
+	name      string
+	field     int
+	omitEmpty bool
+	required  bool
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// NewRegexDecoder creates a RegexDecoder that decodes the named submatches of "re" into structs of
+// the same type as "v", which must be a struct or a pointer to a struct.
+func NewRegexDecoder(re *regexp.Regexp, v interface{}) (*RegexDecoder, error) {
+	typ := reflect.TypeOf(v)
+	if typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("regex %q: must decode into a struct or *struct, not %T", re, v)
+	}
+
+	groups := map[string][]int{}
+	for i, name := range re.SubexpNames() {
+		if name != "" {
+			groups[name] = append(groups[name], i)
+		}
+	}
+
+	d := &RegexDecoder{re: re, typ: typ}
+	mapped := map[string]bool{}
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		tag, hasTag := sf.Tag.Lookup("re")
+		if tag == "-" || sf.PkgPath != "" && !hasTag {
+			continue
+		}
+		if sf.PkgPath != "" {
+			return nil, fmt.Errorf("regex %q: field %s.%s has an re tag but is not exported", re, typ, sf.Name)
+		}
+
+		opts := strings.Split(tag, ",")
+		name := opts[0]
+		if name == "" {
+			for _, g := range re.SubexpNames() {
+				if g != "" && strings.EqualFold(g, sf.Name) {
+					name = g
+					break
+				}
+			}
+			if name == "" && !hasTag {
+				continue
+			}
+		}
+		idx, ok := groups[name]
+		if !ok {
+			return nil, fmt.Errorf("regex %q: field %s.%s: regex has no submatch named %q", re, typ, sf.Name, name)
+		}
+		if mapped[name] {
+			return nil, fmt.Errorf("regex %q: submatch %q is mapped to more than one field of %s", re, name, typ)
+		}
+		if !decodable(sf.Type) {
+			return nil, fmt.Errorf("regex %q: field %s.%s: cannot decode into type %s", re, typ, sf.Name, sf.Type)
+		}
+		mapped[name] = true
+
+		mf := matchField{name: name, field: i}
+		for _, opt := range opts[1:] {
+			switch opt {
+			case "omitempty":
+				mf.omitEmpty = true
+			case "required":
+				mf.required = true
+			default:
+				return nil, fmt.Errorf("regex %q: field %s.%s: unknown re tag option %q", re, typ, sf.Name, opt)
+			}
+		}
+		for _, g := range idx {
+			mf.group = g
+			d.fields = append(d.fields, mf)
+		}
+	}
+
+	for name := range groups {
+		if !mapped[name] {
+			return nil, fmt.Errorf("regex %q: submatch %q does not map to a field of %s", re, name, typ)
+		}
+	}
+	return d, nil
+}
+
+// MustNewRegexDecoder is like NewRegexDecoder(), but panics on an error. It is intended for package
+// level variables, like regexp.MustCompile().
+func MustNewRegexDecoder(re *regexp.Regexp, v interface{}) *RegexDecoder {
+	d, err := NewRegexDecoder(re, v)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// Decode matches the regex against "s" and decodes the named submatches into "dst", which must be a
+// pointer to the struct type the RegexDecoder was created with. If the regex does not match, an error
+// wrapping ErrNoMatch is returned. On an error, "dst" is not changed.
+func (d *RegexDecoder) Decode(s string, dst interface{}) error {
+	_, err := d.decode(s, dst)
+	return err
+}
+
+// decode implements Decode() and returns the submatch locations for recording provenance. The
+// submatches are decoded into a copy of "dst", which is only stored in "dst" if there is no error, so
+// a failed decode leaves "dst" unchanged.
+func (d *RegexDecoder) decode(s string, dst interface{}) ([]int, error) {
+	out := reflect.ValueOf(dst)
+	if out.Kind() != reflect.Ptr || out.IsNil() || out.Elem().Type() != d.typ {
+		return nil, fmt.Errorf("regex %q: must decode into a non-nil *%s, not %T", d.re, d.typ, dst)
+	}
+	out = out.Elem()
+	v := reflect.New(d.typ).Elem()
+	v.Set(out)
+
+	locs := d.re.FindStringSubmatchIndex(s)
+	if locs == nil {
+		return nil, fmt.Errorf("%w: regex %q, input %q", ErrNoMatch, d.re, s)
+	}
+
+	for _, mf := range d.fields {
+		start, end := locs[mf.group*2], locs[mf.group*2+1]
+		switch {
+		case start < 0:
+			continue
+		case start == end && mf.omitEmpty:
+			continue
+		}
+		if err := setField(v.Field(mf.field), s[start:end]); err != nil {
+			return nil, fmt.Errorf("regex %q, input %q: submatch %q: %w", d.re, s, mf.name, err)
+		}
+	}
+
+	for _, mf := range d.fields {
+		if !mf.required || participated(d.fields, locs, mf) {
+			continue
+		}
+		return nil, fmt.Errorf("regex %q, input %q: required submatch %q did not participate in the match", d.re, s, mf.name)
+	}
+	out.Set(v)
+	return locs, nil
+}
+
+// participated reports if any submatch named mf.name participated in the match.
+func participated(fields []matchField, locs []int, mf matchField) bool {
+	for _, f := range fields {
+		if f.name != mf.name {
+			continue
+		}
+		start, end := locs[f.group*2], locs[f.group*2+1]
+		if start >= 0 && !(start == end && f.omitEmpty) {
+			return true
+		}
+	}
+	return false
+}
+
+type decoderKey struct {
+	re  *regexp.Regexp
+	typ reflect.Type
+}
+
+// decoders caches the RegexDecoders created by MatchInto().
+var decoders sync.Map
+
+// MatchInto decodes the named submatches of "re" against "s" into "dst", which must be a pointer to a
+// struct. See RegexDecoder for how submatches map to fields. The RegexDecoder for each regex and type
+// is cached, but prefer MustNewRegexDecoder() in a package variable so that mapping errors are found
+// when the package is loaded.
+func MatchInto(re *regexp.Regexp, s string, dst interface{}) error {
+	d, err := cachedDecoder(re, dst)
+	if err != nil {
+		return err
+	}
+	return d.Decode(s, dst)
+}
+
+func cachedDecoder(re *regexp.Regexp, dst interface{}) (*RegexDecoder, error) {
+	key := decoderKey{re: re, typ: reflect.TypeOf(dst)}
+	if d, ok := decoders.Load(key); ok {
+		return d.(*RegexDecoder), nil
+	}
+	d, err := NewRegexDecoder(re, dst)
+	if err != nil {
+		return nil, err
+	}
+	decoders.Store(key, d)
+	return d, nil
+}
+
+// MatchInto calls MatchInto() against line.Raw and records the provenance of each named submatch
+// at path "path.<submatch name>". If path is empty, the submatch name is used as the path. Each field
+// that is decoded is recorded as set (see Set()).
+func (p *Parser) MatchInto(path string, re *regexp.Regexp, line Line, dst interface{}) error {
+	d, err := cachedDecoder(re, dst)
+	if err != nil {
+		return err
+	}
+	locs, err := d.decode(line.Raw, dst)
+	if err != nil {
+		return fmt.Errorf("[Line %d]: %w", line.LineNum, err)
+	}
+
+	v := reflect.ValueOf(dst).Elem()
+	for _, mf := range d.fields {
+		start, end := locs[mf.group*2], locs[mf.group*2+1]
+		if start < 0 || start == end && mf.omitEmpty {
+			continue
+		}
+		p.MarkSet(v.Field(mf.field).Addr().Interface())
+		if p.prov == nil {
+			continue
+		}
+		p.prov[joinPath(path, mf.name)] = Source{
+			LineNum: line.LineNum,
+			Index:   itemIndexAt(line.Raw, start),
+			Raw:     line.Raw[start:end],
+		}
+	}
+	return nil
+}
+
+// decodable reports if setField() can decode into a value of type "t".
+func decodable(t reflect.Type) bool {
+	if reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return t.Elem().Kind() != reflect.Ptr && decodable(t.Elem())
+	case reflect.String, reflect.Bool, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setField converts "s" to the type of "v" and stores it.
+func setField(v reflect.Value, s string) error {
+	if v.CanAddr() {
+		if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return u.UnmarshalText([]byte(s))
+		}
+	}
+
+	if v.Kind() == reflect.Ptr {
+		n := reflect.New(v.Type().Elem())
+		if err := setField(n.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(n)
+		return nil
+	}
+
+	if v.Kind() == reflect.String {
+		v.SetString(s)
+		return nil
+	}
+	if s == "" {
+		return fmt.Errorf("cannot convert an empty string to %s", v.Type())
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("cannot convert %q to %s", s, v.Type())
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() == durationType {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return fmt.Errorf("cannot convert %q to %s: %w", s, v.Type(), err)
+			}
+			v.SetInt(int64(d))
+			return nil
+		}
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("cannot convert %q to %s: %w", s, v.Type(), err)
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("cannot convert %q to %s: %w", s, v.Type(), err)
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("cannot convert %q to %s: %w", s, v.Type(), err)
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("cannot decode into type %s", v.Type())
+	}
+	return nil
+}
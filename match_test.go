@@ -0,0 +1,277 @@
+package halfpike
+
+import (
+	"context"
+	"errors"
+	"net"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		desc    string
+		re      *regexp.Regexp
+		s       string
+		want    map[string]string
+		noMatch bool
+	}{
+		{
+			desc: "Named submatches",
+			re:   regexp.MustCompile(`(?P<name>\S+) is (?P<state>up|down)`),
+			s:    "ge-0/0/0 is up",
+			want: map[string]string{"name": "ge-0/0/0", "state": "up"},
+		},
+		{
+			desc: "Unnamed submatches are ignored",
+			re:   regexp.MustCompile(`(\S+) is (?P<state>up|down)`),
+			s:    "ge-0/0/0 is down",
+			want: map[string]string{"state": "down"},
+		},
+		{
+			desc: "Empty submatch is kept",
+			re:   regexp.MustCompile(`Description:\s*(?P<desc>.*)$`),
+			s:    "Description:",
+			want: map[string]string{"desc": ""},
+		},
+		{
+			desc: "Submatch that did not participate is absent",
+			re:   regexp.MustCompile(`MTU: (?P<mtu>\d+)(, Speed: (?P<speed>\S+))?`),
+			s:    "MTU: 1514",
+			want: map[string]string{"mtu": "1514"},
+		},
+		{
+			desc:    "No match",
+			re:      regexp.MustCompile(`MTU: (?P<mtu>\d+)`),
+			s:       "Speed: 1000mbps",
+			noMatch: true,
+		},
+		{
+			desc:    "No named submatch participated",
+			re:      regexp.MustCompile(`MTU(: (?P<mtu>\d+))?`),
+			s:       "MTU",
+			noMatch: true,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := Match(test.re, test.s)
+		switch {
+		case err == nil && test.noMatch:
+			t.Errorf("TestMatch(%s): got err == nil, want err != nil", test.desc)
+			continue
+		case err != nil && !test.noMatch:
+			t.Errorf("TestMatch(%s): got err == %s, want err == nil", test.desc, err)
+			continue
+		case err != nil:
+			if !errors.Is(err, ErrNoMatch) || !strings.Contains(err.Error(), test.s) {
+				t.Errorf("TestMatch(%s): got err == %s, want ErrNoMatch with the input", test.desc, err)
+			}
+			continue
+		}
+		if diff := pretty.Compare(test.want, got); diff != "" {
+			t.Errorf("TestMatch(%s): -want/+got:\n%s", test.desc, diff)
+		}
+	}
+}
+
+type matchIface struct {
+	Name   string `re:"name"`
+	Unit   *int   `re:"unit"`
+	MTU    int
+	Speed  *string `re:"speed,omitempty"`
+	Addr   net.IP  `re:"addr"`
+	Flap   time.Duration
+	Up     bool   `re:"up"`
+	Ignore string `re:"-"`
+	other  int
+}
+
+func TestMatchInto(t *testing.T) {
+	re := regexp.MustCompile(`^(?P<name>[^.\s]+)(\.(?P<unit>\d+))? mtu (?P<mtu>\d*) speed (?P<speed>\S*) addr (?P<addr>\S+) flap (?P<flap>\S+) up (?P<up>\S+)$`)
+	unit := 10
+	speed := "1g"
+
+	tests := []struct {
+		desc    string
+		s       string
+		want    matchIface
+		wantErr bool
+	}{
+		{
+			desc: "All submatches",
+			s:    "ge-0/0/0.10 mtu 1514 speed 1g addr 10.0.0.1 flap 1h2m up true",
+			want: matchIface{
+				Name:  "ge-0/0/0",
+				Unit:  &unit,
+				MTU:   1514,
+				Speed: &speed,
+				Addr:  net.ParseIP("10.0.0.1"),
+				Flap:  time.Hour + 2*time.Minute,
+				Up:    true,
+			},
+		},
+		{
+			desc: "Absent submatch leaves a nil pointer, omitempty leaves a nil pointer",
+			s:    "ge-0/0/0 mtu 9000 speed  addr 10.0.0.1 flap 0s up false",
+			want: matchIface{
+				Name: "ge-0/0/0",
+				MTU:  9000,
+				Addr: net.ParseIP("10.0.0.1"),
+			},
+		},
+		{
+			desc:    "Empty submatch for an int",
+			s:       "ge-0/0/0 mtu  speed 1g addr 10.0.0.1 flap 0s up false",
+			wantErr: true,
+		},
+		{
+			desc:    "Bad conversion",
+			s:       "ge-0/0/0 mtu 1514 speed 1g addr 10.0.0.1 flap 0s up maybe",
+			wantErr: true,
+		},
+		{
+			desc:    "TextUnmarshaler error",
+			s:       "ge-0/0/0 mtu 1514 speed 1g addr 10.0.0.300 flap 0s up true",
+			wantErr: true,
+		},
+		{
+			desc:    "No match",
+			s:       "ge-0/0/0 is up",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		got := matchIface{}
+		err := MatchInto(re, test.s, &got)
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestMatchInto(%s): got err == nil, want err != nil", test.desc)
+			continue
+		case err != nil && !test.wantErr:
+			t.Errorf("TestMatchInto(%s): got err == %s, want err == nil", test.desc, err)
+			continue
+		case err != nil:
+			if !strings.Contains(err.Error(), test.s) {
+				t.Errorf("TestMatchInto(%s): got err == %s, want the input in the error", test.desc, err)
+			}
+			// Fields before the one that failed must not have been set.
+			if diff := pretty.Compare(matchIface{}, got); diff != "" {
+				t.Errorf("TestMatchInto(%s): dst was changed on an error, -want/+got:\n%s", test.desc, diff)
+			}
+			continue
+		}
+		if diff := pretty.Compare(test.want, got); diff != "" {
+			t.Errorf("TestMatchInto(%s): -want/+got:\n%s", test.desc, diff)
+		}
+	}
+}
+
+func TestNewRegexDecoder(t *testing.T) {
+	type ok struct {
+		Name string `re:"name,required"`
+	}
+	type noField struct {
+		Name string
+	}
+	type noGroup struct {
+		Name  string
+		State string `re:"state"`
+	}
+	type badType struct {
+		Name []string `re:"name"`
+	}
+	type twice struct {
+		Name  string
+		Other string `re:"name"`
+	}
+	type badOpt struct {
+		Name string `re:"name,sometimes"`
+	}
+	type unexported struct {
+		name string `re:"name"`
+	}
+
+	re := regexp.MustCompile(`(?P<name>\S+)( (?P<speed>\S+))?`)
+	nameRE := regexp.MustCompile(`(?P<name>\S+)?`)
+
+	tests := []struct {
+		desc    string
+		re      *regexp.Regexp
+		v       interface{}
+		wantErr bool
+	}{
+		{desc: "Struct", re: nameRE, v: ok{}},
+		{desc: "Pointer to struct", re: nameRE, v: &ok{}},
+		{desc: "Not a struct", re: nameRE, v: "name", wantErr: true},
+		{desc: "Nil", re: nameRE, v: nil, wantErr: true},
+		{desc: "Submatch without a field", re: re, v: noField{}, wantErr: true},
+		{desc: "Tag without a submatch", re: nameRE, v: noGroup{}, wantErr: true},
+		{desc: "Field type cannot be decoded", re: nameRE, v: badType{}, wantErr: true},
+		{desc: "Submatch mapped twice", re: nameRE, v: twice{}, wantErr: true},
+		{desc: "Unknown tag option", re: nameRE, v: badOpt{}, wantErr: true},
+		{desc: "Unexported field with a tag", re: nameRE, v: unexported{}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		_, err := NewRegexDecoder(test.re, test.v)
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestNewRegexDecoder(%s): got err == nil, want err != nil", test.desc)
+		case err != nil && !test.wantErr:
+			t.Errorf("TestNewRegexDecoder(%s): got err == %s, want err == nil", test.desc, err)
+		}
+	}
+
+	d := MustNewRegexDecoder(nameRE, ok{})
+	if err := d.Decode("", &ok{}); err == nil {
+		t.Errorf("TestNewRegexDecoder(required): got err == nil, want err != nil")
+	}
+	if err := d.Decode("router1", &noField{}); err == nil {
+		t.Errorf("TestNewRegexDecoder(wrong type): got err == nil, want err != nil")
+	}
+	got := ok{}
+	if err := d.Decode("router1", &got); err != nil || got.Name != "router1" {
+		t.Errorf("TestNewRegexDecoder(Decode): got (%+v, %v), want ({Name: router1}, nil)", got, err)
+	}
+}
+
+func TestParserMatchInto(t *testing.T) {
+	type mtu struct {
+		Type string
+		MTU  int
+	}
+	re := regexp.MustCompile(`Link-level type: (?P<type>\w+), MTU: (?P<mtu>\d+)`)
+
+	p, err := newParser(context.Background(), "  Link-level type: Ethernet, MTU: 1514, Speed: 1000mbps\n", WithProvenance(Provenance{}))
+	if err != nil {
+		panic(err)
+	}
+	go p.lex.run()
+	defer p.Close()
+
+	got := mtu{}
+	if err := p.MatchInto("intf", re, p.Next(), &got); err != nil {
+		t.Fatalf("TestParserMatchInto: got err == %s", err)
+	}
+	if diff := pretty.Compare(mtu{Type: "Ethernet", MTU: 1514}, got); diff != "" {
+		t.Errorf("TestParserMatchInto: -want/+got:\n%s", diff)
+	}
+
+	want := Provenance{
+		"intf.type": {LineNum: 0, Index: 2, Raw: "Ethernet"},
+		"intf.mtu":  {LineNum: 0, Index: 4, Raw: "1514"},
+	}
+	if diff := pretty.Compare(want, p.Provenance()); diff != "" {
+		t.Errorf("TestParserMatchInto(provenance): -want/+got:\n%s", diff)
+	}
+
+	if err := p.MatchInto("intf", re, p.Next(), &got); !errors.Is(err, ErrNoMatch) {
+		t.Errorf("TestParserMatchInto(EOF line): got err == %v, want ErrNoMatch", err)
+	}
+}
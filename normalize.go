@@ -0,0 +1,211 @@
+package halfpike
+
+import (
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// PosError is an error at a position in the content.
+type PosError struct {
+	// Offset is the byte offset in the content passed to Parse().
+	Offset int
+	// LineNum is the Line.LineNum of the line the error is on.
+	LineNum int
+	// Column is the byte offset of the error within the line.
+	Column int
+	// Err is the error.
+	Err error
+}
+
+// Error implements error.Error().
+func (p *PosError) Error() string {
+	return fmt.Sprintf("[Line %d, Column %d, Offset %d]: %s", p.LineNum, p.Column, p.Offset, p.Err)
+}
+
+// Unwrap returns the underlying error.
+func (p *PosError) Unwrap() error {
+	return p.Err
+}
+
+// WithLatin1 causes the content to be decoded as Latin-1 (ISO-8859-1) instead of UTF-8.
+// Use this for files from tools that do not output UTF-8.
+func WithLatin1() Option {
+	return func(p *Parser) {
+		p.latin1 = true
+	}
+}
+
+var (
+	bomUTF8    = "\xef\xbb\xbf"
+	bomUTF16LE = "\xff\xfe"
+	bomUTF16BE = "\xfe\xff"
+)
+
+// decode converts content to UTF-8. It removes a byte order mark, decodes UTF-16 (detected by its
+// byte order mark or the NUL bytes of ASCII text) and, if latin1 is set, Latin-1. Content that is
+// only separated by "\r" has those converted to "\n". Content that is not valid UTF-8 or UTF-16 returns
+// a *PosError.
+func decode(content string, latin1 bool) (string, error) {
+	var err error
+	switch {
+	case strings.HasPrefix(content, bomUTF16LE):
+		content, err = decodeUTF16(content[len(bomUTF16LE):], binary.LittleEndian, len(bomUTF16LE))
+		if err != nil {
+			return "", err
+		}
+	case strings.HasPrefix(content, bomUTF16BE):
+		content, err = decodeUTF16(content[len(bomUTF16BE):], binary.BigEndian, len(bomUTF16BE))
+		if err != nil {
+			return "", err
+		}
+	case latin1:
+		content = decodeLatin1(content)
+	default:
+		offset := 0
+		if strings.HasPrefix(content, bomUTF8) {
+			content = content[len(bomUTF8):]
+			offset = len(bomUTF8)
+		}
+		if order := detectUTF16(content); order != nil {
+			content, err = decodeUTF16(content, order, offset)
+			if err != nil {
+				return "", err
+			}
+			break
+		}
+		if err := validUTF8(content, offset); err != nil {
+			return "", err
+		}
+	}
+
+	if !strings.Contains(content, "\n") && strings.Contains(content, "\r") {
+		content = strings.ReplaceAll(content, "\r", "\n")
+	}
+	return content, nil
+}
+
+// detectUTF16 detects UTF-16 without a byte order mark by looking for the NUL bytes that are half of
+// every ASCII character. If the content does not look like UTF-16, nil is returned.
+func detectUTF16(content string) binary.ByteOrder {
+	n := len(content)
+	if n > 1024 {
+		n = 1024
+	}
+	// An odd trailing byte is left for decodeUTF16() to report.
+	n &^= 1
+	if n < 4 {
+		return nil
+	}
+
+	var evenNul, oddNul int
+	for i := 0; i < n; i++ {
+		if content[i] != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			evenNul++
+		} else {
+			oddNul++
+		}
+	}
+
+	pairs := n / 2
+	switch {
+	case evenNul == 0 && oddNul*10 >= pairs*4:
+		return binary.LittleEndian
+	case oddNul == 0 && evenNul*10 >= pairs*4:
+		return binary.BigEndian
+	}
+	return nil
+}
+
+// decodeUTF16 decodes UTF-16 content. An odd trailing byte or an unpaired surrogate returns a *PosError
+// instead of being dropped or becoming U+FFFD. "offset" is added to the reported offset to account for
+// bytes that were removed from the start of the content.
+func decodeUTF16(content string, order binary.ByteOrder, offset int) (string, error) {
+	b := strings.Builder{}
+	b.Grow(len(content) / 2)
+
+	lineNum, lineStart := 0, 0
+	posErr := func(i int, format string, a ...interface{}) error {
+		return &PosError{
+			Offset:  i + offset,
+			LineNum: lineNum,
+			Column:  i - lineStart,
+			Err:     fmt.Errorf(format, a...),
+		}
+	}
+
+	for i := 0; i < len(content); i += 2 {
+		if i+1 == len(content) {
+			return "", posErr(i, "UTF-16 content has an odd trailing byte 0x%02X", content[i])
+		}
+		r := rune(order.Uint16([]byte(content[i : i+2])))
+		switch {
+		case utf16.IsSurrogate(r):
+			if i+3 < len(content) {
+				low := rune(order.Uint16([]byte(content[i+2 : i+4])))
+				if pair := utf16.DecodeRune(r, low); pair != utf8.RuneError {
+					b.WriteRune(pair)
+					i += 2
+					continue
+				}
+			}
+			return "", posErr(i, "unpaired UTF-16 surrogate 0x%04X", r)
+		case r == '\n':
+			lineNum++
+			lineStart = i + 2
+		}
+		b.WriteRune(r)
+	}
+	return b.String(), nil
+}
+
+func decodeLatin1(content string) string {
+	b := strings.Builder{}
+	b.Grow(len(content))
+	for i := 0; i < len(content); i++ {
+		b.WriteRune(rune(content[i]))
+	}
+	return b.String()
+}
+
+// validUTF8 returns a *PosError for the first invalid UTF-8 byte in content. "offset" is added to
+// the reported offset to account for bytes that were removed from the start of the content.
+func validUTF8(content string, offset int) error {
+	if utf8.ValidString(content) {
+		return nil
+	}
+
+	for i := 0; i < len(content); {
+		r, size := utf8.DecodeRuneInString(content[i:])
+		if r == utf8.RuneError && size == 1 {
+			lineStart := strings.LastIndex(content[:i], "\n") + 1
+			return &PosError{
+				Offset:  i + offset,
+				LineNum: strings.Count(content[:i], "\n"),
+				Column:  i - lineStart,
+				Err:     fmt.Errorf("invalid UTF-8 byte 0x%02X", content[i]),
+			}
+		}
+		i += size
+	}
+	return nil
+}
+
+// lineEndRE matches the "\r"s at the end of a line.
+var lineEndRE = regexp.MustCompile(`\r+(\n|$)`)
+
+// normalizeLineEndings converts "\r\n" to "\n" and removes any other "\r" at the end of a line. A "\r"
+// within a line is left for ApplyCarriageReturns(), as making it a line break would change the
+// Line.LineNum of every line after it. The lexer treats one that remains as a space.
+func normalizeLineEndings(content string) string {
+	if !strings.Contains(content, "\r") {
+		return content
+	}
+	return lineEndRE.ReplaceAllString(content, "\n")
+}
@@ -0,0 +1,262 @@
+package halfpike
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func utf16Bytes(s string, order binary.ByteOrder, bom bool) string {
+	u := utf16.Encode([]rune(s))
+	if bom {
+		u = append([]uint16{0xFEFF}, u...)
+	}
+	b := make([]byte, len(u)*2)
+	for i, c := range u {
+		order.PutUint16(b[i*2:], c)
+	}
+	return string(b)
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		desc    string
+		content string
+		latin1  bool
+		want    string
+		wantErr *PosError
+	}{
+		{
+			desc:    "UTF-8",
+			content: "Hostname: router-1\n",
+			want:    "Hostname: router-1\n",
+		},
+		{
+			desc:    "UTF-8 BOM",
+			content: "\xef\xbb\xbfHostname: router-1\n",
+			want:    "Hostname: router-1\n",
+		},
+		{
+			desc:    "UTF-16LE BOM",
+			content: utf16Bytes("Hostname: router-1\n", binary.LittleEndian, true),
+			want:    "Hostname: router-1\n",
+		},
+		{
+			desc:    "UTF-16BE BOM",
+			content: utf16Bytes("Description: café\n", binary.BigEndian, true),
+			want:    "Description: café\n",
+		},
+		{
+			desc:    "UTF-16LE without BOM",
+			content: utf16Bytes("Hostname: router-1\n", binary.LittleEndian, false),
+			want:    "Hostname: router-1\n",
+		},
+		{
+			desc:    "UTF-16BE without BOM",
+			content: utf16Bytes("Hostname: router-1\n", binary.BigEndian, false),
+			want:    "Hostname: router-1\n",
+		},
+		{
+			desc:    "UTF-16LE surrogate pair",
+			content: utf16Bytes("Description: \U0001F600\n", binary.LittleEndian, true),
+			want:    "Description: \U0001F600\n",
+		},
+		{
+			desc:    "UTF-16LE odd trailing byte",
+			content: utf16Bytes("line one\nab", binary.LittleEndian, true) + "c",
+			wantErr: &PosError{Offset: 24, LineNum: 1, Column: 4},
+		},
+		{
+			desc:    "UTF-16BE unpaired high surrogate",
+			content: utf16Bytes("line one\nab", binary.BigEndian, true) + "\xd8\x3d\x00a",
+			wantErr: &PosError{Offset: 24, LineNum: 1, Column: 4},
+		},
+		{
+			desc:    "UTF-16LE odd trailing byte without BOM",
+			content: utf16Bytes("Hostname: router-1\nab", binary.LittleEndian, false) + "c",
+			wantErr: &PosError{Offset: 42, LineNum: 1, Column: 4},
+		},
+		{
+			desc:    "UTF-16LE unpaired low surrogate without BOM",
+			content: utf16Bytes("Hostname: router-1\nab", binary.LittleEndian, false) + "\x41\xdc",
+			wantErr: &PosError{Offset: 42, LineNum: 1, Column: 4},
+		},
+		{
+			desc:    "Latin-1",
+			content: "Description: caf\xe9\n",
+			latin1:  true,
+			want:    "Description: café\n",
+		},
+		{
+			desc:    "CR only line endings",
+			content: "line one\rline two\r",
+			want:    "line one\nline two\n",
+		},
+		{
+			desc:    "Invalid UTF-8",
+			content: "line one\nDescription: caf\xe9\n",
+			wantErr: &PosError{Offset: 25, LineNum: 1, Column: 16},
+		},
+		{
+			desc:    "Invalid UTF-8 after BOM",
+			content: "\xef\xbb\xbf\xff\n",
+			wantErr: &PosError{Offset: 3, LineNum: 0, Column: 0},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := decode(test.content, test.latin1)
+		switch {
+		case err == nil && test.wantErr != nil:
+			t.Errorf("TestDecode(%s): got err == nil, want err != nil", test.desc)
+			continue
+		case err != nil && test.wantErr == nil:
+			t.Errorf("TestDecode(%s): got err == %s", test.desc, err)
+			continue
+		case err != nil:
+			pErr := &PosError{}
+			if !errors.As(err, &pErr) {
+				t.Errorf("TestDecode(%s): got err == %T, want *PosError", test.desc, err)
+				continue
+			}
+			pErr.Err = nil
+			if diff := pretty.Compare(test.wantErr, pErr); diff != "" {
+				t.Errorf("TestDecode(%s): PosError -want/+got:\n%s", test.desc, diff)
+			}
+			continue
+		}
+		if got != test.want {
+			t.Errorf("TestDecode(%s): got %q, want %q", test.desc, got, test.want)
+		}
+	}
+}
+
+func TestNormalizeLineEndings(t *testing.T) {
+	tests := []struct {
+		desc    string
+		content string
+		want    string
+	}{
+		{
+			desc:    "LF",
+			content: "one\ntwo\n",
+			want:    "one\ntwo\n",
+		},
+		{
+			desc:    "CRLF",
+			content: "one\r\ntwo\r\n",
+			want:    "one\ntwo\n",
+		},
+		{
+			desc:    "CR before CRLF",
+			content: "one\r\r\ntwo\r",
+			want:    "one\ntwo\n",
+		},
+		{
+			desc:    "CR within a line is kept",
+			content: "one\r\n---(more)---\r   \rtwo\r\nthree\n",
+			want:    "one\n---(more)---\r   \rtwo\nthree\n",
+		},
+	}
+
+	for _, test := range tests {
+		if got := normalizeLineEndings(test.content); got != test.want {
+			t.Errorf("TestNormalizeLineEndings(%s): got %q, want %q", test.desc, got, test.want)
+		}
+	}
+}
+
+// lineObj records the text and line number of each line.
+type lineObj struct {
+	lines []string
+	nums  []int
+}
+
+func (l *lineObj) Start(ctx context.Context, p *Parser) ParseFn {
+	for {
+		line := p.Next()
+		if p.EOF(line) {
+			return nil
+		}
+		l.lines = append(l.lines, ItemJoin(line, -1, -1))
+		l.nums = append(l.nums, line.LineNum)
+	}
+}
+
+func (l *lineObj) Validate() error {
+	return nil
+}
+
+func TestParseNormalization(t *testing.T) {
+	tests := []struct {
+		desc      string
+		content   string
+		options   []Option
+		wantLines []string
+		wantNums  []int
+		err       bool
+	}{
+		{
+			desc:      "Windows line endings",
+			content:   "Hostname: router-1\r\nModel: mx960\r\n",
+			wantLines: []string{"Hostname: router-1", "Model: mx960"},
+			wantNums:  []int{0, 1},
+		},
+		{
+			desc:      "UTF-16LE with Windows line endings",
+			content:   utf16Bytes("Hostname: router-1\r\nModel: mx960\r\n", binary.LittleEndian, true),
+			wantLines: []string{"Hostname: router-1", "Model: mx960"},
+			wantNums:  []int{0, 1},
+		},
+		{
+			desc:      "CR within a line does not add a line",
+			content:   "one\n---(more)---\r   \rtwo\nthree\n",
+			wantLines: []string{"one", "---(more)--- two", "three"},
+			wantNums:  []int{0, 1, 2},
+		},
+		{
+			desc:      "CR within a line is applied by a Preprocessor",
+			content:   "one\n---(more)---\r            \rtwo\nthree\n",
+			options:   []Option{WithPreprocessors(TerminalCleanup...)},
+			wantLines: []string{"one", "two", "three"},
+			wantNums:  []int{0, 1, 2},
+		},
+		{
+			desc:      "Latin-1",
+			content:   "Description: caf\xe9\n",
+			options:   []Option{WithLatin1()},
+			wantLines: []string{"Description: café"},
+			wantNums:  []int{0},
+		},
+		{
+			desc:    "Invalid UTF-8",
+			content: "Description: caf\xe9\n",
+			err:     true,
+		},
+	}
+
+	for _, test := range tests {
+		obj := &lineObj{}
+		err := Parse(context.Background(), test.content, obj, test.options...)
+		switch {
+		case err == nil && test.err:
+			t.Errorf("TestParseNormalization(%s): got err == nil, want err != nil", test.desc)
+			continue
+		case err != nil && !test.err:
+			t.Errorf("TestParseNormalization(%s): got err == %s", test.desc, err)
+			continue
+		case err != nil:
+			continue
+		}
+		if diff := pretty.Compare(test.wantLines, obj.lines); diff != "" {
+			t.Errorf("TestParseNormalization(%s): lines -want/+got:\n%s", test.desc, diff)
+		}
+		if diff := pretty.Compare(test.wantNums, obj.nums); diff != "" {
+			t.Errorf("TestParseNormalization(%s): LineNums -want/+got:\n%s", test.desc, diff)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package cisco
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johnsiilver/halfpike"
+	"github.com/johnsiilver/halfpike/parsers/model"
+	"github.com/johnsiilver/halfpike/units"
+)
+
+// BGPSummary is the output of "show ip bgp summary". It implements halfpike.ParseObject.
+type BGPSummary struct {
+	// RouterID is the BGP router identifier.
+	RouterID net.IP
+	// LocalAS is the local autonomous system number.
+	LocalAS int
+	// TableVersion is the BGP table version.
+	TableVersion int
+	// Neighbors are the BGP neighbors.
+	Neighbors []*BGPSummaryNeighbor
+
+	parser *halfpike.Parser
+}
+
+// BGPSummaryNeighbor is a neighbor entry in "show ip bgp summary".
+type BGPSummaryNeighbor struct {
+	// PeerIP is the IP address of the neighbor.
+	PeerIP net.IP
+	// Version is the BGP version the neighbor uses.
+	Version int
+	// PeerAS is the neighbor's autonomous system number.
+	PeerAS int
+	// MsgRcvd is the number of messages received from the neighbor.
+	MsgRcvd int
+	// MsgSent is the number of messages sent to the neighbor.
+	MsgSent int
+	// TableVersion is the last version of the BGP table sent to the neighbor.
+	TableVersion int
+	// InQ is the number of messages waiting to be processed from the neighbor.
+	InQ int
+	// OutQ is the number of messages waiting to be sent to the neighbor.
+	OutQ int
+	// UpDown is how long the session has been in its current state. This is 0 if it has "never" been up.
+	UpDown time.Duration
+	// State is the current state of the session.
+	State model.BGPState
+	// StateReason is the reason given for the state, like "Admin" in "Idle (Admin)".
+	StateReason string
+	// PrefixesReceived is the number of prefixes received. This is only set if the session is
+	// established.
+	PrefixesReceived int
+}
+
+// Validate implements halfpike.Validator.Validate().
+func (b *BGPSummary) Validate() error {
+	if b.RouterID == nil {
+		return fmt.Errorf("BGPSummary: RouterID was not set")
+	}
+	for _, n := range b.Neighbors {
+		if n.State == model.NSUnknown {
+			return fmt.Errorf("Neighbor(%s): State was not set", n.PeerIP)
+		}
+	}
+	return nil
+}
+
+// Start implements halfpike.ParseObject.Start().
+func (b *BGPSummary) Start(ctx context.Context, p *halfpike.Parser) halfpike.ParseFn {
+	b.parser = p
+	return b.findRouterID
+}
+
+var (
+	// BGP router identifier 192.0.2.1, local AS number 65000
+	routerIDRE = regexp.MustCompile(`^BGP router identifier (?P<id>[^,]+), local AS number (?P<as>[\d.]+)`)
+	// BGP table version is 1234, main routing table version 1234
+	tableVerRE = regexp.MustCompile(`^BGP table version is (?P<ver>\d+)`)
+)
+
+var neighborHeader = []string{"Neighbor", "V", "AS", "MsgRcvd", "MsgSent", "TblVer", "InQ", "OutQ", "Up/Down", "State/PfxRcd"}
+
+func (b *BGPSummary) findRouterID(ctx context.Context, p *halfpike.Parser) halfpike.ParseFn {
+	line, err := p.FindStart([]string{"BGP", "router", "identifier"})
+	if err != nil {
+		return p.Errorf("could not find the BGP router identifier line")
+	}
+	m, err := halfpike.Match(routerIDRE, halfpike.ItemJoin(line, -1, -1))
+	if err != nil {
+		return p.Errorf("[Line %d]: BGP router identifier line had unknown format", line.LineNum)
+	}
+	if b.RouterID = net.ParseIP(m["id"]); b.RouterID == nil {
+		return p.Errorf("[Line %d]: BGP router identifier %q was not an IP", line.LineNum, m["id"])
+	}
+	if b.LocalAS, err = parseAS(m["as"]); err != nil {
+		return p.Errorf("[Line %d]: local AS number: %s", line.LineNum, err)
+	}
+	return b.findNeighbors
+}
+
+func (b *BGPSummary) findNeighbors(ctx context.Context, p *halfpike.Parser) halfpike.ParseFn {
+	for {
+		line := p.Next()
+		if p.EOF(line) {
+			// Devices output a summary without a neighbor table if none are configured.
+			return nil
+		}
+		if m, err := halfpike.Match(tableVerRE, halfpike.ItemJoin(line, -1, -1)); err == nil {
+			b.TableVersion, _ = strconv.Atoi(m["ver"])
+			continue
+		}
+		if p.IsAtStart(line, neighborHeader) {
+			return b.neighbors
+		}
+	}
+}
+
+// neighbors decodes the rows of the neighbor table:
+//
+//	10.0.0.2        4        65001   12345   12340     1234    0    0 1w2d          150
+//	10.0.0.6        4        65002       0       0        1    0    0 never    Idle (Admin)
+//	2001:DB8:100:200::2
+//	                4     65000.10      50      51       12    0    0 00:10:00        3
+func (b *BGPSummary) neighbors(ctx context.Context, p *halfpike.Parser) halfpike.ParseFn {
+	for {
+		line := p.Next()
+		if p.EOF(line) {
+			return nil
+		}
+
+		f := strings.Fields(halfpike.ItemJoin(line, -1, -1))
+		// The table ends at the first line that isn't a neighbor, such as a blank line or the
+		// summary text some versions output after it.
+		if len(f) == 0 || net.ParseIP(f[0]) == nil {
+			return nil
+		}
+		// A neighbor too long for its column, usually IPv6, is on a line of its own and the
+		// rest of its row is on the next line.
+		if len(f) == 1 {
+			next := p.Next()
+			if p.EOF(next) {
+				return p.Errorf("[Line %d]: neighbor %s did not have a row after it", line.LineNum, f[0])
+			}
+			f = append(f, strings.Fields(halfpike.ItemJoin(next, -1, -1))...)
+		}
+		if len(f) < 10 {
+			return p.Errorf("[Line %d]: neighbor entry had %d fields, expected at least 10", line.LineNum, len(f))
+		}
+
+		n := &BGPSummaryNeighbor{}
+		if n.PeerIP = net.ParseIP(f[0]); n.PeerIP == nil {
+			return p.Errorf("[Line %d]: neighbor %q was not an IP", line.LineNum, f[0])
+		}
+		if err := n.decode(f[1:]); err != nil {
+			return p.Errorf("[Line %d] Neighbor(%s): %s", line.LineNum, n.PeerIP, err)
+		}
+		b.Neighbors = append(b.Neighbors, n)
+	}
+}
+
+// decode decodes the fields after the neighbor's IP.
+func (n *BGPSummaryNeighbor) decode(f []string) error {
+	ints := []struct {
+		name  string
+		index int
+		v     *int
+	}{
+		{"V", 0, &n.Version},
+		{"MsgRcvd", 2, &n.MsgRcvd},
+		{"MsgSent", 3, &n.MsgSent},
+		{"TblVer", 4, &n.TableVersion},
+		{"InQ", 5, &n.InQ},
+		{"OutQ", 6, &n.OutQ},
+	}
+	for _, in := range ints {
+		v, err := strconv.Atoi(f[in.index])
+		if err != nil {
+			return fmt.Errorf("%s column was not an integer: %q", in.name, f[in.index])
+		}
+		*in.v = v
+	}
+
+	var err error
+	if n.PeerAS, err = parseAS(f[1]); err != nil {
+		return fmt.Errorf("AS column: %s", err)
+	}
+	// A session that has "never" been up has an UpDown of 0.
+	if f[7] != "never" {
+		if n.UpDown, err = units.ParseUptime(f[7]); err != nil {
+			return fmt.Errorf("Up/Down column: %s", err)
+		}
+	}
+
+	state := f[8:]
+	if pfx, err := strconv.Atoi(state[0]); err == nil {
+		n.State, n.PrefixesReceived = model.NSEstablished, pfx
+		return nil
+	}
+
+	s, ok := model.ParseBGPState(state[0])
+	if !ok {
+		return fmt.Errorf("State/PfxRcd column %q is not a known state", state[0])
+	}
+	n.State = s
+	if len(state) > 1 {
+		n.StateReason = strings.Trim(strings.Join(state[1:], " "), "()")
+	}
+	return nil
+}
+
+// parseAS parses an AS number in asplain ("4200000001") or asdot ("64086.59904") notation.
+func parseAS(s string) (int, error) {
+	hi, lo, dot := strings.Cut(s, ".")
+	if !dot {
+		as, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("AS %q was not an integer", s)
+		}
+		return int(as), nil
+	}
+
+	h, err := strconv.ParseUint(hi, 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("asdot AS %q had a bad high order value", s)
+	}
+	l, err := strconv.ParseUint(lo, 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("asdot AS %q had a bad low order value", s)
+	}
+	return int(h<<16 | l), nil
+}
@@ -0,0 +1,168 @@
+package cisco
+
+import (
+	"context"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/johnsiilver/halfpike"
+	"github.com/johnsiilver/halfpike/parsers/model"
+)
+
+func TestBGPSummary(t *testing.T) {
+	established := func(ip string, as, rcvd, sent, tblVer int, upDown time.Duration, pfx int) *BGPSummaryNeighbor {
+		return &BGPSummaryNeighbor{
+			PeerIP:           net.ParseIP(ip),
+			Version:          4,
+			PeerAS:           as,
+			MsgRcvd:          rcvd,
+			MsgSent:          sent,
+			TableVersion:     tblVer,
+			UpDown:           upDown,
+			State:            model.NSEstablished,
+			PrefixesReceived: pfx,
+		}
+	}
+	idle := &BGPSummaryNeighbor{
+		PeerIP:       net.ParseIP("10.0.0.6"),
+		Version:      4,
+		PeerAS:       65002,
+		TableVersion: 1,
+		State:        model.NSIdle,
+		StateReason:  "Admin",
+	}
+
+	tests := []struct {
+		file string
+		want *BGPSummary
+	}{
+		{
+			file: "show_ip_bgp_summary.txt",
+			want: &BGPSummary{
+				RouterID:     net.ParseIP("192.0.2.1"),
+				LocalAS:      65000,
+				TableVersion: 1234,
+				Neighbors: []*BGPSummaryNeighbor{
+					established("10.0.0.2", 65001, 12345, 12340, 1234, week+2*day, 150),
+					idle,
+					{
+						PeerIP:  net.ParseIP("10.0.0.10"),
+						Version: 4,
+						PeerAS:  4200000001,
+						MsgRcvd: 10,
+						MsgSent: 12,
+						UpDown:  83 * time.Second,
+						State:   model.NSActive,
+					},
+					established("10.0.0.14", 65004, 200, 210, 1234, 3*day+4*time.Hour, 2),
+				},
+			},
+		},
+		{
+			// The table is followed by text that isn't a neighbor.
+			file: "show_ip_bgp_summary_trailer.txt",
+			want: &BGPSummary{
+				RouterID:     net.ParseIP("192.0.2.1"),
+				LocalAS:      65000,
+				TableVersion: 1234,
+				Neighbors: []*BGPSummaryNeighbor{
+					established("10.0.0.2", 65001, 12345, 12340, 1234, week+2*day, 150),
+					idle,
+				},
+			},
+		},
+		{
+			// IPv6 neighbors that are too long for their column wrap onto a second line.
+			file: "show_bgp_ipv6_unicast_summary.txt",
+			want: &BGPSummary{
+				RouterID:     net.ParseIP("192.0.2.1"),
+				LocalAS:      65000,
+				TableVersion: 12,
+				Neighbors: []*BGPSummaryNeighbor{
+					established("2001:DB8:1::2", 65001, 100, 101, 12, time.Hour+2*time.Minute+3*time.Second, 5),
+					established("2001:DB8:100:200::2", 65002, 50, 51, 12, 10*time.Minute, 3),
+					{
+						PeerIP:       net.ParseIP("2001:DB8:100:200::6"),
+						Version:      4,
+						PeerAS:       65003,
+						TableVersion: 1,
+						State:        model.NSActive,
+					},
+				},
+			},
+		},
+		{
+			// AS numbers in asdot notation.
+			file: "show_ip_bgp_summary_asdot.txt",
+			want: &BGPSummary{
+				RouterID:     net.ParseIP("192.0.2.1"),
+				LocalAS:      65546,
+				TableVersion: 1234,
+				Neighbors: []*BGPSummaryNeighbor{
+					established("10.0.0.2", 4259840010, 12345, 12340, 1234, week+2*day, 150),
+					idle,
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		b, err := os.ReadFile("testdata/" + test.file)
+		if err != nil {
+			t.Fatalf("TestBGPSummary(%s): got err == %s", test.file, err)
+		}
+
+		got := &BGPSummary{}
+		if err := halfpike.Parse(context.Background(), string(b), got); err != nil {
+			t.Errorf("TestBGPSummary(%s): got err == %s", test.file, err)
+			continue
+		}
+		if diff := cmpConfig.Compare(test.want, got); diff != "" {
+			t.Errorf("TestBGPSummary(%s): -want/+got:\n%s", test.file, diff)
+		}
+	}
+}
+
+func TestBGPSummaryErrors(t *testing.T) {
+	const header = `BGP router identifier 192.0.2.1, local AS number 65000
+Neighbor        V           AS MsgRcvd MsgSent   TblVer  InQ OutQ Up/Down  State/PfxRcd
+`
+
+	tests := []struct {
+		desc    string
+		content string
+	}{
+		{
+			desc:    "No router identifier",
+			content: "nothing to see here\n",
+		},
+		{
+			desc:    "Bad state",
+			content: header + "10.0.0.6        4        65002       0       0        1    0    0 never    Dancing\n",
+		},
+		{
+			desc:    "Bad Up/Down",
+			content: header + "10.0.0.6        4        65002       0       0        1    0    0 1z    Idle\n",
+		},
+		{
+			desc:    "Bad asdot AS",
+			content: header + "10.0.0.6        4        65002.70000       0       0        1    0    0 never    Idle\n",
+		},
+		{
+			desc:    "Wrapped neighbor without a row",
+			content: header + "2001:DB8:100:200::2\n",
+		},
+		{
+			desc:    "Bad AS",
+			content: header + "10.0.0.6        4        AS65002       0       0        1    0    0 never    Idle\n",
+		},
+	}
+
+	for _, test := range tests {
+		if err := halfpike.Parse(context.Background(), test.content, &BGPSummary{}); err == nil {
+			t.Errorf("TestBGPSummaryErrors(%s): got err == nil, want err != nil", test.desc)
+		}
+	}
+}
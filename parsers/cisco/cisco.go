@@ -0,0 +1,32 @@
+// Package cisco provides halfpike parsers for the output of Cisco IOS and IOS-XE commands.
+// Data that is also reported by other vendors, such as interface states and BGP states, uses the
+// types in the model package.
+package cisco
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// counterList decodes a list of counters in the form "2 input errors, 1 CRC, 0 frame" into
+// a map of counter name to value.
+func counterList(s string) (map[string]int64, error) {
+	m := map[string]int64{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		sp := strings.SplitN(part, " ", 2)
+		if len(sp) != 2 {
+			return nil, fmt.Errorf("counter %q did not have a value and name", part)
+		}
+		n, err := strconv.ParseInt(sp[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("counter %q did not start with an integer", part)
+		}
+		m[strings.TrimSpace(sp[1])] = n
+	}
+	return m, nil
+}
@@ -0,0 +1,16 @@
+package cisco
+
+import (
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+var (
+	day  = 24 * time.Hour
+	week = 7 * day
+	year = 365 * day
+)
+
+// cmpConfig is used to compare results without unexported fields.
+var cmpConfig = pretty.Config{Diffable: true}
@@ -0,0 +1,314 @@
+package cisco
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/johnsiilver/halfpike"
+	"github.com/johnsiilver/halfpike/parsers/model"
+)
+
+// Interfaces is the output of "show interfaces". It implements halfpike.ParseObject.
+type Interfaces struct {
+	Interfaces []*Interface
+
+	parser *halfpike.Parser
+}
+
+// Interface is a network interface in "show interfaces".
+type Interface struct {
+	// Name is the name of the interface, like GigabitEthernet0/0.
+	Name string
+	// Description is the interface's description.
+	Description string
+	// State is the interface's administrative state.
+	State model.InterState
+	// Status is the interface's operational (line protocol) status.
+	Status model.InterStatus
+	// Hardware is the hardware type, like "iGbE" or "Loopback".
+	Hardware string
+	// MAC is the current MAC address. This is nil if the interface does not have one.
+	MAC net.HardwareAddr
+	// BIA is the burned in MAC address. This is nil if the interface does not have one.
+	BIA net.HardwareAddr
+	// Address is the interface's IP address and network mask. This is nil if not set.
+	Address *net.IPNet
+	// MTU is the maximum amount of bytes that can be sent in a frame.
+	MTU int
+	// Bandwidth is the configured bandwidth in bits per second.
+	Bandwidth int64
+	// Encapsulation is the encapsulation, like "ARPA".
+	Encapsulation string
+	// InputRate is the input rate.
+	InputRate model.Rate
+	// OutputRate is the output rate.
+	OutputRate model.Rate
+	// Traffic are the traffic statistics. This is nil if the device did not output them.
+	Traffic *model.TrafficStats
+	// Counters are the other interface counters keyed by name, like "CRC" or "input errors".
+	Counters map[string]int64
+
+	initCalled bool
+}
+
+func (i *Interface) init() {
+	i.MTU = -1
+	i.Counters = map[string]int64{}
+	i.initCalled = true
+}
+
+// Validate implements halfpike.Validator.
+func (i *Interface) Validate() error {
+	if !i.initCalled {
+		return fmt.Errorf("an Interface did not have init() called before storing data")
+	}
+
+	switch {
+	case i.Name == "":
+		return fmt.Errorf("an Interface did not have Name assigned")
+	case i.State == model.IStateUnknown:
+		return fmt.Errorf("Interface(%s): State was not set", i.Name)
+	case i.Status == model.IStatUnknown:
+		return fmt.Errorf("Interface(%s): Status was not set", i.Name)
+	case i.MTU == -1:
+		return fmt.Errorf("Interface(%s): MTU was not set", i.Name)
+	}
+	return nil
+}
+
+// Validate implements halfpike.Validator.Validate().
+func (i *Interfaces) Validate() error {
+	for _, v := range i.Interfaces {
+		if err := v.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Start implements halfpike.ParseObject.Start().
+func (i *Interfaces) Start(ctx context.Context, p *halfpike.Parser) halfpike.ParseFn {
+	i.parser = p
+	return i.findInterface
+}
+
+// GigabitEthernet0/1 is administratively down, line protocol is down (disabled)
+var interStartRE = regexp.MustCompile(`^(?P<name>\S+) is (?P<state>[^,]+), line protocol is (?P<proto>\S+)`)
+
+func (i *Interfaces) findInterface(ctx context.Context, p *halfpike.Parser) halfpike.ParseFn {
+	for {
+		line := p.Next()
+		if m, err := halfpike.Match(interStartRE, halfpike.ItemJoin(line, -1, -1)); err == nil {
+			return i.newInterface(line, m)
+		}
+		if p.EOF(line) {
+			if len(i.Interfaces) == 0 {
+				return p.Errorf("could not find an interface in the output")
+			}
+			return nil
+		}
+	}
+}
+
+func (i *Interfaces) newInterface(line halfpike.Line, m map[string]string) halfpike.ParseFn {
+	inter := &Interface{Name: m["name"]}
+	inter.init()
+	i.Interfaces = append(i.Interfaces, inter)
+
+	switch m["state"] {
+	case "up", "down":
+		inter.State = model.IStateEnabled
+	case "administratively down", "deleted":
+		inter.State = model.IStateDisabled
+	default:
+		return i.errorf(line, "interface state %q is not a known state", m["state"])
+	}
+
+	var err error
+	if inter.Status, err = protocolStatus(m["proto"]); err != nil {
+		return i.errorf(line, "%s", err)
+	}
+	return i.interAttrs
+}
+
+// interAttrs decodes all lines belonging to the current interface until we find the next interface or EOF.
+func (i *Interfaces) interAttrs(ctx context.Context, p *halfpike.Parser) halfpike.ParseFn {
+	inter := i.current()
+
+	for {
+		line := p.Next()
+		if interStartRE.MatchString(halfpike.ItemJoin(line, -1, -1)) {
+			p.Backup()
+			return i.findInterface
+		}
+
+		handled := false
+		for _, h := range interHandlers {
+			if !p.IsAtStart(line, h.find) {
+				continue
+			}
+			if err := h.decode(inter, line); err != nil {
+				return i.errorf(line, "%s", err)
+			}
+			handled = true
+			break
+		}
+		if !handled && len(line.Items) > 0 && line.Items[0].Type == halfpike.ItemInt {
+			if err := decodeCounters(inter, line); err != nil {
+				return i.errorf(line, "%s", err)
+			}
+		}
+
+		if p.EOF(line) {
+			return nil
+		}
+	}
+}
+
+// current returns the interface we are currently decoding.
+func (i *Interfaces) current() *Interface {
+	if len(i.Interfaces) == 0 {
+		return nil
+	}
+	return i.Interfaces[len(i.Interfaces)-1]
+}
+
+func (i *Interfaces) errorf(line halfpike.Line, s string, a ...interface{}) halfpike.ParseFn {
+	if cur := i.current(); cur != nil {
+		return i.parser.Errorf("[Line %d] interface(%s): %s", line.LineNum, cur.Name, fmt.Sprintf(s, a...))
+	}
+	return i.parser.Errorf("[Line %d]: %s", line.LineNum, fmt.Sprintf(s, a...))
+}
+
+// interHandler decodes a line that starts with "find".
+type interHandler struct {
+	find   []string
+	decode func(inter *Interface, line halfpike.Line) error
+}
+
+// interHandlers are the handlers for lines within an interface record. Lines that do not match a
+// handler are ignored, as IOS adds lines depending on the version and interface type. Lines that
+// start with an integer and do not match a handler are decoded as counters.
+var interHandlers = []interHandler{
+	{[]string{"Hardware", "is", halfpike.Skip}, decodeHardware},
+	{[]string{"Description:", halfpike.Skip}, decodeDescription},
+	{[]string{"Internet", "address", "is", halfpike.Skip}, decodeAddress},
+	{[]string{"MTU", halfpike.Skip, "bytes,", "BW", halfpike.Skip}, decodeMTU},
+	{[]string{"Encapsulation", halfpike.Skip}, decodeEncap},
+	{[]string{halfpike.Skip, halfpike.Skip, "input", "rate"}, decodeRate},
+	{[]string{halfpike.Skip, halfpike.Skip, "output", "rate"}, decodeRate},
+}
+
+// Hardware is iGbE, address is 5254.0012.3456 (bia 5254.0012.3456)
+var hardwareRE = regexp.MustCompile(`^Hardware is (?P<hw>[^,]+)(, address is (?P<mac>\S+) \(bia (?P<bia>[^)]+)\))?`)
+
+func decodeHardware(inter *Interface, line halfpike.Line) error {
+	m, err := halfpike.Match(hardwareRE, halfpike.ItemJoin(line, -1, -1))
+	if err != nil {
+		return fmt.Errorf("Hardware line had unknown format")
+	}
+	inter.Hardware = m["hw"]
+	if m["mac"] == "" {
+		return nil
+	}
+	if inter.MAC, err = net.ParseMAC(m["mac"]); err != nil {
+		return fmt.Errorf("address %q was not a MAC address", m["mac"])
+	}
+	if inter.BIA, err = net.ParseMAC(m["bia"]); err != nil {
+		return fmt.Errorf("bia %q was not a MAC address", m["bia"])
+	}
+	return nil
+}
+
+// Description: uplink to core-1
+func decodeDescription(inter *Interface, line halfpike.Line) error {
+	inter.Description = halfpike.ItemJoin(line, 1, -1)
+	return nil
+}
+
+// Internet address is 10.1.1.1/30
+func decodeAddress(inter *Interface, line halfpike.Line) error {
+	ip, n, err := net.ParseCIDR(line.Items[3].Val)
+	if err != nil {
+		return fmt.Errorf("Internet address %q was not in CIDR format", line.Items[3].Val)
+	}
+	inter.Address = &net.IPNet{IP: ip, Mask: n.Mask}
+	return nil
+}
+
+// MTU 1500 bytes, BW 1000000 Kbit/sec, DLY 10 usec,
+func decodeMTU(inter *Interface, line halfpike.Line) error {
+	mtu, err := line.Items[1].ToInt()
+	if err != nil {
+		return fmt.Errorf("MTU was not an integer: %q", line.Items[1].Val)
+	}
+	bw, err := strconv.ParseInt(line.Items[4].Val, 10, 64)
+	if err != nil {
+		return fmt.Errorf("BW was not an integer: %q", line.Items[4].Val)
+	}
+	inter.MTU, inter.Bandwidth = mtu, bw*1000
+	return nil
+}
+
+// Encapsulation ARPA, loopback not set
+func decodeEncap(inter *Interface, line halfpike.Line) error {
+	inter.Encapsulation = strings.TrimSuffix(line.Items[1].Val, ",")
+	return nil
+}
+
+// 5 minute input rate 2000 bits/sec, 3 packets/sec
+var rateRE = regexp.MustCompile(`^\d+ \S+ (?P<dir>input|output) rate (?P<bps>\d+) bits/sec, (?P<pps>\d+) packets/sec`)
+
+func decodeRate(inter *Interface, line halfpike.Line) error {
+	m, err := halfpike.Match(rateRE, halfpike.ItemJoin(line, -1, -1))
+	if err != nil {
+		return fmt.Errorf("rate had unknown format")
+	}
+	bps, _ := strconv.ParseInt(m["bps"], 10, 64)
+	pps, _ := strconv.ParseInt(m["pps"], 10, 64)
+
+	r := model.Rate{BPS: bps, PPS: pps}
+	if m["dir"] == "input" {
+		inter.InputRate = r
+	} else {
+		inter.OutputRate = r
+	}
+	return nil
+}
+
+// decodeCounters decodes a line of counters such as:
+// 123456 packets input, 98765432 bytes, 0 no buffer
+// 2 input errors, 1 CRC, 0 frame, 0 overrun, 0 ignored
+// Packet and byte counters are stored in Traffic, all others in Counters.
+func decodeCounters(inter *Interface, line halfpike.Line) error {
+	counters, err := counterList(halfpike.ItemJoin(line, -1, -1))
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range []string{"input", "output"} {
+		pkts, ok := counters["packets "+dir]
+		if !ok {
+			continue
+		}
+		if inter.Traffic == nil {
+			inter.Traffic = &model.TrafficStats{}
+		}
+		if dir == "input" {
+			inter.Traffic.InputPackets, inter.Traffic.InputBytes = pkts, counters["bytes"]
+		} else {
+			inter.Traffic.OutputPackets, inter.Traffic.OutputBytes = pkts, counters["bytes"]
+		}
+		delete(counters, "packets "+dir)
+		delete(counters, "bytes")
+	}
+
+	for k, v := range counters {
+		inter.Counters[k] = v
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package cisco
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/johnsiilver/halfpike"
+	"github.com/johnsiilver/halfpike/parsers/model"
+)
+
+// IPInterfaceBrief is the output of "show ip interface brief". It implements halfpike.ParseObject.
+type IPInterfaceBrief struct {
+	Interfaces []*IPInterface
+
+	parser *halfpike.Parser
+}
+
+// IPInterface is an entry in "show ip interface brief".
+type IPInterface struct {
+	// Name is the name of the interface, like GigabitEthernet0/0.
+	Name string
+	// IP is the IP address of the interface. This is nil if it is "unassigned".
+	IP net.IP
+	// OK indicates if the IP address is valid.
+	OK bool
+	// Method is how the IP address was assigned, like "NVRAM", "manual" or "DHCP".
+	Method string
+	// State is the interface's administrative state.
+	State model.InterState
+	// Status is the interface's operational (line protocol) status.
+	Status model.InterStatus
+}
+
+// Validate implements halfpike.Validator.Validate().
+func (b *IPInterfaceBrief) Validate() error {
+	for _, i := range b.Interfaces {
+		switch {
+		case i.State == model.IStateUnknown:
+			return fmt.Errorf("IPInterface(%s): State was not set", i.Name)
+		case i.Status == model.IStatUnknown:
+			return fmt.Errorf("IPInterface(%s): Status was not set", i.Name)
+		}
+	}
+	return nil
+}
+
+// Start implements halfpike.ParseObject.Start().
+func (b *IPInterfaceBrief) Start(ctx context.Context, p *halfpike.Parser) halfpike.ParseFn {
+	b.parser = p
+	return b.findHeader
+}
+
+var briefHeader = []string{"Interface", "IP-Address", "OK?", "Method", "Status", "Protocol"}
+
+func (b *IPInterfaceBrief) findHeader(ctx context.Context, p *halfpike.Parser) halfpike.ParseFn {
+	if _, err := p.FindStart(briefHeader); err != nil {
+		return p.Errorf("could not find the header of show ip interface brief")
+	}
+	return b.entries
+}
+
+// GigabitEthernet0/1     unassigned      YES unset  administratively down down
+func (b *IPInterfaceBrief) entries(ctx context.Context, p *halfpike.Parser) halfpike.ParseFn {
+	for {
+		line := p.Next()
+		if p.EOF(line) {
+			return nil
+		}
+
+		f := strings.Fields(halfpike.ItemJoin(line, -1, -1))
+		// The table ends at the first line that isn't an interface, such as a blank line or the
+		// prompt that follows the output in a capture.
+		if len(f) < 6 {
+			return nil
+		}
+
+		inter := &IPInterface{Name: f[0], Method: f[3]}
+		if f[1] != "unassigned" {
+			if inter.IP = net.ParseIP(f[1]); inter.IP == nil {
+				return b.parser.Errorf("[Line %d] interface(%s): IP-Address %q is not valid", line.LineNum, inter.Name, f[1])
+			}
+		}
+		switch f[2] {
+		case "YES":
+			inter.OK = true
+		case "NO":
+		default:
+			return b.parser.Errorf("[Line %d] interface(%s): OK? column had unknown value %q", line.LineNum, inter.Name, f[2])
+		}
+
+		var err error
+		if inter.State, err = briefState(strings.Join(f[4:len(f)-1], " ")); err != nil {
+			return b.parser.Errorf("[Line %d] interface(%s): %s", line.LineNum, inter.Name, err)
+		}
+		if inter.Status, err = protocolStatus(f[len(f)-1]); err != nil {
+			return b.parser.Errorf("[Line %d] interface(%s): %s", line.LineNum, inter.Name, err)
+		}
+		b.Interfaces = append(b.Interfaces, inter)
+	}
+}
+
+// briefState converts the Status column to an InterState. The Status column also includes
+// whether the link is up, which is covered by the Protocol column.
+func briefState(s string) (model.InterState, error) {
+	switch s {
+	case "up", "down":
+		return model.IStateEnabled, nil
+	case "administratively down", "deleted":
+		return model.IStateDisabled, nil
+	}
+	return model.IStateUnknown, fmt.Errorf("Status %q is not a known status", s)
+}
+
+// protocolStatus converts the state of the line protocol to an InterStatus.
+func protocolStatus(s string) (model.InterStatus, error) {
+	switch s {
+	case "up":
+		return model.IStatUp, nil
+	case "down":
+		return model.IStatDown, nil
+	}
+	return model.IStatUnknown, fmt.Errorf("line protocol status %q is not a known status", s)
+}
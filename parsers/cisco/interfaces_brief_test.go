@@ -0,0 +1,70 @@
+package cisco
+
+import (
+	"context"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/johnsiilver/halfpike"
+	"github.com/johnsiilver/halfpike/parsers/model"
+)
+
+func TestIPInterfaceBrief(t *testing.T) {
+	want := []*IPInterface{
+		{Name: "GigabitEthernet0/0", IP: net.ParseIP("10.1.1.1"), OK: true, Method: "NVRAM", State: model.IStateEnabled, Status: model.IStatUp},
+		{Name: "GigabitEthernet0/1", OK: true, Method: "unset", State: model.IStateDisabled, Status: model.IStatDown},
+		{Name: "GigabitEthernet0/2", IP: net.ParseIP("10.1.2.1"), OK: true, Method: "manual", State: model.IStateEnabled, Status: model.IStatDown},
+		{Name: "Loopback0", IP: net.ParseIP("192.0.2.10"), OK: true, Method: "manual", State: model.IStateEnabled, Status: model.IStatUp},
+		{Name: "Vlan100", IP: net.ParseIP("10.100.0.1"), OK: false, Method: "DHCP", State: model.IStateEnabled, Status: model.IStatDown},
+	}
+
+	// show_ip_interface_brief_prompt.txt ends with the device prompt, which ends the table.
+	for _, file := range []string{"show_ip_interface_brief.txt", "show_ip_interface_brief_prompt.txt"} {
+		b, err := os.ReadFile("testdata/" + file)
+		if err != nil {
+			t.Fatalf("TestIPInterfaceBrief(%s): got err == %s", file, err)
+		}
+
+		got := &IPInterfaceBrief{}
+		if err := halfpike.Parse(context.Background(), string(b), got); err != nil {
+			t.Errorf("TestIPInterfaceBrief(%s): got err == %s", file, err)
+			continue
+		}
+		if diff := cmpConfig.Compare(want, got.Interfaces); diff != "" {
+			t.Errorf("TestIPInterfaceBrief(%s): -want/+got:\n%s", file, diff)
+		}
+	}
+}
+
+func TestIPInterfaceBriefErrors(t *testing.T) {
+	const header = "Interface              IP-Address      OK? Method Status                Protocol\n"
+
+	tests := []struct {
+		desc    string
+		content string
+	}{
+		{
+			desc:    "No header",
+			content: "GigabitEthernet0/0     10.1.1.1        YES NVRAM  up                    up\n",
+		},
+		{
+			desc:    "Bad IP",
+			content: header + "GigabitEthernet0/0     10.1.1        YES NVRAM  up                    up\n",
+		},
+		{
+			desc:    "Bad status",
+			content: header + "GigabitEthernet0/0     10.1.1.1        YES NVRAM  sideways              up\n",
+		},
+		{
+			desc:    "Bad OK? value",
+			content: header + "GigabitEthernet0/0     10.1.1.1        MAYBE NVRAM  up                  up\n",
+		},
+	}
+
+	for _, test := range tests {
+		if err := halfpike.Parse(context.Background(), test.content, &IPInterfaceBrief{}); err == nil {
+			t.Errorf("TestIPInterfaceBriefErrors(%s): got err == nil, want err != nil", test.desc)
+		}
+	}
+}
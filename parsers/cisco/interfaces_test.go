@@ -0,0 +1,140 @@
+package cisco
+
+import (
+	"context"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/johnsiilver/halfpike"
+	"github.com/johnsiilver/halfpike/parsers/model"
+)
+
+func TestInterfaces(t *testing.T) {
+	b, err := os.ReadFile("testdata/show_interfaces.txt")
+	if err != nil {
+		panic(err)
+	}
+
+	want := []*Interface{
+		{
+			Name:          "GigabitEthernet0/0",
+			Description:   "uplink to core-1",
+			State:         model.IStateEnabled,
+			Status:        model.IStatUp,
+			Hardware:      "iGbE",
+			MAC:           mustMAC("5254.0012.3456"),
+			BIA:           mustMAC("5254.0012.3456"),
+			Address:       &net.IPNet{IP: net.ParseIP("10.1.1.1"), Mask: net.CIDRMask(30, 32)},
+			MTU:           1500,
+			Bandwidth:     1000000000,
+			Encapsulation: "ARPA",
+			InputRate:     model.Rate{BPS: 2000, PPS: 3},
+			OutputRate:    model.Rate{BPS: 1000, PPS: 1},
+			Traffic: &model.TrafficStats{
+				InputBytes:    98765432,
+				OutputBytes:   12345678,
+				InputPackets:  123456,
+				OutputPackets: 654321,
+			},
+			Counters: map[string]int64{
+				"no buffer":                  0,
+				"runts":                      0,
+				"giants":                     0,
+				"throttles":                  0,
+				"input errors":               2,
+				"CRC":                        1,
+				"frame":                      0,
+				"overrun":                    0,
+				"ignored":                    0,
+				"watchdog":                   0,
+				"multicast":                  0,
+				"pause input":                0,
+				"underruns":                  0,
+				"output errors":              0,
+				"collisions":                 0,
+				"interface resets":           1,
+				"unknown protocol drops":     0,
+				"babbles":                    0,
+				"late collision":             0,
+				"deferred":                   0,
+				"lost carrier":               0,
+				"no carrier":                 0,
+				"pause output":               0,
+				"output buffer failures":     0,
+				"output buffers swapped out": 0,
+			},
+		},
+		{
+			Name:          "GigabitEthernet0/1",
+			State:         model.IStateDisabled,
+			Status:        model.IStatDown,
+			Hardware:      "iGbE",
+			MAC:           mustMAC("5254.0012.3457"),
+			BIA:           mustMAC("5254.0012.3457"),
+			MTU:           1500,
+			Bandwidth:     1000000000,
+			Encapsulation: "ARPA",
+			Traffic:       &model.TrafficStats{},
+			Counters:      map[string]int64{"no buffer": 0, "underruns": 0},
+		},
+		{
+			Name:          "Loopback0",
+			State:         model.IStateEnabled,
+			Status:        model.IStatUp,
+			Hardware:      "Loopback",
+			Address:       &net.IPNet{IP: net.ParseIP("192.0.2.10"), Mask: net.CIDRMask(32, 32)},
+			MTU:           1514,
+			Bandwidth:     8000000000,
+			Encapsulation: "LOOPBACK",
+			Traffic:       &model.TrafficStats{OutputPackets: 17, OutputBytes: 1088},
+			Counters:      map[string]int64{"no buffer": 0, "underruns": 0},
+		},
+	}
+
+	got := &Interfaces{}
+	if err := halfpike.Parse(context.Background(), string(b), got); err != nil {
+		t.Fatalf("TestInterfaces: got err == %s", err)
+	}
+	if diff := cmpConfig.Compare(want, got.Interfaces); diff != "" {
+		t.Errorf("TestInterfaces: -want/+got:\n%s", diff)
+	}
+}
+
+func TestInterfacesErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		content string
+	}{
+		{
+			desc:    "No interfaces",
+			content: "nothing to see here\n",
+		},
+		{
+			desc:    "Bad state",
+			content: "GigabitEthernet0/0 is sleeping, line protocol is up\n  MTU 1500 bytes, BW 1000000 Kbit/sec, DLY 10 usec,\n",
+		},
+		{
+			desc:    "Missing MTU",
+			content: "GigabitEthernet0/0 is up, line protocol is up\n  Hardware is iGbE, address is 5254.0012.3456 (bia 5254.0012.3456)\n",
+		},
+		{
+			desc:    "Bad counter",
+			content: "GigabitEthernet0/0 is up, line protocol is up\n  MTU 1500 bytes, BW 1000000 Kbit/sec, DLY 10 usec,\n     12 packets input, many bytes\n",
+		},
+	}
+
+	for _, test := range tests {
+		if err := halfpike.Parse(context.Background(), test.content, &Interfaces{}); err == nil {
+			t.Errorf("TestInterfacesErrors(%s): got err == nil, want err != nil", test.desc)
+		}
+	}
+}
+
+func mustMAC(s string) net.HardwareAddr {
+	mac, err := net.ParseMAC(s)
+	if err != nil {
+		panic(err)
+	}
+	return mac
+}
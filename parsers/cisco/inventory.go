@@ -0,0 +1,89 @@
+package cisco
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/johnsiilver/halfpike"
+)
+
+// Inventory is the output of "show inventory". It implements halfpike.ParseObject.
+type Inventory struct {
+	Items []*InventoryItem
+
+	parser *halfpike.Parser
+}
+
+// InventoryItem is a hardware component in the device.
+type InventoryItem struct {
+	// Name is the name of the component, like "Chassis" or "Power Supply Module 0".
+	Name string
+	// Description describes the component.
+	Description string
+	// PID is the product ID.
+	PID string
+	// VID is the version ID. This may be empty.
+	VID string
+	// SerialNumber is the serial number. This may be empty.
+	SerialNumber string
+}
+
+// Validate implements halfpike.Validator.Validate().
+func (i *Inventory) Validate() error {
+	for _, item := range i.Items {
+		if item.PID == "" {
+			return fmt.Errorf("InventoryItem(%s): PID was not set", item.Name)
+		}
+	}
+	return nil
+}
+
+// Start implements halfpike.ParseObject.Start().
+func (i *Inventory) Start(ctx context.Context, p *halfpike.Parser) halfpike.ParseFn {
+	i.parser = p
+	return i.findItem
+}
+
+var (
+	// NAME: "Chassis", DESCR: "Cisco ISR4451 Chassis"
+	invNameRE = regexp.MustCompile(`^NAME: "(?P<name>[^"]*)", DESCR: "(?P<descr>[^"]*)"`)
+	// PID: ISR4451-X/K9      , VID: V04, SN: FGL1234567A
+	invPIDRE = regexp.MustCompile(`^PID:(?P<pid>[^,]*),\s*VID:(?P<vid>[^,]*),\s*SN:(?P<sn>.*)$`)
+)
+
+func (i *Inventory) findItem(ctx context.Context, p *halfpike.Parser) halfpike.ParseFn {
+	line, err := p.FindStart([]string{"NAME:"})
+	if err != nil {
+		if len(i.Items) == 0 {
+			return p.Errorf("could not find an inventory item in the output")
+		}
+		return nil
+	}
+
+	m, err := halfpike.Match(invNameRE, halfpike.ItemJoin(line, -1, -1))
+	if err != nil {
+		return p.Errorf("[Line %d]: NAME line had unknown format", line.LineNum)
+	}
+	i.Items = append(i.Items, &InventoryItem{Name: m["name"], Description: m["descr"]})
+	return i.pid
+}
+
+// pid decodes the PID line that follows the NAME line.
+func (i *Inventory) pid(ctx context.Context, p *halfpike.Parser) halfpike.ParseFn {
+	item := i.Items[len(i.Items)-1]
+
+	line := p.Next()
+	if !p.IsAtStart(line, []string{"PID:"}) {
+		return p.Errorf("[Line %d] item(%s): expected PID line after NAME line", line.LineNum, item.Name)
+	}
+	m, err := halfpike.Match(invPIDRE, halfpike.ItemJoin(line, -1, -1))
+	if err != nil {
+		return p.Errorf("[Line %d] item(%s): PID line had unknown format", line.LineNum, item.Name)
+	}
+	item.PID = strings.TrimSpace(m["pid"])
+	item.VID = strings.TrimSpace(m["vid"])
+	item.SerialNumber = strings.TrimSpace(m["sn"])
+	return i.findItem
+}
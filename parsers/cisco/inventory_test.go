@@ -0,0 +1,57 @@
+package cisco
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/johnsiilver/halfpike"
+)
+
+func TestInventory(t *testing.T) {
+	b, err := os.ReadFile("testdata/show_inventory.txt")
+	if err != nil {
+		panic(err)
+	}
+
+	want := []*InventoryItem{
+		{Name: "Chassis", Description: "Cisco ISR4451 Chassis", PID: "ISR4451-X/K9", VID: "V04", SerialNumber: "FGL1234567A"},
+		{Name: "Power Supply Module 0", Description: "450W AC Power Supply for Cisco ISR 4450, ISR 4350", PID: "XXX-PWR-450W-AC", VID: "V02", SerialNumber: "ART2222F0AB"},
+		{Name: "Fan Tray", Description: "Cisco ISR4450, ISR4350 Fan Assembly", PID: "ACS-4450-FANASSY"},
+		{Name: "module 0", Description: "Cisco ISR4451 Built-In NIM controller", PID: "ISR4451-X-4x1GE", VID: "V01"},
+	}
+
+	got := &Inventory{}
+	if err := halfpike.Parse(context.Background(), string(b), got); err != nil {
+		t.Fatalf("TestInventory: got err == %s", err)
+	}
+	if diff := cmpConfig.Compare(want, got.Items); diff != "" {
+		t.Errorf("TestInventory: -want/+got:\n%s", diff)
+	}
+}
+
+func TestInventoryErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		content string
+	}{
+		{
+			desc:    "No items",
+			content: "nothing to see here\n",
+		},
+		{
+			desc:    "Missing PID line",
+			content: "NAME: \"Chassis\", DESCR: \"Cisco ISR4451 Chassis\"\n\nNAME: \"Fan Tray\", DESCR: \"Fans\"\n",
+		},
+		{
+			desc:    "Empty PID",
+			content: "NAME: \"Chassis\", DESCR: \"Cisco ISR4451 Chassis\"\nPID: , VID: V04, SN: FGL1234567A\n",
+		},
+	}
+
+	for _, test := range tests {
+		if err := halfpike.Parse(context.Background(), test.content, &Inventory{}); err == nil {
+			t.Errorf("TestInventoryErrors(%s): got err == nil, want err != nil", test.desc)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package cisco
+
+import "github.com/johnsiilver/halfpike"
+
+// Vendor is the vendor name the parsers in this package are registered under.
+const Vendor = "cisco"
+
+// Register registers the parsers in this package with "r" for all IOS and IOS-XE versions.
+func Register(r *halfpike.Registry) error {
+	regs := []struct {
+		command string
+		newFn   func() halfpike.ParseObject
+	}{
+		{"show version", func() halfpike.ParseObject { return &Version{} }},
+		{"show ip interface brief", func() halfpike.ParseObject { return &IPInterfaceBrief{} }},
+		{"show ip bgp summary", func() halfpike.ParseObject { return &BGPSummary{} }},
+		{"show interfaces", func() halfpike.ParseObject { return &Interfaces{} }},
+		{"show inventory", func() halfpike.ParseObject { return &Inventory{} }},
+	}
+
+	for _, reg := range regs {
+		if err := r.Register(Vendor, reg.command, "", reg.newFn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package cisco
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/johnsiilver/halfpike"
+)
+
+func TestRegister(t *testing.T) {
+	r := halfpike.NewRegistry()
+	if err := Register(r); err != nil {
+		t.Fatalf("TestRegister: got err == %s", err)
+	}
+
+	b, err := os.ReadFile("testdata/show_ip_interface_brief.txt")
+	if err != nil {
+		panic(err)
+	}
+
+	obj, reg, err := r.Parse(context.Background(), "Cisco", "15.2(7)E4", "sh ip int br", string(b))
+	if err != nil {
+		t.Fatalf("TestRegister: got err == %s", err)
+	}
+	if reg.Command != "show ip interface brief" {
+		t.Errorf("TestRegister: got Registration.Command %q, want %q", reg.Command, "show ip interface brief")
+	}
+	if got := len(obj.(*IPInterfaceBrief).Interfaces); got != 5 {
+		t.Errorf("TestRegister: got %d interfaces, want 5", got)
+	}
+}
@@ -0,0 +1,138 @@
+package cisco
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johnsiilver/halfpike"
+	"github.com/johnsiilver/halfpike/units"
+)
+
+// Version is the output of "show version". It implements halfpike.ParseObject.
+type Version struct {
+	// Software is the operating system, either "IOS" or "IOS-XE".
+	Software string
+	// Version is the software version, like "15.2(7)E4" or "17.03.04a".
+	Version string
+	// Hostname is the device's hostname.
+	Hostname string
+	// Uptime is how long the device has been up.
+	Uptime time.Duration
+	// ReloadReason is why the device last reloaded, such as "power-on" or "Reload Command".
+	ReloadReason string
+	// Image is the system image file the device booted, like "flash:packages.conf".
+	Image string
+	// Model is the hardware model, like "C9300-48P".
+	Model string
+	// Memory is the amount of main memory in kilobytes.
+	Memory int64
+	// SerialNumber is the processor board ID.
+	SerialNumber string
+	// ConfigRegister is the configuration register, like "0x2102".
+	ConfigRegister string
+
+	parser *halfpike.Parser
+}
+
+// Validate implements halfpike.Validator.Validate().
+func (v *Version) Validate() error {
+	switch {
+	case v.Software == "":
+		return fmt.Errorf("Version: Software was not set")
+	case v.Version == "":
+		return fmt.Errorf("Version: Version was not set")
+	case v.Hostname == "":
+		return fmt.Errorf("Version: Hostname was not set")
+	case v.Model == "":
+		return fmt.Errorf("Version: Model was not set")
+	}
+	return nil
+}
+
+// Start implements halfpike.ParseObject.Start().
+func (v *Version) Start(ctx context.Context, p *halfpike.Parser) halfpike.ParseFn {
+	v.parser = p
+	return v.findSoftware
+}
+
+var (
+	// Cisco IOS XE Software, Version 17.03.04a
+	// Cisco IOS Software, C2960X Software (C2960X-UNIVERSALK9-M), Version 15.2(7)E4, RELEASE SOFTWARE (fc2)
+	softwareRE = regexp.MustCompile(`^Cisco (?P<os>IOS XE|IOS) Software.*, Version (?P<version>[^,\s]+)`)
+	// sw-core-1 uptime is 1 year, 2 weeks, 3 days, 4 hours, 5 minutes
+	uptimeRE = regexp.MustCompile(`^(?P<host>\S+) uptime is (?P<uptime>.+)$`)
+	// System image file is "flash:packages.conf"
+	imageRE = regexp.MustCompile(`^System image file is "(?P<image>[^"]+)"`)
+	// cisco C9300-48P (X86) processor with 1392724K/6147K bytes of memory.
+	modelRE = regexp.MustCompile(`^cisco (?P<model>\S+) .*with (?P<mem>\d+)K(/\d+K)? bytes of memory`)
+)
+
+// findSoftware finds the first line that details the software version.
+func (v *Version) findSoftware(ctx context.Context, p *halfpike.Parser) halfpike.ParseFn {
+	for {
+		line := p.Next()
+		if m, err := halfpike.Match(softwareRE, halfpike.ItemJoin(line, -1, -1)); err == nil {
+			v.Software = "IOS"
+			if m["os"] == "IOS XE" {
+				v.Software = "IOS-XE"
+			}
+			v.Version = m["version"]
+			return v.attrs
+		}
+		if p.EOF(line) {
+			return p.Errorf("could not find the software version line in the output")
+		}
+	}
+}
+
+// attrs decodes the lines after the software version.
+func (v *Version) attrs(ctx context.Context, p *halfpike.Parser) halfpike.ParseFn {
+	for {
+		line := p.Next()
+		if p.EOF(line) {
+			return nil
+		}
+
+		text := halfpike.ItemJoin(line, -1, -1)
+		switch {
+		case softwareRE.MatchString(text):
+			// IOS-XE lists the version a second time in the IOS format, which we ignore.
+		case uptimeRE.MatchString(text):
+			m, _ := halfpike.Match(uptimeRE, text)
+			d, err := units.ParseDuration(m["uptime"])
+			if err != nil {
+				return v.errorf(line, "%s", err)
+			}
+			v.Hostname, v.Uptime = m["host"], d
+		case p.IsAtStart(line, []string{"System", "returned", "to", "ROM", "by"}):
+			// System returned to ROM by Reload Command at 09:14:07 UTC Mon Sep 6 2025
+			reason := halfpike.ItemJoin(line, 5, -1)
+			if i := strings.Index(reason, " at "); i != -1 {
+				reason = reason[:i]
+			}
+			v.ReloadReason = reason
+		case imageRE.MatchString(text):
+			m, _ := halfpike.Match(imageRE, text)
+			v.Image = m["image"]
+		case modelRE.MatchString(text):
+			m, _ := halfpike.Match(modelRE, text)
+			mem, err := strconv.ParseInt(m["mem"], 10, 64)
+			if err != nil {
+				return v.errorf(line, "memory was not an integer: %q", m["mem"])
+			}
+			v.Model, v.Memory = m["model"], mem
+		case p.IsAtStart(line, []string{"Processor", "board", "ID", halfpike.Skip}):
+			v.SerialNumber = line.Items[3].Val
+		case p.IsAtStart(line, []string{"Configuration", "register", "is", halfpike.Skip}):
+			v.ConfigRegister = line.Items[3].Val
+		}
+	}
+}
+
+func (v *Version) errorf(line halfpike.Line, s string, a ...interface{}) halfpike.ParseFn {
+	return v.parser.Errorf("[Line %d]: %s", line.LineNum, fmt.Sprintf(s, a...))
+}
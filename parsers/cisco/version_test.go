@@ -0,0 +1,96 @@
+package cisco
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/johnsiilver/halfpike"
+)
+
+func TestVersion(t *testing.T) {
+	tests := []struct {
+		desc string
+		file string
+		want *Version
+	}{
+		{
+			desc: "IOS-XE",
+			file: "show_version_iosxe.txt",
+			want: &Version{
+				Software:       "IOS-XE",
+				Version:        "17.03.04a",
+				Hostname:       "sw-core-1",
+				Uptime:         year + 2*week + 3*day + 4*time.Hour + 5*time.Minute,
+				ReloadReason:   "Reload Command",
+				Image:          "flash:packages.conf",
+				Model:          "C9300-48P",
+				Memory:         1392724,
+				SerialNumber:   "FOC2233X0AB",
+				ConfigRegister: "0x102",
+			},
+		},
+		{
+			desc: "IOS",
+			file: "show_version_ios.txt",
+			want: &Version{
+				Software:       "IOS",
+				Version:        "15.2(7)E4",
+				Hostname:       "sw-access-7",
+				Uptime:         5*week + day + 22*time.Hour + 14*time.Minute,
+				ReloadReason:   "power-on",
+				Image:          "flash:c2960x-universalk9-mz.152-7.E4.bin",
+				Model:          "WS-C2960X-48FPD-L",
+				Memory:         524288,
+				SerialNumber:   "FOC1234W0XY",
+				ConfigRegister: "0xF",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		b, err := os.ReadFile("testdata/" + test.file)
+		if err != nil {
+			panic(err)
+		}
+
+		got := &Version{}
+		if err := halfpike.Parse(context.Background(), string(b), got); err != nil {
+			t.Errorf("TestVersion(%s): got err == %s", test.desc, err)
+			continue
+		}
+
+		if diff := cmpConfig.Compare(test.want, got); diff != "" {
+			t.Errorf("TestVersion(%s): -want/+got:\n%s", test.desc, diff)
+		}
+	}
+}
+
+func TestVersionErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		content string
+	}{
+		{
+			desc:    "No version line",
+			content: "nothing to see here\n",
+		},
+		{
+			desc:    "Missing hostname and model",
+			content: "Cisco IOS Software, C2960X Software (C2960X-UNIVERSALK9-M), Version 15.2(7)E4, RELEASE SOFTWARE (fc2)\n",
+		},
+		{
+			desc: "Bad uptime",
+			content: `Cisco IOS Software, C2960X Software (C2960X-UNIVERSALK9-M), Version 15.2(7)E4, RELEASE SOFTWARE (fc2)
+sw-access-7 uptime is a while
+`,
+		},
+	}
+
+	for _, test := range tests {
+		if err := halfpike.Parse(context.Background(), test.content, &Version{}); err == nil {
+			t.Errorf("TestVersionErrors(%s): got err == nil, want err != nil", test.desc)
+		}
+	}
+}
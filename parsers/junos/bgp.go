@@ -0,0 +1,1012 @@
+// Package junos provides halfpike parsers for the output of Juniper Junos commands.
+package junos
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johnsiilver/halfpike"
+	"github.com/johnsiilver/halfpike/parsers/model"
+)
+
+// PeerType is the type of peer the neighbor is.
+type PeerType uint8
+
+// BGP neighbor types.
+const (
+	// PTUnknown indicates the neighbor type is unknown.
+	PTUnknown PeerType = 0
+	// PTExternal indicates the neighbor is external to the router's AS.
+	PTExternal PeerType = 1
+	// PTInternal indicates the neighbor is internal to the router's AS.
+	PTInternal PeerType = 2
+)
+
+var peerTypeNames = map[PeerType]string{
+	PTUnknown:  "Unknown",
+	PTExternal: "External",
+	PTInternal: "Internal",
+}
+
+// String implements fmt.Stringer.
+func (p PeerType) String() string {
+	if s, ok := peerTypeNames[p]; ok {
+		return s
+	}
+	return fmt.Sprintf("PeerType(%d)", p)
+}
+
+// RIBState is the graceful restart state of a routing table.
+type RIBState uint8
+
+const (
+	RSUnknown    RIBState = 0
+	RSComplete   RIBState = 2
+	RSInProgress RIBState = 3
+)
+
+var ribStateNames = map[RIBState]string{
+	RSUnknown:    "Unknown",
+	RSComplete:   "Complete",
+	RSInProgress: "InProgress",
+}
+
+// String implements fmt.Stringer.
+func (r RIBState) String() string {
+	if s, ok := ribStateNames[r]; ok {
+		return s
+	}
+	return fmt.Sprintf("RIBState(%d)", r)
+}
+
+// SendState is the state of sending routes from a routing table to the neighbor.
+type SendState uint8
+
+const (
+	RSSendUnknown     SendState = 0
+	RSSendSync        SendState = 1
+	RSSendNotSync     SendState = 2
+	RSSendNoAdvertise SendState = 3
+)
+
+var sendStateNames = map[SendState]string{
+	RSSendUnknown:     "Unknown",
+	RSSendSync:        "InSync",
+	RSSendNotSync:     "NotInSync",
+	RSSendNoAdvertise: "NotAdvertising",
+}
+
+// String implements fmt.Stringer.
+func (s SendState) String() string {
+	if n, ok := sendStateNames[s]; ok {
+		return n
+	}
+	return fmt.Sprintf("SendState(%d)", s)
+}
+
+// BGPNeighbors is the output of "show bgp neighbor". It implements halfpike.ParseObject.
+type BGPNeighbors struct {
+	Peers []*BGPNeighbor
+
+	parser *halfpike.Parser
+}
+
+// Validate implements halfpike.Validator.Validate().
+func (b *BGPNeighbors) Validate() error {
+	for _, p := range b.Peers {
+		if err := p.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Start implements halfpike.ParseObject.Start(), which begins our parsing of content.
+func (b *BGPNeighbors) Start(ctx context.Context, p *halfpike.Parser) halfpike.ParseFn {
+	b.parser = p
+	return b.findPeer
+}
+
+// peerRecStart is used to locate the beginning of a BGP Peer record.
+var peerRecStart = []string{"Peer:", halfpike.Skip, "AS", halfpike.Skip, "Local:", halfpike.Skip, "AS", halfpike.Skip}
+
+// Peer: 10.10.10.2+179 AS 22     Local: 10.10.10.1+65406 AS 17
+// Peer: 10.1.1.1 AS 65001        Local: 10.1.1.2 AS 65000
+func (b *BGPNeighbors) findPeer(ctx context.Context, p *halfpike.Parser) halfpike.ParseFn {
+	const (
+		peerIPPort  = 1
+		peerASNum   = 3
+		localIPPort = 5
+		localASNum  = 7
+	)
+
+	line, err := p.FindStart(peerRecStart)
+	if err != nil {
+		if len(b.Peers) == 0 {
+			return p.Errorf("did not locate the start of our list of peers within the output")
+		}
+		return nil
+	}
+
+	rec := &BGPNeighbor{}
+	rec.init()
+	b.Peers = append(b.Peers, rec)
+
+	rec.PeerIP, rec.PeerPort, err = ipPort(line.Items[peerIPPort].Val)
+	if err != nil {
+		return b.errorf(line, "could not retrieve a valid peer IP and port: %s", err)
+	}
+	if rec.PeerAS, err = line.Items[peerASNum].ToInt(); err != nil {
+		return b.errorf(line, "could not retrieve the peer AS num: %s", err)
+	}
+	rec.LocalIP, rec.LocalPort, err = ipPort(line.Items[localIPPort].Val)
+	if err != nil {
+		return b.errorf(line, "could not retrieve a valid local IP and port: %s", err)
+	}
+	if rec.LocalAS, err = line.Items[localASNum].ToInt(); err != nil {
+		return b.errorf(line, "could not retrieve the local AS num: %s", err)
+	}
+
+	return b.peerAttrs
+}
+
+// peerAttrs decodes all lines belonging to the current peer until we find the next peer or EOF.
+func (b *BGPNeighbors) peerAttrs(ctx context.Context, p *halfpike.Parser) halfpike.ParseFn {
+	rec := b.lastPeer()
+
+	for {
+		line := p.Next()
+		if p.IsAtStart(line, peerRecStart) {
+			p.Backup()
+			return b.findPeer
+		}
+
+		for _, h := range peerHandlers {
+			if !p.IsAtStart(line, h.find) {
+				continue
+			}
+			if err := h.decode(rec, line); err != nil {
+				return b.errorf(line, "%s", err)
+			}
+			break
+		}
+
+		if p.EOF(line) {
+			return nil
+		}
+	}
+}
+
+// lastPeer returns the last *BGPNeighbor added to our *BGPNeighbors slice.
+func (b *BGPNeighbors) lastPeer() *BGPNeighbor {
+	if len(b.Peers) == 0 {
+		return nil
+	}
+	return b.Peers[len(b.Peers)-1]
+}
+
+func (b *BGPNeighbors) errorf(line halfpike.Line, s string, a ...interface{}) halfpike.ParseFn {
+	rec := b.lastPeer()
+	if rec == nil || rec.PeerIP == nil {
+		return b.parser.Errorf("[Line %d]: %s", line.LineNum, fmt.Sprintf(s, a...))
+	}
+	return b.parser.Errorf("[Line %d] Peer(%s):Local(%s) entry: %s", line.LineNum, rec.PeerIP, rec.LocalIP, fmt.Sprintf(s, a...))
+}
+
+// BGPNeighbor provides information about a router's BGP Neighbor.
+type BGPNeighbor struct {
+	// PeerIP is the IP address of the neighbor.
+	PeerIP net.IP
+	// PeerPort is the IP port of the peer. This is 0 if the session is not up.
+	PeerPort uint32
+	// PeerAS is the peers autonomous system number.
+	PeerAS int
+	// LocalIP is the IP address on this router the neighbor connects to.
+	LocalIP net.IP
+	// LocalPort is the IP port on this router the neighbor connects to. This is 0 if the session is not up.
+	LocalPort uint32
+	// LocalAS is the local autonomous system number.
+	LocalAS int
+	// Description is the configured description of the neighbor.
+	Description string
+	// Group is the BGP group the neighbor belongs to.
+	Group string
+	// RoutingInstance is the routing instance the neighbor belongs to.
+	RoutingInstance string
+	// Type is the type of peer.
+	Type PeerType
+	// State is the current state of the BGP peer.
+	State model.BGPState
+	// Flags are the flags set on the peer, such as "Sync".
+	Flags []string
+	// LastState is the previous state of the BGP peer.
+	LastState model.BGPState
+	// LastEvent is the last event that caused a state change, such as "RecvKeepAlive".
+	LastEvent string
+	// LastError is the last error for the peer. This is "None" if there has not been one.
+	LastError string
+	// Options are the configuration options on the peer, such as "Preference" or "PeerAS".
+	Options []string
+	// LocalAddress is the configured local address used for the session.
+	LocalAddress net.IP
+	// HoldTime is how long to consider the neighbor valid after not hearing a keep alive.
+	HoldTime time.Duration
+	// Preference is the BGP preference value.
+	Preference int
+	// Flaps is the number of times the session has gone down.
+	Flaps int
+	// LastFlapEvent is the event that caused the last flap.
+	LastFlapEvent string
+	// Errors are the BGP errors that have been sent to or received from the peer.
+	Errors []BGPError
+	// PeerID is the ID the peer uses to identify itself.
+	PeerID net.IP
+	// LocalID is the ID the local router uses to identify itself.
+	LocalID net.IP
+	// ActiveHoldTime is the hold time that was negotiated with the peer.
+	ActiveHoldTime time.Duration
+	// KeepaliveInterval is the interval keep alives are sent at.
+	KeepaliveInterval time.Duration
+	// PeerIndex is the index of the peer within its group.
+	PeerIndex int
+	// BFD is the BFD status, such as "disabled, down".
+	BFD string
+	// LocalInterface is the interface the session is running over.
+	LocalInterface string
+	// NLRI are the NLRI (address families) details for the session.
+	NLRI NLRI
+	// RefreshCapability indicates the peer supports route refresh.
+	RefreshCapability bool
+	// FourByteAS indicates the peer supports 4 byte AS numbers.
+	FourByteAS bool
+	// Addpath indicates the peer supports Addpath.
+	Addpath bool
+	// RestartTimeConfigured is the graceful restart time configured on the peer.
+	RestartTimeConfigured time.Duration
+	// RestartTimeRequested is the graceful restart time requested by the peer.
+	RestartTimeRequested time.Duration
+	// StaleRoutesTime is how long stale routes from the peer are kept during a restart.
+	StaleRoutesTime time.Duration
+	// Tables are the statistics for each routing table, keyed by the table name, like "inet.0".
+	Tables map[string]*TableStats
+	// LastTraffic is when traffic was last seen.
+	LastTraffic LastTraffic
+	// InputMessages are statistics for messages received from the peer.
+	InputMessages MessageStats
+	// OutputMessages are statistics for messages sent to the peer.
+	OutputMessages MessageStats
+	// OutputQueues are the number of messages in each output queue, keyed by queue number.
+	OutputQueues map[int]int
+
+	initCalled bool
+}
+
+func (b *BGPNeighbor) init() {
+	b.PeerAS, b.LocalAS = -1, -1
+	b.Preference = -1
+	b.Flaps = -1
+	b.PeerIndex = -1
+	b.HoldTime = -1
+	b.initCalled = true
+}
+
+// Validate implements halfpike.Validator.Validate().
+func (b *BGPNeighbor) Validate() error {
+	if !b.initCalled {
+		return fmt.Errorf("internal error: BGPNeighbor.init() was not called")
+	}
+
+	switch {
+	case b.PeerIP == nil:
+		return fmt.Errorf("PeerIP was nil")
+	case b.LocalIP == nil:
+		return fmt.Errorf("Peer(%s): LocalIP was nil", b.PeerIP)
+	}
+
+	switch -1 {
+	case b.PeerAS:
+		return fmt.Errorf("Peer(%s): PeerAS was not set", b.PeerIP)
+	case b.LocalAS:
+		return fmt.Errorf("Peer(%s): LocalAS was not set", b.PeerIP)
+	case b.Preference:
+		return fmt.Errorf("Peer(%s): Preference was not set", b.PeerIP)
+	case b.Flaps:
+		return fmt.Errorf("Peer(%s): Flaps was not set", b.PeerIP)
+	case int(b.HoldTime):
+		return fmt.Errorf("Peer(%s): HoldTime was not set", b.PeerIP)
+	}
+
+	switch {
+	case b.Type == PTUnknown:
+		return fmt.Errorf("Peer(%s): Type was not set", b.PeerIP)
+	case b.State == model.NSUnknown:
+		return fmt.Errorf("Peer(%s): State was not set", b.PeerIP)
+	case b.LastState == model.NSUnknown:
+		return fmt.Errorf("Peer(%s): LastState was not set", b.PeerIP)
+	case b.LastError == "":
+		return fmt.Errorf("Peer(%s): LastError was not set", b.PeerIP)
+	}
+
+	if b.State == model.NSEstablished {
+		switch {
+		case b.PeerPort == 0:
+			return fmt.Errorf("Peer(%s): PeerPort was not set on an established session", b.PeerIP)
+		case b.LocalPort == 0:
+			return fmt.Errorf("Peer(%s): LocalPort was not set on an established session", b.PeerIP)
+		case b.PeerID == nil:
+			return fmt.Errorf("Peer(%s): PeerID was not set on an established session", b.PeerIP)
+		case b.LocalID == nil:
+			return fmt.Errorf("Peer(%s): LocalID was not set on an established session", b.PeerIP)
+		case b.PeerIndex == -1:
+			return fmt.Errorf("Peer(%s): PeerIndex was not set on an established session", b.PeerIP)
+		}
+	}
+
+	for _, t := range b.Tables {
+		if err := t.Validate(); err != nil {
+			return fmt.Errorf("Peer(%s): %w", b.PeerIP, err)
+		}
+	}
+	return nil
+}
+
+// lastTable returns the last table that was added by a "Table" line.
+func (b *BGPNeighbor) lastTable() *TableStats {
+	var last *TableStats
+	for _, t := range b.Tables {
+		if last == nil || t.order > last.order {
+			last = t
+		}
+	}
+	return last
+}
+
+// BGPError is a BGP error that has been sent to or received from a peer.
+type BGPError struct {
+	// Name is the name of the error, such as "Hold Timer Expired Error".
+	Name string
+	// Sent is the number of times we sent the error to the peer.
+	Sent int
+	// Recv is the number of times we received the error from the peer.
+	Recv int
+}
+
+// NLRI lists the NLRI (address families) for the various capabilities of a session.
+type NLRI struct {
+	// RestartConfigured are NLRI configured for graceful restart on the peer.
+	RestartConfigured []string
+	// Advertised are NLRI advertised by the peer.
+	Advertised []string
+	// Session are NLRI for this session.
+	Session []string
+	// RestartSupported are NLRI the peer supports graceful restart for.
+	RestartSupported []string
+	// RestartNegotiated are NLRI graceful restart was negotiated for.
+	RestartNegotiated []string
+	// EndOfRIBReceived are NLRI that we received end-of-rib markers for.
+	EndOfRIBReceived []string
+	// EndOfRIBSent are NLRI that we sent end-of-rib markers for.
+	EndOfRIBSent []string
+}
+
+// LastTraffic details how long ago traffic was seen on the session.
+type LastTraffic struct {
+	Received time.Duration
+	Sent     time.Duration
+	Checked  time.Duration
+}
+
+// MessageStats are statistics on BGP messages.
+type MessageStats struct {
+	Total     int
+	Updates   int
+	Refreshes int
+	Octets    int
+}
+
+// TableStats contains information about a routing table for a neighbor.
+type TableStats struct {
+	// Name is the name of the table, like "inet.0".
+	Name string
+	// Bit is the table's bit.
+	Bit int
+	// RIBState is the graceful restart state of the table.
+	RIBState RIBState
+	// SendState is the state of sending routes to the neighbor.
+	SendState SendState
+	// ActivePrefixes is the number of active prefixes.
+	ActivePrefixes int
+	// ReceivedPrefixes is the number of prefixes received.
+	ReceivedPrefixes int
+	// AcceptedPrefixes is the number of prefixes accepted.
+	AcceptedPrefixes int
+	// SuppressedPrefixes is the number of prefixes suppressed due to damping.
+	SuppressedPrefixes int
+	// AdvertisedPrefixes is the number of prefixes advertised. This is -1 if
+	// SendState is RSSendNoAdvertise, as the device does not output it.
+	AdvertisedPrefixes int
+
+	// order is the order the table was found in the output.
+	order int
+}
+
+func newTableStats(name string, order int) *TableStats {
+	return &TableStats{
+		Name:               name,
+		Bit:                -1,
+		ActivePrefixes:     -1,
+		ReceivedPrefixes:   -1,
+		AcceptedPrefixes:   -1,
+		SuppressedPrefixes: -1,
+		AdvertisedPrefixes: -1,
+		order:              order,
+	}
+}
+
+// Validate implements halfpike.Validator.
+func (t *TableStats) Validate() error {
+	switch -1 {
+	case t.Bit:
+		return fmt.Errorf("Table(%s): Bit was not parsed from the input", t.Name)
+	case t.ActivePrefixes:
+		return fmt.Errorf("Table(%s): ActivePrefixes was not parsed from the input", t.Name)
+	case t.ReceivedPrefixes:
+		return fmt.Errorf("Table(%s): ReceivedPrefixes was not parsed from the input", t.Name)
+	case t.AcceptedPrefixes:
+		return fmt.Errorf("Table(%s): AcceptedPrefixes was not parsed from the input", t.Name)
+	case t.SuppressedPrefixes:
+		return fmt.Errorf("Table(%s): SuppressedPrefixes was not parsed from the input", t.Name)
+	}
+
+	switch {
+	case t.RIBState == RSUnknown:
+		return fmt.Errorf("Table(%s): RIBState was unknown, which indicates the parser is broken on input", t.Name)
+	case t.SendState == RSSendUnknown:
+		return fmt.Errorf("Table(%s): SendState was unknown, which indicates the parser is broken on input", t.Name)
+	case t.SendState != RSSendNoAdvertise && t.AdvertisedPrefixes == -1:
+		return fmt.Errorf("Table(%s): AdvertisedPrefixes was not parsed from the input", t.Name)
+	}
+	return nil
+}
+
+// peerHandler decodes a line that starts with "find" into a BGPNeighbor.
+type peerHandler struct {
+	find   []string
+	decode func(rec *BGPNeighbor, line halfpike.Line) error
+}
+
+// peerHandlers are the handlers for lines within a peer record. Lines that do not match a
+// handler are ignored, as Junos adds lines depending on the version and session state.
+// Order matters, as the first handler that matches is used.
+var peerHandlers = []peerHandler{
+	{[]string{"Description:", halfpike.Skip}, decodeDescription},
+	{[]string{"Group:", halfpike.Skip}, decodeGroup},
+	{[]string{"Type:", halfpike.Skip, "State:", halfpike.Skip}, decodeTypeState},
+	{[]string{"Last", "State:", halfpike.Skip}, decodeLastState},
+	{[]string{"Last", "Error:", halfpike.Skip}, decodeLastError},
+	{[]string{"Options:", halfpike.Skip}, decodeOptions},
+	{[]string{"Local", "Address:", halfpike.Skip}, decodeHoldTimePref},
+	{[]string{"Holdtime:", halfpike.Skip}, decodeHoldTimePref},
+	{[]string{"Number", "of", "flaps:", halfpike.Skip}, decodeFlaps},
+	{[]string{"Last", "flap", "event:", halfpike.Skip}, decodeLastFlap},
+	{[]string{"Error:", halfpike.Skip}, decodeError},
+	{[]string{"Peer", "ID:", halfpike.Skip}, decodeIDs},
+	{[]string{"Keepalive", "Interval:", halfpike.Skip}, decodeKeepalive},
+	{[]string{"BFD:", halfpike.Skip}, decodeBFD},
+	{[]string{"Local", "Interface:", halfpike.Skip}, decodeLocalInterface},
+	{[]string{"NLRI", "for", "restart", "configured", "on", "peer:"}, decodeNLRI(func(n *NLRI) *[]string { return &n.RestartConfigured })},
+	{[]string{"NLRI", "advertised", "by", "peer:"}, decodeNLRI(func(n *NLRI) *[]string { return &n.Advertised })},
+	{[]string{"NLRI", "for", "this", "session:"}, decodeNLRI(func(n *NLRI) *[]string { return &n.Session })},
+	{[]string{"NLRI", "that", "peer", "supports", "restart", "for:"}, decodeNLRI(func(n *NLRI) *[]string { return &n.RestartSupported })},
+	{[]string{"NLRI", "that", "restart", "is", "negotiated", "for:"}, decodeNLRI(func(n *NLRI) *[]string { return &n.RestartNegotiated })},
+	{[]string{"NLRI", "of", "received", "end-of-rib", "markers:"}, decodeNLRI(func(n *NLRI) *[]string { return &n.EndOfRIBReceived })},
+	{[]string{"NLRI", "of", "all", "end-of-rib", "markers", "sent:"}, decodeNLRI(func(n *NLRI) *[]string { return &n.EndOfRIBSent })},
+	{[]string{"Peer", "supports", "Refresh", "capability"}, func(rec *BGPNeighbor, line halfpike.Line) error {
+		rec.RefreshCapability = true
+		return nil
+	}},
+	{[]string{"Peer", "supports", "4", "byte", "AS", "extension"}, func(rec *BGPNeighbor, line halfpike.Line) error {
+		rec.FourByteAS = true
+		return nil
+	}},
+	{[]string{"Peer", "supports", "Addpath"}, func(rec *BGPNeighbor, line halfpike.Line) error {
+		rec.Addpath = true
+		return nil
+	}},
+	{[]string{"Restart", "time", "configured", "on", "the", "peer:", halfpike.Skip}, decodeSeconds(6, func(rec *BGPNeighbor) *time.Duration { return &rec.RestartTimeConfigured })},
+	{[]string{"Restart", "time", "requested", "by", "this", "peer:", halfpike.Skip}, decodeSeconds(6, func(rec *BGPNeighbor) *time.Duration { return &rec.RestartTimeRequested })},
+	{[]string{"Stale", "routes", "from", "peer", "are", "kept", "for:", halfpike.Skip}, decodeSeconds(7, func(rec *BGPNeighbor) *time.Duration { return &rec.StaleRoutesTime })},
+	{[]string{"Table", halfpike.Skip, "Bit:", halfpike.Skip}, decodeTable},
+	{[]string{"RIB", "State:", "BGP", halfpike.Skip}, decodeRIBState},
+	{[]string{"Send", "state:", halfpike.Skip}, decodeSendState},
+	{[]string{"Active", "prefixes:", halfpike.Skip}, decodeTableInt(func(t *TableStats) *int { return &t.ActivePrefixes })},
+	{[]string{"Received", "prefixes:", halfpike.Skip}, decodeTableInt(func(t *TableStats) *int { return &t.ReceivedPrefixes })},
+	{[]string{"Accepted", "prefixes:", halfpike.Skip}, decodeTableInt(func(t *TableStats) *int { return &t.AcceptedPrefixes })},
+	{[]string{"Suppressed", "due", "to", "damping:", halfpike.Skip}, decodeTableInt(func(t *TableStats) *int { return &t.SuppressedPrefixes })},
+	{[]string{"Advertised", "prefixes:", halfpike.Skip}, decodeTableInt(func(t *TableStats) *int { return &t.AdvertisedPrefixes })},
+	{[]string{"Last", "traffic", "(seconds):"}, decodeLastTraffic},
+	{[]string{"Input", "messages:"}, decodeMessages(func(rec *BGPNeighbor) *MessageStats { return &rec.InputMessages })},
+	{[]string{"Output", "messages:"}, decodeMessages(func(rec *BGPNeighbor) *MessageStats { return &rec.OutputMessages })},
+	{[]string{"Output", halfpike.Skip, halfpike.Skip}, decodeOutputQueue},
+}
+
+// Description: core-rr-1
+func decodeDescription(rec *BGPNeighbor, line halfpike.Line) error {
+	rec.Description = halfpike.ItemJoin(line, 1, -1)
+	return nil
+}
+
+// Group: ibgp                  Routing-Instance: master
+func decodeGroup(rec *BGPNeighbor, line halfpike.Line) error {
+	rec.Group = line.Items[1].Val
+	if item, ok := valueAfter(line, "Routing-Instance:"); ok {
+		rec.RoutingInstance = item.Val
+	}
+	return nil
+}
+
+// toPeerType converts a string representing the peer type to an enumerated value.
+var toPeerType = halfpike.NewEnum[PeerType]("peer type").
+	Add("Internal", PTInternal).
+	Add("External", PTExternal)
+
+// Type: External    State: Established    Flags: <Sync>
+func decodeTypeState(rec *BGPNeighbor, line halfpike.Line) error {
+	const (
+		peerType = 1
+		state    = 3
+	)
+
+	t, err := toPeerType.Lookup(line.Items[peerType].Val)
+	if err != nil {
+		return fmt.Errorf("Type: %w", err)
+	}
+	rec.Type = t
+
+	s, ok := model.ParseBGPState(line.Items[state].Val)
+	if !ok {
+		return fmt.Errorf("BGP State was not one of the accepted types (Active, Connect, ...), was %s", line.Items[state].Val)
+	}
+	rec.State = s
+
+	i := indexOf(line, "Flags:")
+	if i == -1 {
+		return fmt.Errorf("did not find Flags: on the Type line")
+	}
+	flags, err := angleList(halfpike.ItemJoin(line, i+1, -1))
+	if err != nil {
+		return fmt.Errorf("Flags: %s", err)
+	}
+	rec.Flags = flags
+	return nil
+}
+
+// Last State: OpenConfirm   Last Event: RecvKeepAlive
+func decodeLastState(rec *BGPNeighbor, line halfpike.Line) error {
+	s, ok := model.ParseBGPState(line.Items[2].Val)
+	if !ok {
+		return fmt.Errorf("BGP last state was not one of the accepted types (Active, Connect, ...), was %s", line.Items[2].Val)
+	}
+	rec.LastState = s
+
+	if item, ok := valueAfter(line, "Event:"); ok {
+		rec.LastEvent = item.Val
+	}
+	return nil
+}
+
+// Last Error: Hold Timer Expired Error
+func decodeLastError(rec *BGPNeighbor, line halfpike.Line) error {
+	rec.LastError = halfpike.ItemJoin(line, 2, -1)
+	return nil
+}
+
+// Options: <Preference PeerAS Refresh>
+func decodeOptions(rec *BGPNeighbor, line halfpike.Line) error {
+	opts, err := angleList(halfpike.ItemJoin(line, 1, -1))
+	if err != nil {
+		return fmt.Errorf("Options: %s", err)
+	}
+	// Junos splits long option lists into multiple Options lines.
+	rec.Options = append(rec.Options, opts...)
+	return nil
+}
+
+// Holdtime: 90 Preference: 170
+// Local Address: 192.168.0.1 Holdtime: 90 Preference: 170
+func decodeHoldTimePref(rec *BGPNeighbor, line halfpike.Line) error {
+	if line.Items[0].Val == "Local" {
+		ip := net.ParseIP(line.Items[2].Val)
+		if ip == nil {
+			return fmt.Errorf("Local Address does not appear to be an IP: was %s", line.Items[2].Val)
+		}
+		rec.LocalAddress = ip
+	}
+
+	ht, ok := valueAfter(line, "Holdtime:")
+	if !ok {
+		return fmt.Errorf("did not find Holdtime")
+	}
+	v, err := ht.ToInt()
+	if err != nil {
+		return fmt.Errorf("Holdtime was not an integer, was %s", ht.Val)
+	}
+	rec.HoldTime = time.Duration(v) * time.Second
+
+	pref, ok := valueAfter(line, "Preference:")
+	if !ok {
+		return fmt.Errorf("did not find Preference")
+	}
+	if rec.Preference, err = pref.ToInt(); err != nil {
+		return fmt.Errorf("Preference was not an integer, was %s", pref.Val)
+	}
+	return nil
+}
+
+// Number of flaps: 0
+func decodeFlaps(rec *BGPNeighbor, line halfpike.Line) error {
+	v, err := line.Items[3].ToInt()
+	if err != nil {
+		return fmt.Errorf("Number of flaps was not an integer, was %s", line.Items[3].Val)
+	}
+	rec.Flaps = v
+	return nil
+}
+
+// Last flap event: RecvNotify
+func decodeLastFlap(rec *BGPNeighbor, line halfpike.Line) error {
+	rec.LastFlapEvent = halfpike.ItemJoin(line, 3, -1)
+	return nil
+}
+
+// Error: 'Hold Timer Expired Error' Sent: 5 Recv: 0
+func decodeError(rec *BGPNeighbor, line halfpike.Line) error {
+	start := strings.Index(line.Raw, "'")
+	end := strings.LastIndex(line.Raw, "'")
+	if start == -1 || start == end {
+		return fmt.Errorf("Error line did not have a quoted error name")
+	}
+	e := BGPError{Name: line.Raw[start+1 : end]}
+
+	var err error
+	sent, ok := valueAfter(line, "Sent:")
+	if !ok {
+		return fmt.Errorf("Error line did not have Sent:")
+	}
+	if e.Sent, err = sent.ToInt(); err != nil {
+		return fmt.Errorf("Error line Sent: was not an integer, was %s", sent.Val)
+	}
+	recv, ok := valueAfter(line, "Recv:")
+	if !ok {
+		return fmt.Errorf("Error line did not have Recv:")
+	}
+	if e.Recv, err = recv.ToInt(); err != nil {
+		return fmt.Errorf("Error line Recv: was not an integer, was %s", recv.Val)
+	}
+	rec.Errors = append(rec.Errors, e)
+	return nil
+}
+
+// Peer ID: 10.10.10.6       Local ID: 10.10.10.1       Active Holdtime: 90
+func decodeIDs(rec *BGPNeighbor, line halfpike.Line) error {
+	const (
+		peer  = 2
+		local = 5
+	)
+
+	if !isAt(line, 3, "Local", "ID:") || len(line.Items) <= local {
+		return fmt.Errorf("Peer ID line did not have a Local ID")
+	}
+
+	pid := net.ParseIP(line.Items[peer].Val)
+	if pid == nil {
+		return fmt.Errorf("PeerID does not appear to be an IP: was %s", line.Items[peer].Val)
+	}
+	loc := net.ParseIP(line.Items[local].Val)
+	if loc == nil {
+		return fmt.Errorf("LocalID does not appear to be an IP: was %s", line.Items[local].Val)
+	}
+	rec.PeerID = pid
+	rec.LocalID = loc
+
+	if item, ok := valueAfter(line, "Holdtime:"); ok {
+		v, err := item.ToInt()
+		if err != nil {
+			return fmt.Errorf("Active Holdtime was not an integer, was %s", item.Val)
+		}
+		rec.ActiveHoldTime = time.Duration(v) * time.Second
+	}
+	return nil
+}
+
+// Keepalive Interval: 30         Group index: 0    Peer index: 0    SNMP index: 1
+func decodeKeepalive(rec *BGPNeighbor, line halfpike.Line) error {
+	v, err := line.Items[2].ToInt()
+	if err != nil {
+		return fmt.Errorf("Keepalive Interval was not an integer, was %s", line.Items[2].Val)
+	}
+	rec.KeepaliveInterval = time.Duration(v) * time.Second
+
+	for i := range line.Items {
+		if isAt(line, i, "Peer", "index:") && i+2 < len(line.Items) {
+			if rec.PeerIndex, err = line.Items[i+2].ToInt(); err != nil {
+				return fmt.Errorf("Peer index was not an integer, was %s", line.Items[i+2].Val)
+			}
+		}
+	}
+	return nil
+}
+
+// BFD: disabled, down
+func decodeBFD(rec *BGPNeighbor, line halfpike.Line) error {
+	rec.BFD = halfpike.ItemJoin(line, 1, -1)
+	return nil
+}
+
+// Local Interface: ge-1/2/0.0
+func decodeLocalInterface(rec *BGPNeighbor, line halfpike.Line) error {
+	rec.LocalInterface = line.Items[2].Val
+	return nil
+}
+
+// NLRI advertised by peer: inet-unicast inet6-unicast
+func decodeNLRI(field func(n *NLRI) *[]string) func(rec *BGPNeighbor, line halfpike.Line) error {
+	return func(rec *BGPNeighbor, line halfpike.Line) error {
+		i := indexOfSuffix(line, ":")
+		list := []string{}
+		for _, item := range line.Items[i+1:] {
+			switch item.Type {
+			case halfpike.ItemEOL, halfpike.ItemEOF:
+				continue
+			}
+			list = append(list, item.Val)
+		}
+		*field(&rec.NLRI) = list
+		return nil
+	}
+}
+
+// Restart time configured on the peer: 120
+func decodeSeconds(index int, field func(rec *BGPNeighbor) *time.Duration) func(rec *BGPNeighbor, line halfpike.Line) error {
+	return func(rec *BGPNeighbor, line halfpike.Line) error {
+		v, err := line.Items[index].ToInt()
+		if err != nil {
+			return fmt.Errorf("%s was not an integer, was %s", halfpike.ItemJoin(line, 0, index), line.Items[index].Val)
+		}
+		*field(rec) = time.Duration(v) * time.Second
+		return nil
+	}
+}
+
+// Table inet.0 Bit: 10000
+func decodeTable(rec *BGPNeighbor, line halfpike.Line) error {
+	const (
+		table = 1
+		bit   = 3
+	)
+
+	if rec.Tables == nil {
+		rec.Tables = map[string]*TableStats{}
+	}
+	name := line.Items[table].Val
+	if _, ok := rec.Tables[name]; ok {
+		return fmt.Errorf("Table %s was listed twice", name)
+	}
+
+	t := newTableStats(name, len(rec.Tables))
+	b, err := line.Items[bit].ToInt()
+	if err != nil {
+		return fmt.Errorf("Table(%s) had a Bit that wasn't an integer: %s", name, line.Items[bit].Val)
+	}
+	t.Bit = b
+	rec.Tables[name] = t
+	return nil
+}
+
+var toRIBState = halfpike.NewEnum[RIBState]("RIB state").
+	Add("restart is complete", RSComplete).
+	Add("restart in progress", RSInProgress)
+
+// RIB State: BGP restart is complete
+func decodeRIBState(rec *BGPNeighbor, line halfpike.Line) error {
+	t := rec.lastTable()
+	if t == nil {
+		return fmt.Errorf("found RIB State outside of a Table")
+	}
+
+	s := halfpike.ItemJoin(line, 3, -1)
+	v, err := toRIBState.Lookup(s)
+	if err != nil {
+		return fmt.Errorf("Table(%s): %w", t.Name, err)
+	}
+	t.RIBState = v
+	return nil
+}
+
+var toSendState = halfpike.NewEnum[SendState]("send state").
+	Add("in sync", RSSendSync).
+	Add("not in sync", RSSendNotSync).
+	Add("not advertising", RSSendNoAdvertise)
+
+// Send state: in sync
+func decodeSendState(rec *BGPNeighbor, line halfpike.Line) error {
+	t := rec.lastTable()
+	if t == nil {
+		return fmt.Errorf("found Send state outside of a Table")
+	}
+
+	s := halfpike.ItemJoin(line, 2, -1)
+	v, err := toSendState.Lookup(s)
+	if err != nil {
+		return fmt.Errorf("Table(%s): %w", t.Name, err)
+	}
+	t.SendState = v
+	return nil
+}
+
+// Active prefixes:              0
+func decodeTableInt(field func(t *TableStats) *int) func(rec *BGPNeighbor, line halfpike.Line) error {
+	return func(rec *BGPNeighbor, line halfpike.Line) error {
+		t := rec.lastTable()
+		if t == nil {
+			return fmt.Errorf("found %q outside of a Table", halfpike.ItemJoin(line, -1, -1))
+		}
+
+		i := indexOfSuffix(line, ":") + 1
+		v, err := line.Items[i].ToInt()
+		if err != nil {
+			return fmt.Errorf("Table(%s) did not have %s value as a int, had %v", t.Name, halfpike.ItemJoin(line, 0, i), line.Items[i].Val)
+		}
+		*field(t) = v
+		return nil
+	}
+}
+
+// Last traffic (seconds): Received 10   Sent 6    Checked 1
+func decodeLastTraffic(rec *BGPNeighbor, line halfpike.Line) error {
+	fields := map[string]*time.Duration{
+		"Received": &rec.LastTraffic.Received,
+		"Sent":     &rec.LastTraffic.Sent,
+		"Checked":  &rec.LastTraffic.Checked,
+	}
+	for k, ptr := range fields {
+		item, ok := valueAfter(line, k)
+		if !ok {
+			return fmt.Errorf("Last traffic did not have %s", k)
+		}
+		v, err := item.ToInt()
+		if err != nil {
+			return fmt.Errorf("Last traffic %s was not an integer, was %s", k, item.Val)
+		}
+		*ptr = time.Duration(v) * time.Second
+	}
+	return nil
+}
+
+// Input messages:  Total 8522   Updates 1       Refreshes 0     Octets 161922
+func decodeMessages(field func(rec *BGPNeighbor) *MessageStats) func(rec *BGPNeighbor, line halfpike.Line) error {
+	return func(rec *BGPNeighbor, line halfpike.Line) error {
+		ms := field(rec)
+		fields := map[string]*int{
+			"Total":     &ms.Total,
+			"Updates":   &ms.Updates,
+			"Refreshes": &ms.Refreshes,
+			"Octets":    &ms.Octets,
+		}
+		for k, ptr := range fields {
+			item, ok := valueAfter(line, k)
+			if !ok {
+				return fmt.Errorf("%s did not have %s", halfpike.ItemJoin(line, 0, 2), k)
+			}
+			v, err := item.ToInt()
+			if err != nil {
+				return fmt.Errorf("%s %s was not an integer, was %s", halfpike.ItemJoin(line, 0, 2), k, item.Val)
+			}
+			*ptr = v
+		}
+		return nil
+	}
+}
+
+// Output Queue[0]: 0
+// Output Queue[1]: 0            (inet.0, inet-unicast)
+func decodeOutputQueue(rec *BGPNeighbor, line halfpike.Line) error {
+	s := line.Items[1].Val
+	if !strings.HasPrefix(s, "Queue[") {
+		return nil
+	}
+	if !strings.HasSuffix(s, "]:") {
+		return fmt.Errorf("Output Queue had unexpected format: %s", s)
+	}
+	q, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(s, "Queue["), "]:"))
+	if err != nil {
+		return fmt.Errorf("Output Queue number was not an integer: %s", s)
+	}
+	v, err := line.Items[2].ToInt()
+	if err != nil {
+		return fmt.Errorf("Output Queue[%d] was not an integer, was %s", q, line.Items[2].Val)
+	}
+	if rec.OutputQueues == nil {
+		rec.OutputQueues = map[int]int{}
+	}
+	rec.OutputQueues[q] = v
+	return nil
+}
+
+// valueAfter returns the Item after the first Item with value "key".
+func valueAfter(line halfpike.Line, key string) (halfpike.Item, bool) {
+	i := indexOf(line, key)
+	if i == -1 || i+1 >= len(line.Items) {
+		return halfpike.Item{}, false
+	}
+	switch line.Items[i+1].Type {
+	case halfpike.ItemEOL, halfpike.ItemEOF:
+		return halfpike.Item{}, false
+	}
+	return line.Items[i+1], true
+}
+
+// indexOf returns the index of the first Item with value "key" or -1.
+func indexOf(line halfpike.Line, key string) int {
+	for i, item := range line.Items {
+		if item.Val == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// indexOfSuffix returns the index of the first Item that ends with "suffix" or -1.
+func indexOfSuffix(line halfpike.Line, suffix string) int {
+	for i, item := range line.Items {
+		if strings.HasSuffix(item.Val, suffix) {
+			return i
+		}
+	}
+	return -1
+}
+
+// isAt returns true if line.Items starting at index "i" have the values in "vals".
+func isAt(line halfpike.Line, i int, vals ...string) bool {
+	if i+len(vals) > len(line.Items) {
+		return false
+	}
+	for x, v := range vals {
+		if line.Items[i+x].Val != v {
+			return false
+		}
+	}
+	return true
+}
+
+// angleList converts "<Preference PeerAS Refresh>" into []string{"Preference", "PeerAS", "Refresh"}.
+func angleList(s string) ([]string, error) {
+	if !strings.HasPrefix(s, "<") || !strings.HasSuffix(s, ">") {
+		return nil, fmt.Errorf("list was not enclosed in <>: %s", s)
+	}
+	return strings.Fields(s[1 : len(s)-1]), nil
+}
+
+// ipPort splits <ip>+<port> or <ip>. If there is no port, 0 is returned for the port.
+func ipPort(s string) (net.IP, uint32, error) {
+	sp := strings.Split(s, `+`)
+	if len(sp) > 2 {
+		return nil, 0, fmt.Errorf("IP address and port could not be found with syntax <ip>+<port>: %s", s)
+	}
+	ip := net.ParseIP(sp[0])
+	if ip == nil {
+		return nil, 0, fmt.Errorf("IP address could not be parsed: %s", sp[0])
+	}
+	if len(sp) == 1 {
+		return ip, 0, nil
+	}
+	port, err := strconv.ParseUint(sp[1], 10, 16)
+	if err != nil {
+		return nil, 0, fmt.Errorf("IP port could not be parsed from: %s", sp[1])
+	}
+	return ip, uint32(port), nil
+}
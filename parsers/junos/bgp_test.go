@@ -0,0 +1,361 @@
+package junos
+
+import (
+	"context"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johnsiilver/halfpike"
+	"github.com/johnsiilver/halfpike/parsers/model"
+	"github.com/kylelemons/godebug/pretty"
+)
+
+// cmpConfig is used to compare results without unexported fields.
+var cmpConfig = pretty.Config{Diffable: true}
+
+func TestBGPNeighbors(t *testing.T) {
+	tests := []struct {
+		desc string
+		file string
+		want []*BGPNeighbor
+	}{
+		{
+			desc: "Two established external peers",
+			file: "show_bgp_neighbor.txt",
+			want: []*BGPNeighbor{
+				{
+					PeerIP:            net.ParseIP("10.10.10.2"),
+					PeerPort:          179,
+					PeerAS:            22,
+					LocalIP:           net.ParseIP("10.10.10.1"),
+					LocalPort:         65406,
+					LocalAS:           17,
+					Type:              PTExternal,
+					State:             model.NSEstablished,
+					Flags:             []string{"Sync"},
+					LastState:         model.NSOpenConfirm,
+					LastEvent:         "RecvKeepAlive",
+					LastError:         "None",
+					Options:           []string{"Preference", "PeerAS", "Refresh"},
+					HoldTime:          90 * time.Second,
+					Preference:        170,
+					Flaps:             0,
+					PeerID:            net.ParseIP("10.10.10.2"),
+					LocalID:           net.ParseIP("10.10.10.1"),
+					ActiveHoldTime:    90 * time.Second,
+					KeepaliveInterval: 30 * time.Second,
+					PeerIndex:         0,
+					BFD:               "disabled, down",
+					LocalInterface:    "ge-1/2/0.0",
+					NLRI: NLRI{
+						RestartConfigured: []string{"inet-unicast"},
+						Advertised:        []string{"inet-unicast"},
+						Session:           []string{"inet-unicast"},
+						RestartSupported:  []string{"inet-unicast"},
+						RestartNegotiated: []string{"inet-unicast"},
+						EndOfRIBReceived:  []string{"inet-unicast"},
+						EndOfRIBSent:      []string{"inet-unicast"},
+					},
+					RefreshCapability:     true,
+					FourByteAS:            true,
+					RestartTimeConfigured: 120 * time.Second,
+					RestartTimeRequested:  120 * time.Second,
+					StaleRoutesTime:       300 * time.Second,
+					Tables: map[string]*TableStats{
+						"inet.0": {
+							Name:               "inet.0",
+							Bit:                10000,
+							RIBState:           RSComplete,
+							SendState:          RSSendSync,
+							ActivePrefixes:     0,
+							ReceivedPrefixes:   0,
+							AcceptedPrefixes:   0,
+							SuppressedPrefixes: 2,
+							AdvertisedPrefixes: 0,
+						},
+					},
+					LastTraffic:    LastTraffic{Received: 10 * time.Second, Sent: 6 * time.Second, Checked: 1 * time.Second},
+					InputMessages:  MessageStats{Total: 8522, Updates: 1, Refreshes: 0, Octets: 161922},
+					OutputMessages: MessageStats{Total: 8433, Updates: 0, Refreshes: 0, Octets: 160290},
+					OutputQueues:   map[int]int{0: 0},
+				},
+				{
+					PeerIP:            net.ParseIP("10.10.10.6"),
+					PeerPort:          54781,
+					PeerAS:            22,
+					LocalIP:           net.ParseIP("10.10.10.5"),
+					LocalPort:         179,
+					LocalAS:           17,
+					Type:              PTExternal,
+					State:             model.NSEstablished,
+					Flags:             []string{"Sync"},
+					LastState:         model.NSOpenConfirm,
+					LastEvent:         "RecvKeepAlive",
+					LastError:         "None",
+					Options:           []string{"Preference", "PeerAS", "Refresh"},
+					HoldTime:          90 * time.Second,
+					Preference:        170,
+					Flaps:             0,
+					PeerID:            net.ParseIP("10.10.10.6"),
+					LocalID:           net.ParseIP("10.10.10.1"),
+					ActiveHoldTime:    90 * time.Second,
+					KeepaliveInterval: 30 * time.Second,
+					PeerIndex:         1,
+					BFD:               "disabled, down",
+					LocalInterface:    "ge-0/0/1.5",
+					NLRI: NLRI{
+						RestartConfigured: []string{"inet-unicast"},
+						Advertised:        []string{"inet-unicast"},
+						Session:           []string{"inet-unicast"},
+						RestartSupported:  []string{"inet-unicast"},
+						RestartNegotiated: []string{"inet-unicast"},
+						EndOfRIBReceived:  []string{"inet-unicast"},
+						EndOfRIBSent:      []string{"inet-unicast"},
+					},
+					RefreshCapability:     true,
+					FourByteAS:            true,
+					RestartTimeConfigured: 120 * time.Second,
+					RestartTimeRequested:  120 * time.Second,
+					StaleRoutesTime:       300 * time.Second,
+					Tables: map[string]*TableStats{
+						"inet.0": {
+							Name:               "inet.0",
+							Bit:                10000,
+							RIBState:           RSComplete,
+							SendState:          RSSendSync,
+							ActivePrefixes:     0,
+							ReceivedPrefixes:   0,
+							AcceptedPrefixes:   0,
+							SuppressedPrefixes: 0,
+							AdvertisedPrefixes: 0,
+						},
+					},
+					LastTraffic:    LastTraffic{Received: 12 * time.Second, Sent: 6 * time.Second, Checked: 33 * time.Second},
+					InputMessages:  MessageStats{Total: 8527, Updates: 1, Refreshes: 0, Octets: 162057},
+					OutputMessages: MessageStats{Total: 8430, Updates: 0, Refreshes: 0, Octets: 160233},
+					OutputQueues:   map[int]int{0: 0},
+				},
+			},
+		},
+		{
+			desc: "Internal peer with multiple tables and peers in other states",
+			file: "show_bgp_neighbor_mixed.txt",
+			want: []*BGPNeighbor{
+				{
+					PeerIP:            net.ParseIP("192.168.0.2"),
+					PeerPort:          179,
+					PeerAS:            65000,
+					LocalIP:           net.ParseIP("192.168.0.1"),
+					LocalPort:         60123,
+					LocalAS:           65000,
+					Description:       "core-rr-1",
+					Group:             "ibgp",
+					RoutingInstance:   "master",
+					Type:              PTInternal,
+					State:             model.NSEstablished,
+					Flags:             []string{"Sync"},
+					LastState:         model.NSOpenConfirm,
+					LastEvent:         "RecvKeepAlive",
+					LastError:         "Cease",
+					Options:           []string{"Preference", "LocalAddress", "AddressFamily", "Rib-group", "Refresh"},
+					LocalAddress:      net.ParseIP("192.168.0.1"),
+					HoldTime:          90 * time.Second,
+					Preference:        170,
+					Flaps:             2,
+					LastFlapEvent:     "RecvNotify",
+					Errors:            []BGPError{{Name: "Cease", Sent: 0, Recv: 2}},
+					PeerID:            net.ParseIP("192.168.0.2"),
+					LocalID:           net.ParseIP("192.168.0.1"),
+					ActiveHoldTime:    90 * time.Second,
+					KeepaliveInterval: 30 * time.Second,
+					PeerIndex:         0,
+					BFD:               "disabled, down",
+					NLRI: NLRI{
+						RestartConfigured: []string{"inet-unicast", "inet6-unicast"},
+						Advertised:        []string{"inet-unicast", "inet6-unicast"},
+						Session:           []string{"inet-unicast", "inet6-unicast"},
+						RestartNegotiated: []string{"inet-unicast", "inet6-unicast"},
+						EndOfRIBReceived:  []string{"inet-unicast", "inet6-unicast"},
+						EndOfRIBSent:      []string{"inet-unicast", "inet6-unicast"},
+					},
+					RefreshCapability: true,
+					FourByteAS:        true,
+					StaleRoutesTime:   300 * time.Second,
+					Tables: map[string]*TableStats{
+						"inet.0": {
+							Name:               "inet.0",
+							Bit:                20000,
+							RIBState:           RSComplete,
+							SendState:          RSSendSync,
+							ActivePrefixes:     812,
+							ReceivedPrefixes:   820,
+							AcceptedPrefixes:   820,
+							SuppressedPrefixes: 0,
+							AdvertisedPrefixes: 14,
+						},
+						"inet6.0": {
+							Name:               "inet6.0",
+							Bit:                30000,
+							RIBState:           RSInProgress,
+							SendState:          RSSendNoAdvertise,
+							ActivePrefixes:     25,
+							ReceivedPrefixes:   25,
+							AcceptedPrefixes:   25,
+							SuppressedPrefixes: 0,
+							AdvertisedPrefixes: -1,
+						},
+					},
+					LastTraffic:    LastTraffic{Received: 3 * time.Second, Sent: 17 * time.Second, Checked: 1811 * time.Second},
+					InputMessages:  MessageStats{Total: 1838, Updates: 913, Refreshes: 0, Octets: 172010},
+					OutputMessages: MessageStats{Total: 1790, Updates: 12, Refreshes: 0, Octets: 34567},
+					OutputQueues:   map[int]int{1: 0, 2: 0},
+				},
+				{
+					PeerIP:          net.ParseIP("10.1.1.1"),
+					PeerAS:          65001,
+					LocalIP:         net.ParseIP("10.1.1.2"),
+					LocalAS:         65000,
+					Group:           "transit-a",
+					RoutingInstance: "master",
+					Type:            PTExternal,
+					State:           model.NSActive,
+					Flags:           []string{},
+					LastState:       model.NSIdle,
+					LastEvent:       "Start",
+					LastError:       "Hold Timer Expired Error",
+					Options:         []string{"Preference", "PeerAS", "Refresh"},
+					HoldTime:        90 * time.Second,
+					Preference:      170,
+					Flaps:           5,
+					LastFlapEvent:   "HoldTime",
+					Errors:          []BGPError{{Name: "Hold Timer Expired Error", Sent: 5, Recv: 0}},
+					PeerIndex:       -1,
+				},
+				{
+					PeerIP:          net.ParseIP("10.2.2.2"),
+					PeerAS:          65002,
+					LocalIP:         net.ParseIP("10.2.2.1"),
+					LocalAS:         65000,
+					Group:           "transit-b",
+					RoutingInstance: "master",
+					Type:            PTExternal,
+					State:           model.NSIdle,
+					Flags:           []string{"ImportEval"},
+					LastState:       model.NSActive,
+					LastEvent:       "Stop",
+					LastError:       "None",
+					Options:         []string{"Preference", "Shutdown", "PeerAS", "Refresh"},
+					HoldTime:        90 * time.Second,
+					Preference:      170,
+					Flaps:           0,
+					PeerIndex:       -1,
+				},
+				{
+					PeerIP:        net.ParseIP("10.3.3.3"),
+					PeerPort:      179,
+					PeerAS:        65003,
+					LocalIP:       net.ParseIP("10.3.3.1"),
+					LocalPort:     51234,
+					LocalAS:       65000,
+					Type:          PTExternal,
+					State:         model.NSOpenSent,
+					Flags:         []string{},
+					LastState:     model.NSConnect,
+					LastEvent:     "RecvOpen",
+					LastError:     "Open Message Error",
+					Options:       []string{"Preference", "PeerAS", "Refresh"},
+					HoldTime:      90 * time.Second,
+					Preference:    170,
+					Flaps:         1,
+					LastFlapEvent: "RecvNotify",
+					Errors:        []BGPError{{Name: "Open Message Error", Sent: 0, Recv: 1}},
+					PeerIndex:     -1,
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		b, err := os.ReadFile("testdata/" + test.file)
+		if err != nil {
+			t.Fatalf("TestBGPNeighbors(%s): got err == %s", test.desc, err)
+		}
+
+		got := &BGPNeighbors{}
+		if err := halfpike.Parse(context.Background(), string(b), got); err != nil {
+			t.Errorf("TestBGPNeighbors(%s): got err == %s", test.desc, err)
+			continue
+		}
+
+		if diff := cmpConfig.Compare(test.want, got.Peers); diff != "" {
+			t.Errorf("TestBGPNeighbors(%s): -want/+got:\n%s", test.desc, diff)
+		}
+	}
+}
+
+func TestBGPNeighborsErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		content string
+		// wantErr are the texts the error must contain, such as the line and the value that was bad.
+		wantErr []string
+	}{
+		{
+			desc:    "No peers",
+			content: "nothing to see here\n",
+			wantErr: []string{"start of our list of peers"},
+		},
+		{
+			desc: "Bad state",
+			content: `Peer: 10.1.1.1 AS 65001 Local: 10.1.1.2 AS 65000
+  Type: External    State: Dancing         Flags: <>
+`,
+			wantErr: []string{"[Line 1] Peer(10.1.1.1)", "Dancing"},
+		},
+		{
+			desc: "Bad type",
+			content: `Peer: 10.1.1.1 AS 65001 Local: 10.1.1.2 AS 65000
+  Type: external    State: Active         Flags: <>
+`,
+			wantErr: []string{"[Line 1] Peer(10.1.1.1)", "peer type", "external"},
+		},
+		{
+			desc: "Bad Send state",
+			content: `Peer: 10.1.1.1 AS 65001 Local: 10.1.1.2 AS 65000
+  Table inet.0 Bit: 10000
+    Send state: maybe in sync
+`,
+			wantErr: []string{"[Line 2] Peer(10.1.1.1)", "Table(inet.0)", "send state", "maybe in sync"},
+		},
+		{
+			desc: "Missing required lines",
+			content: `Peer: 10.1.1.1 AS 65001 Local: 10.1.1.2 AS 65000
+  Type: External    State: Active         Flags: <>
+`,
+			wantErr: []string{"Peer(10.1.1.1)", "Preference"},
+		},
+		{
+			desc: "Table stats outside a table",
+			content: `Peer: 10.1.1.1 AS 65001 Local: 10.1.1.2 AS 65000
+    Active prefixes:              812
+`,
+			wantErr: []string{"[Line 1] Peer(10.1.1.1)", "Active prefixes: 812", "outside of a Table"},
+		},
+	}
+
+	for _, test := range tests {
+		err := halfpike.Parse(context.Background(), test.content, &BGPNeighbors{})
+		if err == nil {
+			t.Errorf("TestBGPNeighborsErrors(%s): got err == nil, want err != nil", test.desc)
+			continue
+		}
+		for _, want := range test.wantErr {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("TestBGPNeighborsErrors(%s): got err == %s, want it to contain %q", test.desc, err, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,889 @@
+package junos
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johnsiilver/halfpike"
+	"github.com/johnsiilver/halfpike/parsers/model"
+	"github.com/johnsiilver/halfpike/units"
+)
+
+// Unlimited is used for an MTU or Speed that the device reports as "Unlimited".
+const Unlimited = math.MaxInt32
+
+// Interfaces is the output of "show interfaces" or "show interfaces extensive". It implements
+// halfpike.ParseObject.
+type Interfaces struct {
+	Interfaces []*Interface
+
+	parser *halfpike.Parser
+
+	// These track where we are within an interface record.
+	logical *LogicalInterface
+	family  *Family
+	addr    *Address
+	section section
+	traffic *model.TrafficStats
+}
+
+// section is the multi-line section of an interface record we are in.
+type section int8
+
+const (
+	secNone section = iota
+	// secTraffic indicates we are in the "Traffic statistics:" section.
+	secTraffic
+	// secIgnore indicates we are in a statistics section that we do not record.
+	secIgnore
+	secInputErrors
+	secOutputErrors
+)
+
+// Validate implements halfpike.Validator.Validate().
+func (i *Interfaces) Validate() error {
+	for _, v := range i.Interfaces {
+		if err := v.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Start implements halfpike.ParseObject.Start().
+func (i *Interfaces) Start(ctx context.Context, p *halfpike.Parser) halfpike.ParseFn {
+	i.parser = p
+	return i.findInterface
+}
+
+var phyStart = []string{"Physical", "interface:", halfpike.Skip}
+
+// Physical interface: ge-0/0/1, Administratively down, Physical link is Down
+func (i *Interfaces) findInterface(ctx context.Context, p *halfpike.Parser) halfpike.ParseFn {
+	line, err := p.FindStart(phyStart)
+	if err != nil {
+		if len(i.Interfaces) == 0 {
+			return p.Errorf("could not find a physical interface in the output")
+		}
+		return nil
+	}
+
+	inter := &Interface{}
+	inter.init()
+	i.Interfaces = append(i.Interfaces, inter)
+	i.logical, i.family, i.addr, i.section, i.traffic = nil, nil, nil, secNone, nil
+
+	parts := strings.Split(halfpike.ItemJoin(line, 2, -1), ", ")
+	if len(parts) != 3 {
+		return i.errorf(line, "Physical interface line did not have <name>, <state>, <status>")
+	}
+	inter.Name = parts[0]
+
+	if inter.Location, err = portLocation(inter.Name); err != nil {
+		return i.errorf(line, "%s", err)
+	}
+
+	state, ok := toInterState[parts[1]]
+	if !ok {
+		return i.errorf(line, "interface state %q is not a known state", parts[1])
+	}
+	inter.State = state
+
+	if !strings.HasPrefix(parts[2], "Physical link is ") {
+		return i.errorf(line, "interface status %q is not in a known format", parts[2])
+	}
+	status, ok := toStatus[strings.TrimPrefix(parts[2], "Physical link is ")]
+	if !ok {
+		return i.errorf(line, "interface status %q is not a known status", parts[2])
+	}
+	inter.Status = status
+
+	return i.interAttrs
+}
+
+var toInterState = map[string]model.InterState{
+	"Enabled":               model.IStateEnabled,
+	"Disabled":              model.IStateDisabled,
+	"Administratively down": model.IStateDisabled,
+}
+
+var toStatus = map[string]model.InterStatus{
+	"Up":   model.IStatUp,
+	"Down": model.IStatDown,
+}
+
+// interAttrs decodes all lines belonging to the current interface until we find the next
+// interface or EOF.
+func (i *Interfaces) interAttrs(ctx context.Context, p *halfpike.Parser) halfpike.ParseFn {
+	for {
+		line := p.Next()
+		if p.IsAtStart(line, phyStart) {
+			p.Backup()
+			return i.findInterface
+		}
+
+		handled, err := i.decodeErrors(line)
+		if err != nil {
+			return i.errorf(line, "%s", err)
+		}
+		if !handled {
+			for _, h := range interHandlers {
+				if !p.IsAtStart(line, h.find) {
+					continue
+				}
+				if err := h.decode(i, line); err != nil {
+					return i.errorf(line, "%s", err)
+				}
+				break
+			}
+		}
+
+		if p.EOF(line) {
+			return nil
+		}
+	}
+}
+
+func (i *Interfaces) current() *Interface {
+	if len(i.Interfaces) == 0 {
+		return nil
+	}
+	return i.Interfaces[len(i.Interfaces)-1]
+}
+
+func (i *Interfaces) errorf(line halfpike.Line, s string, a ...interface{}) halfpike.ParseFn {
+	if cur := i.current(); cur != nil && cur.Name != "" {
+		return i.parser.Errorf("[Line %d] interface(%s): %s", line.LineNum, cur.Name, fmt.Sprintf(s, a...))
+	}
+	return i.parser.Errorf("[Line %d]: %s", line.LineNum, fmt.Sprintf(s, a...))
+}
+
+// Interface is a physical network interface.
+type Interface struct {
+	// Name is the name the vendor gives the interface, like ge-10/2/1.
+	Name string
+	// Location is the location of the port in the chassis. It is nil for interfaces
+	// that are not a physical port, like lo0 or ae0.
+	Location *PortLocation
+	// Description is the interface's description.
+	Description string
+	// State is the interface's administrative state.
+	State model.InterState
+	// Status is the interface's operational status.
+	Status model.InterStatus
+	// Index is the interface's index.
+	Index int
+	// SNMPIndex is the interface's SNMP ifIndex.
+	SNMPIndex int
+	// LinkLevel is the link-level type, such as "Ethernet" or "Loopback".
+	LinkLevel string
+	// MTU is the maximum amount of bytes that can be sent in a frame.
+	MTU int
+	// Speed is the interface's speed in bits per second. This is 0 if the speed is not
+	// reported or is "Auto".
+	Speed int64
+	// Loopback indicates if the interface is in loopback mode.
+	Loopback bool
+	// DeviceFlags are the device flags, such as "Present" and "Running".
+	DeviceFlags []string
+	// InterfaceFlags are the interface flags, such as "SNMP-Traps".
+	InterfaceFlags []string
+	// LinkFlags are the link flags.
+	LinkFlags []string
+	// CurrentAddress is the current MAC address.
+	CurrentAddress net.HardwareAddr
+	// HardwareAddress is the burned in MAC address.
+	HardwareAddress net.HardwareAddr
+	// LastFlapped is when the interface last changed status. This is the zero value if it never has.
+	LastFlapped time.Time
+	// InputRate is the input rate.
+	InputRate model.Rate
+	// OutputRate is the output rate.
+	OutputRate model.Rate
+	// Traffic are the traffic statistics. This is nil if the device did not output them.
+	Traffic *model.TrafficStats
+	// InputErrors are the input error counters keyed by name, like "Framing errors". These are
+	// only output with "extensive".
+	InputErrors map[string]int64
+	// OutputErrors are the output error counters keyed by name, like "Carrier transitions". These
+	// are only output with "extensive".
+	OutputErrors map[string]int64
+	// Units are the logical interfaces.
+	Units []*LogicalInterface
+
+	initCalled bool
+}
+
+func (i *Interface) init() {
+	i.Index = -1
+	i.SNMPIndex = -1
+	i.MTU = -1
+	i.initCalled = true
+}
+
+// Validate implements halfpike.Validator.
+func (i *Interface) Validate() error {
+	if !i.initCalled {
+		return fmt.Errorf("an Interface did not have init() called before storing data")
+	}
+
+	if i.Name == "" {
+		return fmt.Errorf("an Interface did not have Name assigned")
+	}
+
+	switch -1 {
+	case i.Index:
+		return fmt.Errorf("Interface(%s): Index was not set", i.Name)
+	case i.SNMPIndex:
+		return fmt.Errorf("Interface(%s): SNMPIndex was not set", i.Name)
+	case i.MTU:
+		return fmt.Errorf("Interface(%s): MTU was not set", i.Name)
+	}
+
+	switch {
+	case i.State == model.IStateUnknown:
+		return fmt.Errorf("Interface(%s): State was not set", i.Name)
+	case i.Status == model.IStatUnknown:
+		return fmt.Errorf("Interface(%s): Status was not set", i.Name)
+	case i.LinkLevel == "":
+		return fmt.Errorf("Interface(%s): LinkLevel was not set", i.Name)
+	}
+
+	for _, u := range i.Units {
+		if err := u.Validate(); err != nil {
+			return fmt.Errorf("Interface(%s): %w", i.Name, err)
+		}
+	}
+	return nil
+}
+
+// PortLocation is the location of a port in a chassis.
+type PortLocation struct {
+	// FPC is the flexible PIC concentrator (line card) slot.
+	FPC int
+	// PIC is the physical interface card slot in the FPC.
+	PIC int
+	// Port is the port on the PIC.
+	Port int
+	// Channel is the channel of a channelized port. This is -1 if the port is not channelized.
+	Channel int
+}
+
+// LogicalInterface is a logical interface (unit) on a physical interface.
+type LogicalInterface struct {
+	// Name is the name of the logical interface, like ge-0/0/0.0.
+	Name string
+	// Unit is the unit number.
+	Unit int
+	// Index is the interface index.
+	Index int
+	// SNMPIndex is the SNMP ifIndex.
+	SNMPIndex int
+	// Description is the logical interface's description.
+	Description string
+	// Flags are the logical interface flags, like "Up" and "SNMP-Traps".
+	Flags []string
+	// VLANTag is the VLAN tag of the unit, like "0x8100.100".
+	VLANTag string
+	// Encapsulation is the encapsulation, like "ENET2".
+	Encapsulation string
+	// Traffic are the traffic statistics. This is nil if the device did not output them.
+	Traffic *model.TrafficStats
+	// Families are the protocol families configured on the unit.
+	Families []*Family
+}
+
+// Validate implements halfpike.Validator.
+func (l *LogicalInterface) Validate() error {
+	if l.Name == "" {
+		return fmt.Errorf("a LogicalInterface did not have Name assigned")
+	}
+	if l.Index == -1 {
+		return fmt.Errorf("LogicalInterface(%s): Index was not set", l.Name)
+	}
+	if l.SNMPIndex == -1 {
+		return fmt.Errorf("LogicalInterface(%s): SNMPIndex was not set", l.Name)
+	}
+	for _, f := range l.Families {
+		if f.MTU == -1 {
+			return fmt.Errorf("LogicalInterface(%s): Family(%s): MTU was not set", l.Name, f.Name)
+		}
+		for _, a := range f.Addresses {
+			if a.Local == nil {
+				return fmt.Errorf("LogicalInterface(%s): Family(%s): an Address did not have Local set", l.Name, f.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Family is a protocol family on a logical interface.
+type Family struct {
+	// Name is the family name, like "inet" or "inet6".
+	Name string
+	// MTU is the protocol MTU.
+	MTU int
+	// Flags are the family's flags.
+	Flags []string
+	// Addresses are the addresses assigned to the family.
+	Addresses []*Address
+}
+
+// Address is an address configured on a protocol family.
+type Address struct {
+	// Flags are the address flags, like "Is-Primary".
+	Flags []string
+	// Destination is the network the address is in. This is nil if not output.
+	Destination *net.IPNet
+	// Local is the local address.
+	Local net.IP
+	// Broadcast is the broadcast address. This is nil if not output or "Unspecified".
+	Broadcast net.IP
+}
+
+// interHandler decodes a line that starts with "find".
+type interHandler struct {
+	find   []string
+	decode func(i *Interfaces, line halfpike.Line) error
+}
+
+// interHandlers are the handlers for lines within an interface record. Lines that do not match a
+// handler are ignored, as Junos adds lines depending on the version and interface type.
+// Order matters, as the first handler that matches is used.
+var interHandlers = []interHandler{
+	{[]string{"Interface", "index:", halfpike.Skip}, decodeInterIndex},
+	{[]string{"Description:", halfpike.Skip}, decodeInterDesc},
+	{[]string{"Link-level", "type:", halfpike.Skip}, decodeLinkLevel},
+	{[]string{"Type:", halfpike.Skip}, decodeLinkLevel},
+	{[]string{"Device", "flags"}, decodePhyFlags(func(i *Interface) *[]string { return &i.DeviceFlags })},
+	{[]string{"Interface", "flags"}, decodePhyFlags(func(i *Interface) *[]string { return &i.InterfaceFlags })},
+	{[]string{"Interface", "flags:"}, decodePhyFlags(func(i *Interface) *[]string { return &i.InterfaceFlags })},
+	{[]string{"Link", "flags"}, decodePhyFlags(func(i *Interface) *[]string { return &i.LinkFlags })},
+	{[]string{"Current", "address:", halfpike.Skip}, decodeMACs},
+	{[]string{"Last", "flapped"}, decodeLastFlapped},
+	{[]string{"Input", "rate"}, decodeRate(func(i *Interface) *model.Rate { return &i.InputRate })},
+	{[]string{"Output", "rate"}, decodeRate(func(i *Interface) *model.Rate { return &i.OutputRate })},
+	{[]string{"Traffic", "statistics:"}, decodeTrafficStart},
+	{[]string{"IPv6", "transit", "statistics:"}, ignoreSection},
+	{[]string{"Local", "statistics:"}, ignoreSection},
+	{[]string{"Transit", "statistics:"}, ignoreSection},
+	{[]string{"Input", "bytes"}, decodeCounter(func(t *model.TrafficStats) *int64 { return &t.InputBytes })},
+	{[]string{"Output", "bytes"}, decodeCounter(func(t *model.TrafficStats) *int64 { return &t.OutputBytes })},
+	{[]string{"Input", "packets"}, decodeCounter(func(t *model.TrafficStats) *int64 { return &t.InputPackets })},
+	{[]string{"Input", "packets:"}, decodeCounter(func(t *model.TrafficStats) *int64 { return &t.InputPackets })},
+	{[]string{"Output", "packets"}, decodeCounter(func(t *model.TrafficStats) *int64 { return &t.OutputPackets })},
+	{[]string{"Output", "packets:"}, decodeCounter(func(t *model.TrafficStats) *int64 { return &t.OutputPackets })},
+	{[]string{"Input", "errors:"}, decodeErrorsStart(secInputErrors)},
+	{[]string{"Output", "errors:"}, decodeErrorsStart(secOutputErrors)},
+	{[]string{"Logical", "interface", halfpike.Skip}, decodeLogical},
+	{[]string{"Flags:"}, decodeFlags},
+	{[]string{"Protocol", halfpike.Skip}, decodeFamily},
+	{[]string{"Addresses,", "Flags:"}, decodeAddress},
+	{[]string{"Addresses"}, decodeAddress},
+	{[]string{"Destination:", halfpike.Skip}, decodeAddressAttrs},
+	{[]string{"Local:", halfpike.Skip}, decodeAddressAttrs},
+}
+
+// Interface index: 148, SNMP ifIndex: 526, Generation: 203
+func decodeInterIndex(i *Interfaces, line halfpike.Line) error {
+	kv := keyVals(halfpike.ItemJoin(line, -1, -1))
+	cur := i.current()
+
+	var err error
+	if cur.Index, err = strconv.Atoi(kv["Interface index"]); err != nil {
+		return fmt.Errorf("Interface index was not an integer: %q", kv["Interface index"])
+	}
+	if cur.SNMPIndex, err = strconv.Atoi(kv["SNMP ifIndex"]); err != nil {
+		return fmt.Errorf("SNMP ifIndex was not an integer: %q", kv["SNMP ifIndex"])
+	}
+	return nil
+}
+
+// Description: uplink to core-1
+func decodeInterDesc(i *Interfaces, line halfpike.Line) error {
+	desc := afterColon(line)
+	if i.logical != nil {
+		i.logical.Description = desc
+		return nil
+	}
+	i.current().Description = desc
+	return nil
+}
+
+// Link-level type: Ethernet, MTU: 1514, MRU: 1522, Speed: 1000mbps, ..., Loopback: Disabled,
+// Type: Loopback, MTU: Unlimited
+func decodeLinkLevel(i *Interfaces, line halfpike.Line) error {
+	if i.logical != nil {
+		return nil
+	}
+	cur := i.current()
+	kv := keyVals(halfpike.ItemJoin(line, -1, -1))
+
+	cur.LinkLevel = kv["Link-level type"]
+	if cur.LinkLevel == "" {
+		cur.LinkLevel = kv["Type"]
+	}
+	if cur.LinkLevel == "" {
+		return fmt.Errorf("did not have a link-level type")
+	}
+
+	mtu, err := toMTU(kv["MTU"])
+	if err != nil {
+		return err
+	}
+	cur.MTU = mtu
+
+	if s, ok := kv["Speed"]; ok {
+		if cur.Speed, err = toSpeed(s); err != nil {
+			return err
+		}
+	}
+
+	switch kv["Loopback"] {
+	case "", "None", "Disabled":
+	case "Enabled", "Local", "Remote":
+		cur.Loopback = true
+	default:
+		return fmt.Errorf("Loopback had unknown value %q", kv["Loopback"])
+	}
+	return nil
+}
+
+// Device flags   : Present Running
+// Interface flags: SNMP-Traps Internal: 0x4000
+func decodePhyFlags(field func(i *Interface) *[]string) func(i *Interfaces, line halfpike.Line) error {
+	return func(i *Interfaces, line halfpike.Line) error {
+		*field(i.current()) = flagList(afterColon(line))
+		return nil
+	}
+}
+
+// Current address: 00:05:86:71:1a:c0, Hardware address: 00:05:86:71:1a:c0
+func decodeMACs(i *Interfaces, line halfpike.Line) error {
+	cur := i.current()
+	kv := keyVals(halfpike.ItemJoin(line, -1, -1))
+
+	var err error
+	if cur.CurrentAddress, err = net.ParseMAC(kv["Current address"]); err != nil {
+		return fmt.Errorf("Current address was not a MAC address: %q", kv["Current address"])
+	}
+	if s, ok := kv["Hardware address"]; ok {
+		if cur.HardwareAddress, err = net.ParseMAC(s); err != nil {
+			return fmt.Errorf("Hardware address was not a MAC address: %q", s)
+		}
+	}
+	return nil
+}
+
+// Last flapped   : 2026-09-01 03:04:05 UTC (6w4d 02:03:04 ago)
+// Last flapped   : Never
+func decodeLastFlapped(i *Interfaces, line halfpike.Line) error {
+	s := afterColon(line)
+	if s == "Never" {
+		return nil
+	}
+
+	// The time starts at the Item after the one ending in ":". Line.Time() uses halfpike.TimeZones,
+	// so a zone like "PDT" has its offset instead of the 0 offset time.Parse() would give it.
+	start := -1
+	for x, item := range line.Items {
+		if strings.HasSuffix(item.Val, ":") {
+			start = x + 1
+			break
+		}
+	}
+	if start < 0 {
+		return fmt.Errorf("Last flapped had unknown format: %q", s)
+	}
+	t, _, err := line.Time(start, "2006-01-02 15:04:05 MST")
+	if err != nil {
+		return fmt.Errorf("Last flapped had unknown time format: %s", err)
+	}
+	i.current().LastFlapped = t
+	return nil
+}
+
+// Input rate     : 1544 bps (2 pps)
+func decodeRate(field func(i *Interface) *model.Rate) func(i *Interfaces, line halfpike.Line) error {
+	return func(i *Interfaces, line halfpike.Line) error {
+		f := strings.Fields(afterColon(line))
+		if len(f) != 4 || f[1] != "bps" || f[3] != "pps)" {
+			return fmt.Errorf("rate had unknown format: %q", afterColon(line))
+		}
+		bps, err := strconv.ParseInt(f[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("rate bps was not an integer: %q", f[0])
+		}
+		pps, err := strconv.ParseInt(strings.TrimPrefix(f[2], "("), 10, 64)
+		if err != nil {
+			return fmt.Errorf("rate pps was not an integer: %q", f[2])
+		}
+		*field(i.current()) = model.Rate{BPS: bps, PPS: pps}
+		return nil
+	}
+}
+
+// Traffic statistics:
+func decodeTrafficStart(i *Interfaces, line halfpike.Line) error {
+	i.section = secTraffic
+	i.traffic = &model.TrafficStats{}
+	if i.logical != nil {
+		i.logical.Traffic = i.traffic
+		return nil
+	}
+	i.current().Traffic = i.traffic
+	return nil
+}
+
+// IPv6 transit statistics:
+func ignoreSection(i *Interfaces, line halfpike.Line) error {
+	i.section = secIgnore
+	return nil
+}
+
+// Input  bytes  :          98765432101           1544000 bps
+// Output packets: 654321
+func decodeCounter(field func(t *model.TrafficStats) *int64) func(i *Interfaces, line halfpike.Line) error {
+	return func(i *Interfaces, line halfpike.Line) error {
+		var t *model.TrafficStats
+		switch i.section {
+		case secIgnore:
+			return nil
+		case secTraffic:
+			t = i.traffic
+		default:
+			// Without "extensive", counters are listed without a "Traffic statistics:" header.
+			if i.logical != nil {
+				if i.logical.Traffic == nil {
+					i.logical.Traffic = &model.TrafficStats{}
+				}
+				t = i.logical.Traffic
+			} else {
+				if i.current().Traffic == nil {
+					i.current().Traffic = &model.TrafficStats{}
+				}
+				t = i.current().Traffic
+			}
+		}
+
+		f := strings.Fields(afterColon(line))
+		if len(f) == 0 {
+			return fmt.Errorf("counter did not have a value")
+		}
+		v, err := strconv.ParseInt(f[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("counter was not an integer: %q", f[0])
+		}
+		*field(t) = v
+
+		// With "extensive", the physical interface's rates follow the counters.
+		if i.section == secTraffic && i.logical == nil && len(f) == 3 {
+			return i.decodeTrafficRate(line.Items[0].Val, f[1], f[2])
+		}
+		return nil
+	}
+}
+
+// decodeTrafficRate decodes a rate that follows a counter in the "Traffic statistics:" section.
+// dir is "Input" or "Output" and unit is "bps" or "pps".
+func (i *Interfaces) decodeTrafficRate(dir, val, unit string) error {
+	cur := i.current()
+	r := &cur.InputRate
+	if dir == "Output" {
+		r = &cur.OutputRate
+	}
+
+	v, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return fmt.Errorf("rate was not an integer: %q", val)
+	}
+	switch unit {
+	case "bps":
+		r.BPS = v
+	case "pps":
+		r.PPS = v
+	default:
+		return fmt.Errorf("rate had unknown unit: %q", unit)
+	}
+	return nil
+}
+
+// Input errors:
+func decodeErrorsStart(sec section) func(i *Interfaces, line halfpike.Line) error {
+	return func(i *Interfaces, line halfpike.Line) error {
+		i.section = sec
+		cur := i.current()
+		switch sec {
+		case secInputErrors:
+			cur.InputErrors = map[string]int64{}
+		case secOutputErrors:
+			cur.OutputErrors = map[string]int64{}
+		}
+		return nil
+	}
+}
+
+// decodeErrors decodes lines within an "Input errors:" or "Output errors:" section, such as:
+// Errors: 1, Drops: 2, Framing errors: 3, Runts: 0, Policed discards: 4, L3 incompletes: 0,
+// If the line is not a list of error counters, the section is ended and handled is false.
+func (i *Interfaces) decodeErrors(line halfpike.Line) (handled bool, err error) {
+	var m map[string]int64
+	switch i.section {
+	case secInputErrors:
+		m = i.current().InputErrors
+	case secOutputErrors:
+		m = i.current().OutputErrors
+	default:
+		return false, nil
+	}
+
+	kv := keyVals(halfpike.ItemJoin(line, -1, -1))
+	counters := make(map[string]int64, len(kv))
+	for k, v := range kv {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			i.section = secNone
+			return false, nil
+		}
+		counters[k] = n
+	}
+	if len(counters) == 0 {
+		i.section = secNone
+		return false, nil
+	}
+	for k, v := range counters {
+		m[k] = v
+	}
+	return true, nil
+}
+
+var logicalRE = regexp.MustCompile(`^Logical interface (?P<name>\S+) \(Index (?P<index>\d+)\) \(SNMP ifIndex (?P<snmp>\d+)\)`)
+
+// Logical interface ge-0/0/0.0 (Index 72) (SNMP ifIndex 573) (Generation 137)
+func decodeLogical(i *Interfaces, line halfpike.Line) error {
+	m, err := halfpike.Match(logicalRE, halfpike.ItemJoin(line, -1, -1))
+	if err != nil {
+		return fmt.Errorf("Logical interface line had an unknown format")
+	}
+
+	l := &LogicalInterface{Name: m["name"]}
+	sp := strings.Split(l.Name, ".")
+	if len(sp) != 2 {
+		return fmt.Errorf("Logical interface name %q did not have a unit", l.Name)
+	}
+	if l.Unit, err = strconv.Atoi(sp[1]); err != nil {
+		return fmt.Errorf("Logical interface name %q did not have an integer unit", l.Name)
+	}
+	// These cannot fail, as the regex only matches digits.
+	l.Index, _ = strconv.Atoi(m["index"])
+	l.SNMPIndex, _ = strconv.Atoi(m["snmp"])
+
+	cur := i.current()
+	cur.Units = append(cur.Units, l)
+	i.logical, i.family, i.addr, i.section = l, nil, nil, secNone
+	return nil
+}
+
+// Flags: Up SNMP-Traps 0x4000 VLAN-Tag [ 0x8100.100 ]  Encapsulation: ENET2
+// Flags: Sendbcast-pkt-to-re
+func decodeFlags(i *Interfaces, line halfpike.Line) error {
+	switch {
+	case i.family != nil:
+		i.family.Flags = flagList(afterColon(line))
+		return nil
+	case i.logical == nil:
+		return fmt.Errorf("found Flags: outside of a logical interface")
+	}
+
+	s := afterColon(line)
+	if x := strings.Index(s, "Encapsulation:"); x != -1 {
+		i.logical.Encapsulation = strings.TrimSpace(s[x+len("Encapsulation:"):])
+		s = s[:x]
+	}
+	if x := strings.Index(s, "VLAN-Tag ["); x != -1 {
+		end := strings.Index(s[x:], "]")
+		if end == -1 {
+			return fmt.Errorf("VLAN-Tag did not have a closing ]")
+		}
+		i.logical.VLANTag = strings.TrimSpace(s[x+len("VLAN-Tag [") : x+end])
+		s = s[:x] + s[x+end+1:]
+	}
+	i.logical.Flags = flagList(s)
+	return nil
+}
+
+// Protocol inet, MTU: 1500
+// Protocol inet, MTU: 9174, Generation: 160, Route table: 0
+func decodeFamily(i *Interfaces, line halfpike.Line) error {
+	if i.logical == nil {
+		return fmt.Errorf("found Protocol outside of a logical interface")
+	}
+
+	s := halfpike.ItemJoin(line, 1, -1)
+	x := strings.Index(s, ",")
+	if x == -1 {
+		return fmt.Errorf("Protocol line had unknown format")
+	}
+	f := &Family{Name: s[:x]}
+
+	mtu, err := toMTU(keyVals(s[x+1:])["MTU"])
+	if err != nil {
+		return fmt.Errorf("Protocol %s: %s", f.Name, err)
+	}
+	f.MTU = mtu
+
+	i.logical.Families = append(i.logical.Families, f)
+	i.family, i.addr, i.section = f, nil, secNone
+	return nil
+}
+
+// Addresses, Flags: Is-Preferred Is-Primary
+func decodeAddress(i *Interfaces, line halfpike.Line) error {
+	if i.family == nil {
+		return fmt.Errorf("found Addresses outside of a protocol family")
+	}
+
+	a := &Address{}
+	if len(line.Items) > 2 && line.Items[1].Val == "Flags:" {
+		a.Flags = flagList(halfpike.ItemJoin(line, 2, -1))
+	}
+	i.family.Addresses = append(i.family.Addresses, a)
+	i.addr = a
+	return nil
+}
+
+// Destination: 10.0.0.0/30, Local: 10.0.0.1, Broadcast: 10.0.0.3
+// Local: 192.0.2.1
+func decodeAddressAttrs(i *Interfaces, line halfpike.Line) error {
+	if i.addr == nil {
+		return fmt.Errorf("found address information outside of Addresses")
+	}
+
+	kv := keyVals(halfpike.ItemJoin(line, -1, -1))
+	if s, ok := kv["Destination"]; ok {
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return fmt.Errorf("Destination was not a valid network: %q", s)
+		}
+		i.addr.Destination = n
+	}
+	if s, ok := kv["Local"]; ok {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return fmt.Errorf("Local was not a valid IP: %q", s)
+		}
+		i.addr.Local = ip
+	}
+	if s, ok := kv["Broadcast"]; ok && s != "Unspecified" {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return fmt.Errorf("Broadcast was not a valid IP: %q", s)
+		}
+		i.addr.Broadcast = ip
+	}
+	return nil
+}
+
+var portRE = regexp.MustCompile(`^[a-z]+-(?P<fpc>\d+)/(?P<pic>\d+)/(?P<port>\d+)(:(?P<channel>\d+))?$`)
+
+// portLocation returns the PortLocation for a name like ge-3/0/2 or xe-0/0/0:1. If the name
+// is not for a physical port, such as lo0, nil is returned.
+func portLocation(name string) (*PortLocation, error) {
+	if !portRE.MatchString(name) {
+		return nil, nil
+	}
+	m, err := halfpike.Match(portRE, name)
+	if err != nil {
+		return nil, fmt.Errorf("error disecting the interface name(%s): %s", name, err)
+	}
+
+	loc := &PortLocation{Channel: -1}
+	fields := map[string]*int{"fpc": &loc.FPC, "pic": &loc.PIC, "port": &loc.Port, "channel": &loc.Channel}
+	for k, v := range m {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("could not convert value for %s(%s) to an integer", k, v)
+		}
+		*fields[k] = n
+	}
+	return loc, nil
+}
+
+func toMTU(s string) (int, error) {
+	switch s {
+	case "":
+		return 0, fmt.Errorf("MTU was not found")
+	case "Unlimited":
+		return Unlimited, nil
+	}
+	mtu, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("MTU did not seem to be a valid integer: %s", s)
+	}
+	return mtu, nil
+}
+
+func toSpeed(s string) (int64, error) {
+	switch s {
+	case "Auto":
+		return 0, nil
+	case "Unlimited":
+		return Unlimited, nil
+	}
+
+	bits, err := units.ParseBitRate(s)
+	if err != nil {
+		return 0, fmt.Errorf("could not decipher the interface speed: %w", err)
+	}
+	return int64(bits), nil
+}
+
+// afterColon returns the text after the first ':' in a line with spaces trimmed.
+func afterColon(line halfpike.Line) string {
+	s := halfpike.ItemJoin(line, -1, -1)
+	x := strings.Index(s, ":")
+	if x == -1 {
+		return ""
+	}
+	return strings.TrimSpace(s[x+1:])
+}
+
+// keyVals splits "Key: val, Other key: val," into a map.
+func keyVals(s string) map[string]string {
+	m := map[string]string{}
+	for _, kv := range strings.Split(s, ", ") {
+		kv = strings.TrimSuffix(strings.TrimSpace(kv), ",")
+		sp := strings.SplitN(kv, ": ", 2)
+		if len(sp) != 2 {
+			continue
+		}
+		m[sp[0]] = strings.TrimSpace(sp[1])
+	}
+	return m
+}
+
+// flagList splits a list of flags, removing the "Internal: 0x4000" and bare hex values
+// Junos includes in flag lists. A list of "None" returns an empty list.
+func flagList(s string) []string {
+	flags := []string{}
+	f := strings.Fields(s)
+	if len(f) == 1 && f[0] == "None" {
+		return flags
+	}
+	for x := 0; x < len(f); x++ {
+		switch {
+		case f[x] == "Internal:":
+			x++
+		case strings.HasPrefix(f[x], "0x"):
+		default:
+			flags = append(flags, f[x])
+		}
+	}
+	return flags
+}
@@ -0,0 +1,343 @@
+package junos
+
+import (
+	"context"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johnsiilver/halfpike"
+	"github.com/johnsiilver/halfpike/parsers/model"
+)
+
+func TestInterfaces(t *testing.T) {
+	tests := []struct {
+		desc string
+		file string
+		want []*Interface
+	}{
+		{
+			desc: "Physical, down and loopback interfaces",
+			file: "show_interfaces.txt",
+			want: []*Interface{
+				{
+					Name:            "ge-0/0/0",
+					Location:        &PortLocation{FPC: 0, PIC: 0, Port: 0, Channel: -1},
+					Description:     "uplink to core-1",
+					State:           model.IStateEnabled,
+					Status:          model.IStatUp,
+					Index:           148,
+					SNMPIndex:       526,
+					LinkLevel:       "Ethernet",
+					MTU:             1514,
+					Speed:           1000000000,
+					DeviceFlags:     []string{"Present", "Running"},
+					InterfaceFlags:  []string{"SNMP-Traps"},
+					LinkFlags:       []string{},
+					CurrentAddress:  mustMAC("00:05:86:71:1a:c0"),
+					HardwareAddress: mustMAC("00:05:86:71:1a:c0"),
+					LastFlapped:     time.Date(2026, 9, 1, 3, 4, 5, 0, time.UTC),
+					InputRate:       model.Rate{BPS: 1544, PPS: 2},
+					OutputRate:      model.Rate{BPS: 2312, PPS: 3},
+					Units: []*LogicalInterface{
+						{
+							Name:          "ge-0/0/0.0",
+							Unit:          0,
+							Index:         72,
+							SNMPIndex:     573,
+							Description:   "p2p to core-1",
+							Flags:         []string{"Up", "SNMP-Traps"},
+							Encapsulation: "ENET2",
+							Traffic:       &model.TrafficStats{InputPackets: 123456, OutputPackets: 654321},
+							Families: []*Family{
+								{
+									Name:  "inet",
+									MTU:   1500,
+									Flags: []string{"Sendbcast-pkt-to-re"},
+									Addresses: []*Address{
+										{
+											Flags:       []string{"Is-Preferred", "Is-Primary"},
+											Destination: mustCIDR("10.0.0.0/30"),
+											Local:       net.ParseIP("10.0.0.1"),
+											Broadcast:   net.ParseIP("10.0.0.3"),
+										},
+									},
+								},
+								{
+									Name:  "inet6",
+									MTU:   1500,
+									Flags: []string{"Is-Primary"},
+									Addresses: []*Address{
+										{
+											Flags:       []string{"Is-Preferred", "Is-Primary"},
+											Destination: mustCIDR("2001:db8::/64"),
+											Local:       net.ParseIP("2001:db8::1"),
+										},
+										{
+											Flags:       []string{"Is-Preferred"},
+											Destination: mustCIDR("fe80::/64"),
+											Local:       net.ParseIP("fe80::205:86ff:fe71:1ac0"),
+										},
+									},
+								},
+								{
+									Name:      "multiservice",
+									MTU:       Unlimited,
+									Flags:     []string{},
+									Addresses: []*Address{},
+								},
+							},
+						},
+					},
+				},
+				{
+					Name:            "ge-0/0/1",
+					Location:        &PortLocation{FPC: 0, PIC: 0, Port: 1, Channel: -1},
+					State:           model.IStateDisabled,
+					Status:          model.IStatDown,
+					Index:           149,
+					SNMPIndex:       527,
+					LinkLevel:       "Ethernet",
+					MTU:             1514,
+					DeviceFlags:     []string{"Present", "Running", "Down"},
+					InterfaceFlags:  []string{"Hardware-Down", "Down", "SNMP-Traps"},
+					LinkFlags:       []string{},
+					CurrentAddress:  mustMAC("00:05:86:71:1a:c1"),
+					HardwareAddress: mustMAC("00:05:86:71:1a:c1"),
+				},
+				{
+					Name:           "lo0",
+					State:          model.IStateEnabled,
+					Status:         model.IStatUp,
+					Index:          6,
+					SNMPIndex:      6,
+					LinkLevel:      "Loopback",
+					MTU:            Unlimited,
+					DeviceFlags:    []string{"Present", "Running", "Loopback"},
+					InterfaceFlags: []string{"SNMP-Traps"},
+					LinkFlags:      []string{},
+					Traffic:        &model.TrafficStats{InputPackets: 2417, OutputPackets: 2417},
+					Units: []*LogicalInterface{
+						{
+							Name:          "lo0.0",
+							Unit:          0,
+							Index:         69,
+							SNMPIndex:     16,
+							Flags:         []string{"SNMP-Traps"},
+							Encapsulation: "Unspecified",
+							Traffic:       &model.TrafficStats{InputPackets: 12, OutputPackets: 12},
+							Families: []*Family{
+								{
+									Name:  "inet",
+									MTU:   Unlimited,
+									Flags: []string{"Sendbcast-pkt-to-re"},
+									Addresses: []*Address{
+										{
+											Flags: []string{"Is-Default", "Is-Primary"},
+											Local: net.ParseIP("192.0.2.1"),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc: "Extensive output with counters",
+			file: "show_interfaces_extensive.txt",
+			want: []*Interface{
+				{
+					Name:            "xe-1/2/3",
+					Location:        &PortLocation{FPC: 1, PIC: 2, Port: 3, Channel: -1},
+					Description:     "transit-a port 7",
+					State:           model.IStateEnabled,
+					Status:          model.IStatUp,
+					Index:           200,
+					SNMPIndex:       610,
+					LinkLevel:       "Ethernet",
+					MTU:             9192,
+					Speed:           10000000000,
+					DeviceFlags:     []string{"Present", "Running"},
+					InterfaceFlags:  []string{"SNMP-Traps"},
+					LinkFlags:       []string{},
+					CurrentAddress:  mustMAC("2c:6b:f5:a0:b1:03"),
+					HardwareAddress: mustMAC("2c:6b:f5:a0:b1:03"),
+					LastFlapped:     time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+					InputRate:       model.Rate{BPS: 1544000, PPS: 2000},
+					OutputRate:      model.Rate{BPS: 2312000, PPS: 3000},
+					Traffic: &model.TrafficStats{
+						InputBytes:    98765432101,
+						OutputBytes:   12345678901,
+						InputPackets:  87654321,
+						OutputPackets: 23456789,
+					},
+					InputErrors: map[string]int64{
+						"Errors":               1,
+						"Drops":                2,
+						"Framing errors":       3,
+						"Runts":                0,
+						"Policed discards":     4,
+						"L3 incompletes":       0,
+						"L2 channel errors":    0,
+						"L2 mismatch timeouts": 0,
+						"FIFO errors":          0,
+						"Resource errors":      0,
+					},
+					OutputErrors: map[string]int64{
+						"Carrier transitions": 7,
+						"Errors":              0,
+						"Drops":               5,
+						"Collisions":          0,
+						"Aged packets":        0,
+						"FIFO errors":         0,
+						"HS link CRC errors":  0,
+						"MTU errors":          0,
+						"Resource errors":     0,
+					},
+					Units: []*LogicalInterface{
+						{
+							Name:          "xe-1/2/3.100",
+							Unit:          100,
+							Index:         330,
+							SNMPIndex:     700,
+							Flags:         []string{"Up", "SNMP-Traps"},
+							VLANTag:       "0x8100.100",
+							Encapsulation: "ENET2",
+							Traffic: &model.TrafficStats{
+								InputBytes:    12345678,
+								OutputBytes:   87654321,
+								InputPackets:  123456,
+								OutputPackets: 654321,
+							},
+							Families: []*Family{
+								{
+									Name:  "inet",
+									MTU:   9174,
+									Flags: []string{"Sendbcast-pkt-to-re"},
+									Addresses: []*Address{
+										{
+											Flags:       []string{"Is-Preferred", "Is-Primary"},
+											Destination: mustCIDR("198.51.100.0/31"),
+											Local:       net.ParseIP("198.51.100.0"),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		b, err := os.ReadFile("testdata/" + test.file)
+		if err != nil {
+			t.Fatalf("TestInterfaces(%s): got err == %s", test.desc, err)
+		}
+
+		got := &Interfaces{}
+		if err := halfpike.Parse(context.Background(), string(b), got); err != nil {
+			t.Errorf("TestInterfaces(%s): got err == %s", test.desc, err)
+			continue
+		}
+
+		if diff := cmpConfig.Compare(test.want, got.Interfaces); diff != "" {
+			t.Errorf("TestInterfaces(%s): -want/+got:\n%s", test.desc, diff)
+		}
+	}
+}
+
+func TestInterfacesErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		content string
+	}{
+		{
+			desc:    "No interfaces",
+			content: "nothing to see here\n",
+		},
+		{
+			desc:    "Bad state",
+			content: "Physical interface: ge-0/0/0, Sleeping, Physical link is Up\n",
+		},
+		{
+			desc: "Missing required lines",
+			content: `Physical interface: ge-0/0/0, Enabled, Physical link is Up
+  Description: no index
+`,
+		},
+		{
+			desc: "Bad MTU",
+			content: `Physical interface: ge-0/0/0, Enabled, Physical link is Up
+  Interface index: 148, SNMP ifIndex: 526
+  Link-level type: Ethernet, MTU: big, Speed: 1000mbps
+`,
+		},
+	}
+
+	for _, test := range tests {
+		if err := halfpike.Parse(context.Background(), test.content, &Interfaces{}); err == nil {
+			t.Errorf("TestInterfacesErrors(%s): got err == nil, want err != nil", test.desc)
+		}
+	}
+}
+
+func TestInterfacesLastFlappedZone(t *testing.T) {
+	b, err := os.ReadFile("testdata/show_interfaces.txt")
+	if err != nil {
+		t.Fatalf("TestInterfacesLastFlappedZone: got err == %s", err)
+	}
+	const flapped = "2026-09-01 03:04:05 UTC"
+
+	tests := []struct {
+		desc string
+		zone string
+		want time.Time
+		err  bool
+	}{
+		{desc: "UTC", zone: "UTC", want: time.Date(2026, 9, 1, 3, 4, 5, 0, time.UTC)},
+		{desc: "PDT", zone: "PDT", want: time.Date(2026, 9, 1, 10, 4, 5, 0, time.UTC)},
+		{desc: "CET", zone: "CET", want: time.Date(2026, 9, 1, 2, 4, 5, 0, time.UTC)},
+		{desc: "Unknown zone", zone: "XYZ", err: true},
+	}
+
+	for _, test := range tests {
+		content := strings.Replace(string(b), flapped, strings.Replace(flapped, "UTC", test.zone, 1), 1)
+		got := &Interfaces{}
+		err := halfpike.Parse(context.Background(), content, got)
+		switch {
+		case err == nil && test.err:
+			t.Errorf("TestInterfacesLastFlappedZone(%s): got err == nil, want err != nil", test.desc)
+			continue
+		case err != nil && !test.err:
+			t.Errorf("TestInterfacesLastFlappedZone(%s): got err == %s", test.desc, err)
+			continue
+		case err != nil:
+			continue
+		}
+		if lf := got.Interfaces[0].LastFlapped; !lf.Equal(test.want) {
+			t.Errorf("TestInterfacesLastFlappedZone(%s): got %v, want %v", test.desc, lf, test.want)
+		}
+	}
+}
+
+func mustMAC(s string) net.HardwareAddr {
+	mac, err := net.ParseMAC(s)
+	if err != nil {
+		panic(err)
+	}
+	return mac
+}
+
+func mustCIDR(s string) *net.IPNet {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
@@ -0,0 +1,25 @@
+package junos
+
+import "github.com/johnsiilver/halfpike"
+
+// Vendor is the vendor name the parsers in this package are registered under.
+const Vendor = "juniper"
+
+// Register registers the parsers in this package with "r" for all Junos versions.
+func Register(r *halfpike.Registry) error {
+	regs := []struct {
+		command string
+		newFn   func() halfpike.ParseObject
+	}{
+		{"show bgp neighbor", func() halfpike.ParseObject { return &BGPNeighbors{} }},
+		{"show interfaces", func() halfpike.ParseObject { return &Interfaces{} }},
+		{"show interfaces extensive", func() halfpike.ParseObject { return &Interfaces{} }},
+	}
+
+	for _, reg := range regs {
+		if err := r.Register(Vendor, reg.command, "", reg.newFn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
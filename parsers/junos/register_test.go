@@ -0,0 +1,32 @@
+package junos
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/johnsiilver/halfpike"
+)
+
+func TestRegister(t *testing.T) {
+	r := halfpike.NewRegistry()
+	if err := Register(r); err != nil {
+		t.Fatalf("TestRegister: got err == %s", err)
+	}
+
+	b, err := os.ReadFile("testdata/show_interfaces_extensive.txt")
+	if err != nil {
+		panic(err)
+	}
+
+	obj, reg, err := r.Parse(context.Background(), "Juniper", "18.1R3-S2", "show interfaces extensive | no-more", string(b))
+	if err != nil {
+		t.Fatalf("TestRegister: got err == %s", err)
+	}
+	if reg.Command != "show interfaces extensive" {
+		t.Errorf("TestRegister: got Registration.Command %q, want %q", reg.Command, "show interfaces extensive")
+	}
+	if got := len(obj.(*Interfaces).Interfaces); got != 1 {
+		t.Errorf("TestRegister: got %d interfaces, want 1", got)
+	}
+}
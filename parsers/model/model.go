@@ -0,0 +1,113 @@
+// Package model holds the types that are shared between vendor parsers, so that data that means
+// the same thing on different vendors' devices is represented the same way.
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BGPState is the state of the BGP finite state machine for a neighbor.
+type BGPState uint8
+
+// BGP connection states.
+const (
+	NSUnknown     BGPState = 0
+	NSActive      BGPState = 1
+	NSConnect     BGPState = 2
+	NSEstablished BGPState = 3
+	NSIdle        BGPState = 4
+	NSOpenConfirm BGPState = 5
+	NSOpenSent    BGPState = 6
+)
+
+var bgpStateNames = map[BGPState]string{
+	NSUnknown:     "Unknown",
+	NSActive:      "Active",
+	NSConnect:     "Connect",
+	NSEstablished: "Established",
+	NSIdle:        "Idle",
+	NSOpenConfirm: "OpenConfirm",
+	NSOpenSent:    "OpenSent",
+}
+
+// String implements fmt.Stringer.
+func (b BGPState) String() string {
+	if s, ok := bgpStateNames[b]; ok {
+		return s
+	}
+	return fmt.Sprintf("BGPState(%d)", b)
+}
+
+// ParseBGPState converts the name of a BGP state, such as "Established" or "OpenSent", to a
+// BGPState. The comparison is case insensitive. If s is not a known state, false is returned.
+func ParseBGPState(s string) (BGPState, bool) {
+	for k, v := range bgpStateNames {
+		if k != NSUnknown && strings.EqualFold(v, s) {
+			return k, true
+		}
+	}
+	return NSUnknown, false
+}
+
+// InterState is the administrative state of an interface.
+type InterState int8
+
+const (
+	IStateUnknown  InterState = 0
+	IStateEnabled  InterState = 1
+	IStateDisabled InterState = 2
+)
+
+var interStateNames = map[InterState]string{
+	IStateUnknown:  "Unknown",
+	IStateEnabled:  "Enabled",
+	IStateDisabled: "Disabled",
+}
+
+// String implements fmt.Stringer.
+func (i InterState) String() string {
+	if s, ok := interStateNames[i]; ok {
+		return s
+	}
+	return fmt.Sprintf("InterState(%d)", i)
+}
+
+// InterStatus is the operational status of an interface.
+type InterStatus int8
+
+const (
+	IStatUnknown InterStatus = 0
+	IStatUp      InterStatus = 1
+	IStatDown    InterStatus = 2
+)
+
+var interStatusNames = map[InterStatus]string{
+	IStatUnknown: "Unknown",
+	IStatUp:      "Up",
+	IStatDown:    "Down",
+}
+
+// String implements fmt.Stringer.
+func (i InterStatus) String() string {
+	if s, ok := interStatusNames[i]; ok {
+		return s
+	}
+	return fmt.Sprintf("InterStatus(%d)", i)
+}
+
+// Rate is a traffic rate.
+type Rate struct {
+	// BPS is bits per second.
+	BPS int64
+	// PPS is packets per second.
+	PPS int64
+}
+
+// TrafficStats are traffic counters for an interface.
+type TrafficStats struct {
+	InputBytes    int64
+	OutputBytes   int64
+	InputPackets  int64
+	OutputPackets int64
+}
@@ -0,0 +1,24 @@
+package model
+
+import "testing"
+
+func TestParseBGPState(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   BGPState
+		wantOK bool
+	}{
+		{in: "Established", want: NSEstablished, wantOK: true},
+		{in: "OpenSent", want: NSOpenSent, wantOK: true},
+		{in: "idle", want: NSIdle, wantOK: true},
+		{in: "Unknown", want: NSUnknown},
+		{in: "Dancing", want: NSUnknown},
+	}
+
+	for _, test := range tests {
+		got, ok := ParseBGPState(test.in)
+		if ok != test.wantOK || got != test.want {
+			t.Errorf("TestParseBGPState(%s): got (%v, %v), want (%v, %v)", test.in, got, ok, test.want, test.wantOK)
+		}
+	}
+}
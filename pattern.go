@@ -0,0 +1,477 @@
+package halfpike
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Matcher matches zero or more Items in a Pattern. Matchers are created with Lit(), Any(), AnyN(),
+// Rest(), End(), Optional(), OneOf(), Int(), Float(), Re(), Prefix(), Suffix(), Fold() and Capture().
+// A Pattern is also a Matcher, so it can group Matchers, such as in Optional(Pattern{...}).
+type Matcher interface {
+	fmt.Stringer
+
+	// match tries to match the Items at "pos". For each way it can match, it calls next() with the
+	// position after the match and returns true if next() does.
+	match(s *matchState, pos int, next func(pos int) bool) bool
+}
+
+// Pattern is a sequence of Matchers that must match the Items at the start of a Line. It is the
+// more expressive version of the []string given to FindStart() and IsAtStart():
+//
+//	pat := halfpike.Pattern{
+//		halfpike.Lit("Physical"), halfpike.Lit("interface:"), halfpike.Capture("name", halfpike.Suffix(",")),
+//		halfpike.Rest(),
+//	}
+//
+// Like IsAtStart(), a Pattern only needs to match the start of a Line unless it ends with End() or Rest().
+// Matchers that can match a different number of Items, such as Optional() and Rest(), are tried each
+// way until the rest of the Pattern matches.
+//
+// FindStartPattern(), FindUntilPattern() and IsAtStartPattern() are the Pattern versions of FindStart(),
+// FindUntil() and IsAtStart(). There are no Pattern versions of FindREStart() and IsREStart(), as Re()
+// matches an Item with a regexp.
+type Pattern []Matcher
+
+// FromStrings converts "find", as given to FindStart(), into a Pattern. Skip is converted to Any().
+// A Pattern does not match the end of a Line, so unlike Skip in IsAtStart(), where a trailing Skip
+// can match a Line's ItemEOL, Any() needs an Item: IsAtStart() with []string{"Flags:", Skip} matches
+// "Flags:", but FromStrings() of it does not.
+func FromStrings(find []string) Pattern {
+	pat := make(Pattern, 0, len(find))
+	for _, f := range find {
+		if f == Skip {
+			pat = append(pat, Any())
+			continue
+		}
+		pat = append(pat, Lit(f))
+	}
+	return pat
+}
+
+// reStrings converts "find", as given to FindREStart(), into a Pattern.
+func reStrings(find []*regexp.Regexp) Pattern {
+	pat := make(Pattern, 0, len(find))
+	for _, re := range find {
+		pat = append(pat, Re(re))
+	}
+	return pat
+}
+
+// Captures are the Items captured by Capture() Matchers, by name. If a name is captured more
+// than once, the Items are appended.
+type Captures map[string][]Item
+
+// Item returns the first Item captured by "name".
+func (c Captures) Item(name string) (Item, bool) {
+	items := c[name]
+	if len(items) == 0 {
+		return Item{}, false
+	}
+	return items[0], true
+}
+
+// Text returns the values of the Items captured by "name" joined by a space.
+func (c Captures) Text(name string) string {
+	vals := make([]string, 0, len(c[name]))
+	for _, item := range c[name] {
+		vals = append(vals, item.Val)
+	}
+	return strings.Join(vals, " ")
+}
+
+// Match returns the Captures if "line" starts with a match of the Pattern.
+func (pat Pattern) Match(line Line) (Captures, bool) {
+	s := &matchState{items: lineItems(line)}
+	if !pat.match(s, 0, func(int) bool { return true }) {
+		return nil, false
+	}
+
+	c := Captures{}
+	for _, cp := range s.caps {
+		c[cp.name] = append(c[cp.name], s.items[cp.start:cp.end]...)
+	}
+	return c, true
+}
+
+// String implements fmt.Stringer.
+func (pat Pattern) String() string {
+	s := make([]string, 0, len(pat))
+	for _, m := range pat {
+		s = append(s, m.String())
+	}
+	return "[" + strings.Join(s, " ") + "]"
+}
+
+func (pat Pattern) match(s *matchState, pos int, next func(pos int) bool) bool {
+	if len(pat) == 0 {
+		return next(pos)
+	}
+	return pat[0].match(s, pos, func(pos int) bool {
+		return pat[1:].match(s, pos, next)
+	})
+}
+
+// lineItems returns the Items in "line" without the final ItemEOL or ItemEOF.
+func lineItems(line Line) []Item {
+	items := line.Items
+	if n := len(items); n > 0 && (items[n-1].Type == ItemEOL || items[n-1].Type == ItemEOF) {
+		items = items[:n-1]
+	}
+	return items
+}
+
+// matchState is the state of matching a Pattern against a Line.
+type matchState struct {
+	items []Item
+	caps  []capture
+}
+
+type capture struct {
+	name       string
+	start, end int
+}
+
+// itemMatcher matches a single Item that satisfies "ok".
+type itemMatcher struct {
+	desc string
+	ok   func(Item) bool
+}
+
+func (m itemMatcher) String() string {
+	return m.desc
+}
+
+func (m itemMatcher) match(s *matchState, pos int, next func(pos int) bool) bool {
+	if pos >= len(s.items) || !m.ok(s.items[pos]) {
+		return false
+	}
+	return next(pos + 1)
+}
+
+// Lit matches an Item whose value is "s".
+func Lit(s string) Matcher {
+	return itemMatcher{desc: fmt.Sprintf("%q", s), ok: func(i Item) bool { return i.Val == s }}
+}
+
+// Any matches any single Item. This is the same as Skip.
+func Any() Matcher {
+	return itemMatcher{desc: "Any", ok: func(Item) bool { return true }}
+}
+
+// Int matches an ItemInt.
+func Int() Matcher {
+	return itemMatcher{desc: "Int", ok: func(i Item) bool { return i.Type == ItemInt }}
+}
+
+// Float matches an ItemFloat or an ItemInt.
+func Float() Matcher {
+	return itemMatcher{desc: "Float", ok: func(i Item) bool { return i.Type == ItemFloat || i.Type == ItemInt }}
+}
+
+// Re matches an Item whose value matches "re". Use ^ and $ in "re" to match the whole value.
+func Re(re *regexp.Regexp) Matcher {
+	return itemMatcher{desc: fmt.Sprintf("Re(%s)", re), ok: func(i Item) bool { return re.MatchString(i.Val) }}
+}
+
+// Prefix matches an Item whose value starts with "s".
+func Prefix(s string) Matcher {
+	return itemMatcher{desc: fmt.Sprintf("Prefix(%q)", s), ok: func(i Item) bool { return strings.HasPrefix(i.Val, s) }}
+}
+
+// Suffix matches an Item whose value ends with "s".
+func Suffix(s string) Matcher {
+	return itemMatcher{desc: fmt.Sprintf("Suffix(%q)", s), ok: func(i Item) bool { return strings.HasSuffix(i.Val, s) }}
+}
+
+// Fold matches an Item whose value is "s", ignoring case.
+func Fold(s string) Matcher {
+	return itemMatcher{desc: fmt.Sprintf("Fold(%q)", s), ok: func(i Item) bool { return strings.EqualFold(i.Val, s) }}
+}
+
+type anyN int
+
+func (a anyN) String() string {
+	return fmt.Sprintf("AnyN(%d)", int(a))
+}
+
+func (a anyN) match(s *matchState, pos int, next func(pos int) bool) bool {
+	if pos+int(a) > len(s.items) {
+		return false
+	}
+	return next(pos + int(a))
+}
+
+// AnyN matches any "n" Items. It panics if "n" is negative.
+func AnyN(n int) Matcher {
+	if n < 0 {
+		panic(fmt.Sprintf("halfpike.AnyN(%d): n must not be negative", n))
+	}
+	return anyN(n)
+}
+
+type rest struct{}
+
+func (rest) String() string {
+	return "Rest"
+}
+
+func (rest) match(s *matchState, pos int, next func(pos int) bool) bool {
+	for end := len(s.items); end >= pos; end-- {
+		if next(end) {
+			return true
+		}
+	}
+	return false
+}
+
+// Rest matches zero or more Items. It matches as many as it can while letting the rest of the
+// Pattern match, so Capture("desc", Rest()) at the end of a Pattern captures the rest of the Line.
+func Rest() Matcher {
+	return rest{}
+}
+
+type end struct{}
+
+func (end) String() string {
+	return "End"
+}
+
+func (end) match(s *matchState, pos int, next func(pos int) bool) bool {
+	return pos == len(s.items) && next(pos)
+}
+
+// End matches the end of the Line, so that a Pattern must match the whole Line.
+func End() Matcher {
+	return end{}
+}
+
+type optional struct {
+	m Matcher
+}
+
+func (o optional) String() string {
+	return fmt.Sprintf("Optional(%s)", o.m)
+}
+
+func (o optional) match(s *matchState, pos int, next func(pos int) bool) bool {
+	return o.m.match(s, pos, next) || next(pos)
+}
+
+// Optional matches "m" or nothing.
+func Optional(m Matcher) Matcher {
+	return optional{m: m}
+}
+
+type oneOf []Matcher
+
+func (o oneOf) String() string {
+	s := make([]string, 0, len(o))
+	for _, m := range o {
+		s = append(s, m.String())
+	}
+	return "OneOf(" + strings.Join(s, ", ") + ")"
+}
+
+func (o oneOf) match(s *matchState, pos int, next func(pos int) bool) bool {
+	for _, m := range o {
+		if m.match(s, pos, next) {
+			return true
+		}
+	}
+	return false
+}
+
+// OneOf matches the first of "ms" that allows the rest of the Pattern to match.
+func OneOf(ms ...Matcher) Matcher {
+	return oneOf(ms)
+}
+
+type captureMatcher struct {
+	name string
+	m    Matcher
+}
+
+func (c captureMatcher) String() string {
+	return fmt.Sprintf("Capture(%q, %s)", c.name, c.m)
+}
+
+func (c captureMatcher) match(s *matchState, pos int, next func(pos int) bool) bool {
+	return c.m.match(s, pos, func(end int) bool {
+		s.caps = append(s.caps, capture{name: c.name, start: pos, end: end})
+		if next(end) {
+			return true
+		}
+		s.caps = s.caps[:len(s.caps)-1]
+		return false
+	})
+}
+
+type contains struct {
+	pat Pattern
+}
+
+func (c contains) String() string {
+	return fmt.Sprintf("Contains(%s)", c.pat)
+}
+
+func (c contains) match(s *matchState, pos int, next func(pos int) bool) bool {
+	for start := pos; start <= len(s.items); start++ {
+		if c.pat.match(s, start, next) {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains returns a Pattern that matches "pat" anywhere in a Line instead of only at the start.
+// The first (leftmost) match is used.
+func Contains(pat Pattern) Pattern {
+	return Pattern{contains{pat: pat}}
+}
+
+type endsWith struct {
+	pat Pattern
+}
+
+func (e endsWith) String() string {
+	return fmt.Sprintf("EndsWith(%s)", e.pat)
+}
+
+func (e endsWith) match(s *matchState, pos int, next func(pos int) bool) bool {
+	for start := pos; start <= len(s.items); start++ {
+		ok := e.pat.match(s, start, func(end int) bool {
+			return end == len(s.items) && next(end)
+		})
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// EndsWith returns a Pattern that matches "pat" at the end of a Line.
+func EndsWith(pat Pattern) Pattern {
+	return Pattern{endsWith{pat: pat}}
+}
+
+type exact struct {
+	pat Pattern
+}
+
+func (e exact) String() string {
+	return fmt.Sprintf("Exact(%s)", e.pat)
+}
+
+func (e exact) match(s *matchState, pos int, next func(pos int) bool) bool {
+	return e.pat.match(s, pos, func(end int) bool {
+		return end == len(s.items) && next(end)
+	})
+}
+
+// Exact returns a Pattern that must match all the Items in a Line, so FromStrings([]string{"Flags:", Skip})
+// matches "Flags: <Sync>" but not "Flags: <Sync> <Passive>". This is the same as adding End() to "pat".
+func Exact(pat Pattern) Pattern {
+	return Pattern{exact{pat: pat}}
+}
+
+// Capture records the Items matched by "m" in Captures under "name".
+func Capture(name string, m Matcher) Matcher {
+	return captureMatcher{name: name, m: m}
+}
+
+// IsAtStartPattern checks to see that "pat" matches the beginning of "line" and returns the Captures.
+func (p *Parser) IsAtStartPattern(line Line, pat Pattern) (Captures, bool) {
+	return pat.Match(line)
+}
+
+// FindStartPattern is like FindStart(), but finds a Line that "pat" matches. The Line and its
+// Captures are returned.
+func (p *Parser) FindStartPattern(pat Pattern) (Line, Captures, error) {
+	for line := p.Next(); true; line = p.Next() {
+		if c, ok := pat.Match(line); ok {
+			return line, c, nil
+		}
+
+		if p.EOF(line) {
+			return Line{}, nil, eofError("FindStartPattern", line, pat)
+		}
+	}
+	panic("FindStartPattern() escaped for loop without returning")
+}
+
+// FindUntilPattern is like FindUntil(), but uses Patterns. If "find" is matched, the Line and its
+// Captures are returned. If "until" is matched, Backup() is called and untilFound is true.
+func (p *Parser) FindUntilPattern(find, until Pattern) (matchFound Line, c Captures, untilFound bool, err error) {
+	for line := p.Next(); true; line = p.Next() {
+		if c, ok := find.Match(line); ok {
+			return line, c, false, nil
+		}
+		if _, ok := until.Match(line); ok {
+			p.Backup()
+			return Line{}, nil, true, nil
+		}
+
+		if p.EOF(line) {
+			return Line{}, nil, false, eofError("FindUntilPattern", line, find)
+		}
+	}
+	panic("FindUntilPattern() escaped for loop without returning")
+}
+
+// FindAny is like FindStartPattern(), but finds the first Line that matches any of "patterns". The index
+// of the Pattern that matched is returned with the Line and its Captures. If a Line matches more than one
+// Pattern, the first is used.
+func (p *Parser) FindAny(patterns ...Pattern) (line Line, index int, c Captures, err error) {
+	if len(patterns) == 0 {
+		return Line{}, -1, nil, fmt.Errorf("cannot pass no Patterns to FindAny()")
+	}
+
+	for line := p.Next(); true; line = p.Next() {
+		for i, pat := range patterns {
+			if c, ok := pat.Match(line); ok {
+				return line, i, c, nil
+			}
+		}
+
+		if p.EOF(line) {
+			return Line{}, -1, nil, eofError("FindAny", line, patterns...)
+		}
+	}
+	panic("FindAny() escaped for loop without returning")
+}
+
+// ErrEOF is returned (wrapped in an *EOFError) by the Find methods when the end of the file is
+// reached without finding a match.
+var ErrEOF = errors.New("end of file reached")
+
+// EOFError is returned by the Find methods, such as FindStart() and FindAny(), when the end of the
+// file is reached without finding a match.
+type EOFError struct {
+	// Func is the Find method that was called, such as "FindStart".
+	Func string
+	// LineNum is the Line.LineNum of the last line.
+	LineNum int
+	// Find describes what was being searched for.
+	Find string
+}
+
+// Error implements error.Error().
+func (e *EOFError) Error() string {
+	return fmt.Sprintf("[Line %d]: %s: %s without finding %s", e.LineNum, e.Func, ErrEOF, e.Find)
+}
+
+// Unwrap returns ErrEOF.
+func (e *EOFError) Unwrap() error {
+	return ErrEOF
+}
+
+func eofError(fn string, line Line, patterns ...Pattern) error {
+	find := make([]string, 0, len(patterns))
+	for _, pat := range patterns {
+		find = append(find, pat.String())
+	}
+	return &EOFError{Func: fn, LineNum: line.LineNum, Find: strings.Join(find, " or ")}
+}
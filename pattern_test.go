@@ -0,0 +1,408 @@
+package halfpike
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+// firstLine returns the first Line lexed from "s".
+func firstLine(t *testing.T, s string) Line {
+	t.Helper()
+
+	p, err := newParser(context.Background(), s)
+	if err != nil {
+		t.Fatalf("newParser(%q): got err == %s", s, err)
+	}
+	go p.lex.run()
+	defer p.Close()
+	return p.Next()
+}
+
+func TestPatternMatch(t *testing.T) {
+	tests := []struct {
+		desc    string
+		line    string
+		pat     Pattern
+		want    Captures
+		noMatch bool
+	}{
+		{
+			desc: "Literals match the start of the line",
+			line: "Physical interface: ge-3/0/2, Enabled, Physical link is Up\n",
+			pat:  Pattern{Lit("Physical"), Lit("interface:")},
+			want: Captures{},
+		},
+		{
+			desc:    "Literal mismatch",
+			line:    "Logical interface ge-3/0/2.0\n",
+			pat:     Pattern{Lit("Physical")},
+			noMatch: true,
+		},
+		{
+			desc:    "Pattern longer than the line",
+			line:    "Physical interface:\n",
+			pat:     Pattern{Lit("Physical"), Lit("interface:"), Any()},
+			noMatch: true,
+		},
+		{
+			desc: "Capture with Suffix and Rest",
+			line: "Physical interface: ge-3/0/2, Enabled, Physical link is Up\n",
+			pat: Pattern{
+				Lit("Physical"), Lit("interface:"), Capture("name", Suffix(",")), Capture("state", Suffix(",")),
+				Capture("rest", Rest()),
+			},
+			want: Captures{
+				"name":  {{Type: ItemText, Val: "ge-3/0/2,"}},
+				"state": {{Type: ItemText, Val: "Enabled,"}},
+				"rest": {
+					{Type: ItemText, Val: "Physical"},
+					{Type: ItemText, Val: "link"},
+					{Type: ItemText, Val: "is"},
+					{Type: ItemText, Val: "Up"},
+				},
+			},
+		},
+		{
+			desc: "Rest backtracks to let the Pattern match",
+			line: "Description: uplink to core router\n",
+			pat:  Pattern{Lit("Description:"), Capture("desc", Rest()), Capture("last", Any()), End()},
+			want: Captures{
+				"desc": {
+					{Type: ItemText, Val: "uplink"},
+					{Type: ItemText, Val: "to"},
+					{Type: ItemText, Val: "core"},
+				},
+				"last": {{Type: ItemText, Val: "router"}},
+			},
+		},
+		{
+			desc:    "End requires the whole line",
+			line:    "MTU: 1514 bytes\n",
+			pat:     Pattern{Lit("MTU:"), Int(), End()},
+			noMatch: true,
+		},
+		{
+			desc: "Int does not match an item with trailing punctuation",
+			line: "Link-level type: Ethernet, MTU: 1514, Speed: 1000mbps\n",
+			pat: Pattern{
+				Lit("Link-level"), Lit("type:"), Capture("type", Prefix("Eth")), Fold("mtu:"),
+				Capture("mtu", Int()),
+			},
+			noMatch: true,
+		},
+		{
+			desc: "Optional absent with Fold and Int",
+			line: "Link-level type: MTU: 1514\n",
+			pat: Pattern{
+				Lit("Link-level"), Lit("type:"), Optional(Capture("type", Prefix("Eth"))), Fold("mtu:"),
+				Capture("mtu", Int()),
+			},
+			want: Captures{
+				"mtu": {{Type: ItemInt, Val: "1514"}},
+			},
+		},
+		{
+			desc: "Optional group",
+			line: "Link-level type: Ethernet, MTU: 1514\n",
+			pat: Pattern{
+				Lit("Link-level"), Lit("type:"), Optional(Pattern{Capture("type", Suffix(",")), Lit("MTU:")}),
+				Capture("mtu", Int()),
+			},
+			want: Captures{
+				"type": {{Type: ItemText, Val: "Ethernet,"}},
+				"mtu":  {{Type: ItemInt, Val: "1514"}},
+			},
+		},
+		{
+			desc: "OneOf tries each alternative",
+			line: "Input rate : 3.5 bps\n",
+			pat: Pattern{
+				OneOf(Lit("Output"), Lit("Input")), Lit("rate"), Lit(":"), Capture("rate", Float()),
+				Capture("unit", Re(regexp.MustCompile(`^[kmg]?bps$`))),
+			},
+			want: Captures{
+				"rate": {{Type: ItemFloat, Val: "3.5"}},
+				"unit": {{Type: ItemText, Val: "bps"}},
+			},
+		},
+		{
+			desc: "Captures are undone when backtracking",
+			line: "a b c\n",
+			pat:  Pattern{OneOf(Pattern{Capture("x", Lit("a")), Lit("c")}, Capture("y", AnyN(2))), Lit("c")},
+			want: Captures{
+				"y": {{Type: ItemText, Val: "a"}, {Type: ItemText, Val: "b"}},
+			},
+		},
+		{
+			desc: "Contains matches mid-line",
+			line: "Local Address: 10.10.10.1+65406 Holdtime: 90 Preference: 170\n",
+			pat:  Contains(Pattern{Lit("Holdtime:"), Capture("hold", Int())}),
+			want: Captures{
+				"hold": {{Type: ItemInt, Val: "90"}},
+			},
+		},
+		{
+			desc: "Contains uses the leftmost match",
+			line: "a 1 b 2\n",
+			pat:  Contains(Pattern{Capture("n", Int())}),
+			want: Captures{
+				"n": {{Type: ItemInt, Val: "1"}},
+			},
+		},
+		{
+			desc:    "Contains no match",
+			line:    "Local Address: 10.10.10.1+65406 Preference: 170\n",
+			pat:     Contains(Pattern{Lit("Holdtime:")}),
+			noMatch: true,
+		},
+		{
+			desc: "EndsWith",
+			line: "Last flap event: RecvNotify\n",
+			pat:  EndsWith(Pattern{Lit("event:"), Capture("event", Any())}),
+			want: Captures{
+				"event": {{Type: ItemText, Val: "RecvNotify"}},
+			},
+		},
+		{
+			desc:    "EndsWith not at the end",
+			line:    "Last flap event: RecvNotify\n",
+			pat:     EndsWith(Pattern{Lit("event:")}),
+			noMatch: true,
+		},
+		{
+			desc: "Exact",
+			line: "Flags: <Sync>\n",
+			pat:  Exact(FromStrings([]string{"Flags:", Skip})),
+			want: Captures{},
+		},
+		{
+			desc:    "Exact with extra items",
+			line:    "Flags: <Sync> <Passive>\n",
+			pat:     Exact(FromStrings([]string{"Flags:", Skip})),
+			noMatch: true,
+		},
+		{
+			desc: "FromStrings converts Skip",
+			line: "Send state: in sync\n",
+			pat:  FromStrings([]string{"Send", "state:", Skip, "sync"}),
+			want: Captures{},
+		},
+	}
+
+	for _, test := range tests {
+		line := firstLine(t, test.line)
+		got, ok := test.pat.Match(line)
+		switch {
+		case ok && test.noMatch:
+			t.Errorf("TestPatternMatch(%s): got match, want no match", test.desc)
+			continue
+		case !ok && !test.noMatch:
+			t.Errorf("TestPatternMatch(%s): got no match for %s, want match", test.desc, test.pat)
+			continue
+		case !ok:
+			continue
+		}
+		if diff := pretty.Compare(test.want, got); diff != "" {
+			t.Errorf("TestPatternMatch(%s): -want/+got:\n%s", test.desc, diff)
+		}
+	}
+}
+
+func TestAnyNNegative(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("TestAnyNNegative: AnyN(-1) did not panic")
+		}
+	}()
+	AnyN(-1)
+}
+
+// TestFromStringsEOL covers where FromStrings() differs from IsAtStart(): a trailing Skip matches
+// the ItemEOL of a Line, but Any() does not.
+func TestFromStringsEOL(t *testing.T) {
+	find := []string{"Flags:", Skip}
+	line := firstLine(t, "Flags:\n")
+
+	if !(&Parser{}).IsAtStart(line, find) {
+		t.Errorf("TestFromStringsEOL: IsAtStart() got false, want true")
+	}
+	if _, ok := FromStrings(find).Match(line); ok {
+		t.Errorf("TestFromStringsEOL: FromStrings().Match() got true, want false")
+	}
+}
+
+func TestCapturesText(t *testing.T) {
+	c := Captures{"desc": {{Val: "uplink"}, {Val: "to"}, {Val: "core"}}}
+
+	if got := c.Text("desc"); got != "uplink to core" {
+		t.Errorf("TestCapturesText: got %q, want %q", got, "uplink to core")
+	}
+	if item, ok := c.Item("desc"); !ok || item.Val != "uplink" {
+		t.Errorf("TestCapturesText: Item() got (%v, %v), want (uplink, true)", item, ok)
+	}
+	if _, ok := c.Item("missing"); ok {
+		t.Errorf("TestCapturesText: Item(missing) got ok == true, want false")
+	}
+}
+
+func TestFindPattern(t *testing.T) {
+	p, err := newParser(context.Background(), showBGPNeighbor)
+	if err != nil {
+		panic(err)
+	}
+	go p.lex.run()
+	defer p.Close()
+
+	peer := Pattern{Lit("Peer:"), Capture("peer", Any()), Lit("AS"), Capture("as", Int())}
+	send := Pattern{Lit("Send"), Lit("state:"), Capture("state", Rest())}
+
+	line, c, err := p.FindStartPattern(peer)
+	if err != nil {
+		t.Fatalf("TestFindPattern(FindStartPattern): got err == %s", err)
+	}
+	if line.Items[0].Val != "Peer:" || c.Text("peer") != "10.10.10.2+179" || c.Text("as") != "22" {
+		t.Errorf("TestFindPattern(FindStartPattern): got (%s, %v)", line.Raw, c)
+	}
+
+	_, c, until, err := p.FindUntilPattern(send, peer)
+	if err != nil {
+		t.Fatalf("TestFindPattern(FindUntilPattern): got err == %s", err)
+	}
+	if until || c.Text("state") != "in sync" {
+		t.Errorf("TestFindPattern(FindUntilPattern): got (%v, %v), want (state: in sync, false)", c, until)
+	}
+
+	_, _, until, err = p.FindUntilPattern(send, peer)
+	if err != nil {
+		t.Fatalf("TestFindPattern(FindUntilPattern next record): got err == %s", err)
+	}
+	if !until {
+		t.Errorf("TestFindPattern(FindUntilPattern next record): got until == false, want true")
+	}
+
+	line, c, err = p.FindStartPattern(peer)
+	if err != nil {
+		t.Fatalf("TestFindPattern(FindStartPattern after Backup): got err == %s", err)
+	}
+	if c, ok := p.IsAtStartPattern(line, peer); !ok || c.Text("peer") != "10.10.10.6+54781" {
+		t.Errorf("TestFindPattern(IsAtStartPattern): got (%v, %v)", c, ok)
+	}
+
+	if _, _, err := p.FindStartPattern(Pattern{Lit("NoSuchLine")}); err == nil {
+		t.Errorf("TestFindPattern(EOF): got err == nil, want err != nil")
+	}
+}
+
+func TestFindAny(t *testing.T) {
+	p, err := newParser(context.Background(), showBGPNeighbor)
+	if err != nil {
+		panic(err)
+	}
+	go p.lex.run()
+	defer p.Close()
+
+	patterns := []Pattern{
+		Contains(Pattern{Lit("Holdtime:"), Capture("hold", Int())}),
+		{Lit("Peer:"), Capture("peer", Any())},
+	}
+
+	tests := []struct {
+		desc      string
+		wantIndex int
+		wantName  string
+		wantVal   string
+	}{
+		{desc: "First peer", wantIndex: 1, wantName: "peer", wantVal: "10.10.10.2+179"},
+		{desc: "First holdtime", wantIndex: 0, wantName: "hold", wantVal: "90"},
+		{desc: "Active holdtime mid-line", wantIndex: 0, wantName: "hold", wantVal: "90"},
+		{desc: "Second peer", wantIndex: 1, wantName: "peer", wantVal: "10.10.10.6+54781"},
+	}
+
+	for _, test := range tests {
+		_, i, c, err := p.FindAny(patterns...)
+		if err != nil {
+			t.Fatalf("TestFindAny(%s): got err == %s", test.desc, err)
+		}
+		if i != test.wantIndex || c.Text(test.wantName) != test.wantVal {
+			t.Errorf("TestFindAny(%s): got (%d, %v), want (%d, %s: %s)", test.desc, i, c, test.wantIndex, test.wantName, test.wantVal)
+		}
+	}
+
+	if _, _, _, err := p.FindAny(); err == nil {
+		t.Errorf("TestFindAny(no patterns): got err == nil, want err != nil")
+	}
+}
+
+func TestFindEOFError(t *testing.T) {
+	tests := []struct {
+		desc string
+		find func(p *Parser) error
+	}{
+		{
+			desc: "FindStart",
+			find: func(p *Parser) error {
+				_, err := p.FindStart([]string{"NoSuchLine"})
+				return err
+			},
+		},
+		{
+			desc: "FindUntil",
+			find: func(p *Parser) error {
+				_, _, err := p.FindUntil([]string{"NoSuchLine"}, []string{"NoSuchLineEither"})
+				return err
+			},
+		},
+		{
+			desc: "FindREStart",
+			find: func(p *Parser) error {
+				_, err := p.FindREStart([]*regexp.Regexp{regexp.MustCompile(`^NoSuchLine$`)})
+				return err
+			},
+		},
+		{
+			desc: "FindStartPattern",
+			find: func(p *Parser) error {
+				_, _, err := p.FindStartPattern(Pattern{Lit("NoSuchLine")})
+				return err
+			},
+		},
+		{
+			desc: "FindUntilPattern",
+			find: func(p *Parser) error {
+				_, _, _, err := p.FindUntilPattern(Pattern{Lit("NoSuchLine")}, Pattern{Lit("NoSuchLineEither")})
+				return err
+			},
+		},
+		{
+			desc: "FindAny",
+			find: func(p *Parser) error {
+				_, _, _, err := p.FindAny(Pattern{Lit("NoSuchLine")}, Contains(Pattern{Lit("NoSuchLineEither")}))
+				return err
+			},
+		},
+	}
+
+	for _, test := range tests {
+		p, err := newParser(context.Background(), "Peer: 10.10.10.2+179\nType: External\n")
+		if err != nil {
+			panic(err)
+		}
+		go p.lex.run()
+
+		err = test.find(p)
+		p.Close()
+
+		if !errors.Is(err, ErrEOF) {
+			t.Errorf("TestFindEOFError(%s): got err == %v, want ErrEOF", test.desc, err)
+			continue
+		}
+		var eofErr *EOFError
+		if !errors.As(err, &eofErr) || eofErr.Func != test.desc || eofErr.LineNum != 2 {
+			t.Errorf("TestFindEOFError(%s): got %#v, want *EOFError{Func: %q, LineNum: 2}", test.desc, eofErr, test.desc)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package halfpike
+
+import (
+	"regexp"
+	"strings"
+)
+
+// SourceLine is a line of content being preprocessed.
+type SourceLine struct {
+	// Text is the text of the line without the "\n".
+	Text string
+	// LineNum is the line number the line had in the original content. This is the same
+	// numbering used by Line.LineNum.
+	LineNum int
+}
+
+// Preprocessor cleans up content before it is lexed. It receives the content's lines and returns
+// the lines that should be lexed. Lines may be changed or removed, but a Preprocessor must keep
+// each line's LineNum so that the Parser can report the line in the original content.
+type Preprocessor func(lines []SourceLine) []SourceLine
+
+// LineMap maps the line numbers of preprocessed content to the line numbers of the original content.
+type LineMap []int
+
+// Original returns the line number in the original content for "lineNum" in the preprocessed content.
+func (m LineMap) Original(lineNum int) int {
+	switch {
+	case lineNum < 0 || len(m) == 0:
+		return lineNum
+	case lineNum >= len(m):
+		// Line numbers past the end, such as the EOF line, follow the last line.
+		return m[len(m)-1] + lineNum - (len(m) - 1)
+	}
+	return m[lineNum]
+}
+
+// WithPreprocessors runs "pre" in order on the content before it is lexed. Line.LineNum for
+// lines given to ParseFns are the line numbers in the original content.
+func WithPreprocessors(pre ...Preprocessor) Option {
+	return func(p *Parser) {
+		p.pre = append(p.pre, pre...)
+	}
+}
+
+// Preprocess runs "pre" in order on "content" and returns the cleaned content and a LineMap from
+// the line numbers in the cleaned content to the line numbers in "content". The cleaned content ends
+// in a "\n" only if "content" does.
+func Preprocess(content string, pre ...Preprocessor) (string, LineMap) {
+	split := strings.Split(content, "\n")
+	trailing := split[len(split)-1] == ""
+	if trailing {
+		split = split[:len(split)-1]
+	}
+
+	lines := make([]SourceLine, 0, len(split))
+	for i, s := range split {
+		lines = append(lines, SourceLine{Text: s, LineNum: i})
+	}
+	for _, p := range pre {
+		lines = p(lines)
+	}
+
+	b := strings.Builder{}
+	m := make(LineMap, 0, len(lines))
+	for i, l := range lines {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(l.Text)
+		m = append(m, l.LineNum)
+	}
+	if trailing && len(lines) > 0 {
+		b.WriteString("\n")
+	}
+	return b.String(), m
+}
+
+// TerminalCleanup is a Preprocessor chain that removes the artifacts of capturing output from an
+// interactive SSH or telnet session: ANSI escapes, backspaces, carriage returns and Junos and IOS
+// pagination markers. Prompts and echoed commands are device specific, so add StripPrompts()
+// or StripEcho() as needed.
+var TerminalCleanup = []Preprocessor{StripANSI, ApplyBackspaces, ApplyCarriageReturns, JunosPager, IOSPager}
+
+// ansiRE matches ANSI CSI sequences (colors, cursor movement, erase line), OSC sequences (window titles)
+// and two character escapes.
+var ansiRE = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(\x07|\x1b\\)|\x1b[@-Z\\-_]`)
+
+// StripANSI removes ANSI escape sequences, such as colors, from each line.
+func StripANSI(lines []SourceLine) []SourceLine {
+	for i, l := range lines {
+		if strings.ContainsRune(l.Text, '\x1b') {
+			lines[i].Text = ansiRE.ReplaceAllString(l.Text, "")
+		}
+	}
+	return lines
+}
+
+// ApplyBackspaces applies backspace characters the way a terminal would, moving the cursor back one
+// character so that following text overwrites it. Pagers use this to erase their prompt.
+func ApplyBackspaces(lines []SourceLine) []SourceLine {
+	for i, l := range lines {
+		if strings.ContainsRune(l.Text, '\b') {
+			lines[i].Text = overstrike(l.Text)
+		}
+	}
+	return lines
+}
+
+// ApplyCarriageReturns removes the "\r" of "\r\n" line endings. A "\r" within a line is applied the
+// way a terminal would, moving the cursor to the start of the line so that following text overwrites it.
+func ApplyCarriageReturns(lines []SourceLine) []SourceLine {
+	for i, l := range lines {
+		text := strings.TrimRight(l.Text, "\r")
+		if strings.ContainsRune(text, '\r') {
+			text = overstrike(text)
+		}
+		lines[i].Text = text
+	}
+	return lines
+}
+
+// overstrike emulates a terminal writing "s", where "\b" moves the cursor back a character and "\r"
+// moves it to the start of the line. Trailing spaces left by erasing text are removed.
+func overstrike(s string) string {
+	var (
+		buf    []rune
+		cursor int
+	)
+	for _, r := range s {
+		switch r {
+		case '\b':
+			if cursor > 0 {
+				cursor--
+			}
+		case '\r':
+			cursor = 0
+		default:
+			if cursor < len(buf) {
+				buf[cursor] = r
+			} else {
+				buf = append(buf, r)
+			}
+			cursor++
+		}
+	}
+	return strings.TrimRight(string(buf), " ")
+}
+
+var (
+	// ---(more)---, ---(more 45%)---
+	junosPagerRE = regexp.MustCompile(`-+\(more( \d+%)?\)-+`)
+	//  --More--
+	iosPagerRE = regexp.MustCompile(` ?--More-- ?`)
+)
+
+// JunosPager removes Junos "---(more)---" and "---(more 45%)---" pagination markers. Lines that
+// only contained a marker are removed.
+func JunosPager(lines []SourceLine) []SourceLine {
+	return removeMarker(lines, junosPagerRE)
+}
+
+// IOSPager removes IOS "--More--" pagination markers. Lines that only contained a marker are removed.
+func IOSPager(lines []SourceLine) []SourceLine {
+	return removeMarker(lines, iosPagerRE)
+}
+
+func removeMarker(lines []SourceLine, re *regexp.Regexp) []SourceLine {
+	out := lines[:0]
+	for _, l := range lines {
+		if re.MatchString(l.Text) {
+			l.Text = re.ReplaceAllString(l.Text, "")
+			if strings.TrimSpace(l.Text) == "" {
+				continue
+			}
+		}
+		out = append(out, l)
+	}
+	return out
+}
+
+var (
+	// JunosPrompt matches a Junos prompt, like "user@router> " or "user@router# ".
+	JunosPrompt = regexp.MustCompile(`^\S+@[\w.\-]+[>#%]`)
+	// JunosBanner matches the lines Junos outputs before a prompt, like "{master:0}" or "[edit]".
+	JunosBanner = regexp.MustCompile(`^(\{\w+(:\d+)?\}|\[edit( [^\]]*)?\])\s*$`)
+	// IOSPrompt matches an IOS prompt, like "router>", "router#" or "router(config-if)#".
+	IOSPrompt = regexp.MustCompile(`^[\w.\-]+(\([\w\-]+\))?[>#]`)
+)
+
+// StripPrompts returns a Preprocessor that removes lines that start with a match for one of "prompts",
+// which also removes the command echoed after the prompt. See JunosPrompt, JunosBanner and IOSPrompt.
+func StripPrompts(prompts ...*regexp.Regexp) Preprocessor {
+	return func(lines []SourceLine) []SourceLine {
+		out := lines[:0]
+	Lines:
+		for _, l := range lines {
+			for _, re := range prompts {
+				if re.MatchString(l.Text) {
+					continue Lines
+				}
+			}
+			out = append(out, l)
+		}
+		return out
+	}
+}
+
+// StripEcho returns a Preprocessor that removes the first line that is "command", which is how a device
+// echoes a command when the prompt is not part of the capture. Whitespace is ignored when comparing.
+func StripEcho(command string) Preprocessor {
+	want := strings.Join(strings.Fields(command), " ")
+	return func(lines []SourceLine) []SourceLine {
+		for i, l := range lines {
+			if strings.Join(strings.Fields(l.Text), " ") == want {
+				return append(lines[:i], lines[i+1:]...)
+			}
+		}
+		return lines
+	}
+}
@@ -0,0 +1,187 @@
+package halfpike
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestPreprocessors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		content string
+		pre     []Preprocessor
+		want    string
+		wantMap LineMap
+	}{
+		{
+			desc:    "ANSI colors and erase line",
+			content: "\x1b[1;32mUp\x1b[0m\n\x1b[KDown\n",
+			pre:     []Preprocessor{StripANSI},
+			want:    "Up\nDown\n",
+			wantMap: LineMap{0, 1},
+		},
+		{
+			desc:    "CRLF and overwritten text",
+			content: "line one\r\nold text\rnew\r\n",
+			pre:     []Preprocessor{ApplyCarriageReturns},
+			want:    "line one\nnew text\n",
+			wantMap: LineMap{0, 1},
+		},
+		{
+			desc:    "IOS pager erased with backspaces",
+			content: "first\n --More-- \b\b\b\b\b\b\b\b\b\b          \b\b\b\b\b\b\b\b\b\bsecond\n",
+			pre:     []Preprocessor{ApplyBackspaces, IOSPager},
+			want:    "first\nsecond\n",
+			wantMap: LineMap{0, 1},
+		},
+		{
+			desc:    "IOS pager without erasure",
+			content: "first\n --More-- \nsecond\n",
+			pre:     []Preprocessor{IOSPager},
+			want:    "first\nsecond\n",
+			wantMap: LineMap{0, 2},
+		},
+		{
+			desc:    "Junos pager",
+			content: "first\n---(more 45%)---\nsecond\n---(more)---\r                \rthird\n",
+			pre:     TerminalCleanup,
+			want:    "first\nsecond\nthird\n",
+			wantMap: LineMap{0, 2, 3},
+		},
+		{
+			desc:    "Junos prompts",
+			content: "\n{master:0}\nuser@router-1.lab> show version \nHostname: router-1\n\n{master:0}\nuser@router-1.lab> \n",
+			pre:     []Preprocessor{StripPrompts(JunosPrompt, JunosBanner)},
+			want:    "\nHostname: router-1\n\n",
+			wantMap: LineMap{0, 3, 4},
+		},
+		{
+			desc:    "IOS prompts",
+			content: "router-1#show version\nCisco IOS Software\nrouter-1(config)#\n",
+			pre:     []Preprocessor{StripPrompts(IOSPrompt)},
+			want:    "Cisco IOS Software\n",
+			wantMap: LineMap{1},
+		},
+		{
+			desc:    "No trailing newline",
+			content: "\x1b[1;32mUp\x1b[0m\n\x1b[KDown",
+			pre:     []Preprocessor{StripANSI},
+			want:    "Up\nDown",
+			wantMap: LineMap{0, 1},
+		},
+		{
+			desc:    "No preprocessors without a trailing newline",
+			content: "Up\n\nDown",
+			want:    "Up\n\nDown",
+			wantMap: LineMap{0, 1, 2},
+		},
+		{
+			desc:    "All lines removed",
+			content: "router-1#\n",
+			pre:     []Preprocessor{StripPrompts(IOSPrompt)},
+			want:    "",
+			wantMap: LineMap{},
+		},
+		{
+			desc:    "Echoed command",
+			content: "show  version\nCisco IOS Software\nshow version\n",
+			pre:     []Preprocessor{StripEcho("show version")},
+			want:    "Cisco IOS Software\nshow version\n",
+			wantMap: LineMap{1, 2},
+		},
+	}
+
+	for _, test := range tests {
+		got, gotMap := Preprocess(test.content, test.pre...)
+		if got != test.want {
+			t.Errorf("TestPreprocessors(%s): got %q, want %q", test.desc, got, test.want)
+		}
+		if diff := pretty.Compare(test.wantMap, gotMap); diff != "" {
+			t.Errorf("TestPreprocessors(%s): LineMap -want/+got:\n%s", test.desc, diff)
+		}
+	}
+}
+
+func TestLineMapOriginal(t *testing.T) {
+	m := LineMap{0, 3, 4}
+	tests := []struct {
+		in, want int
+	}{
+		{0, 0},
+		{1, 3},
+		{2, 4},
+		{3, 5},
+		{-1, -1},
+	}
+	for _, test := range tests {
+		if got := m.Original(test.in); got != test.want {
+			t.Errorf("TestLineMapOriginal(%d): got %d, want %d", test.in, got, test.want)
+		}
+	}
+}
+
+func TestPreprocessTrailingNewline(t *testing.T) {
+	noop := func(lines []SourceLine) []SourceLine { return lines }
+
+	for _, content := range []string{"Physical interface: ge-3/0/2\n  MTU: 1522\n", "Physical interface: ge-3/0/2\n  MTU: 1522"} {
+		var got [2][]Line
+		for i, opts := range [][]Option{nil, {WithPreprocessors(noop)}} {
+			p := NewParser(opts...)
+			if err := p.Reset(content); err != nil {
+				t.Fatalf("TestPreprocessTrailingNewline(%q): got err == %s", content, err)
+			}
+			for {
+				line := p.Next()
+				got[i] = append(got[i], line)
+				if p.EOF(line) {
+					break
+				}
+			}
+			p.Close()
+		}
+		if diff := pretty.Compare(got[0], got[1]); diff != "" {
+			t.Errorf("TestPreprocessTrailingNewline(%q): -without/+with preprocessors:\n%s", content, diff)
+		}
+	}
+}
+
+func TestWithPreprocessors(t *testing.T) {
+	content := "\x1b[1muser@router> show interfaces\x1b[0m\r\n" +
+		"Physical interface: ge-3/0/2, Enabled, Physical link is Up\r\n" +
+		"  MTU: 1522 Load: 0.5\r\n" +
+		"---(more)---\r            \r  Address: 10.0.0.1\r\n" +
+		"  Address: 10.0.0.2\r\n" +
+		"\r\n" +
+		"{master:0}\r\n" +
+		"user@router> \r\n"
+
+	rs := &RecordSet{}
+	rs.StartFn = recordParser(rs)
+	pre := []Option{WithPreprocessors(TerminalCleanup...), WithPreprocessors(StripPrompts(JunosPrompt, JunosBanner))}
+
+	if err := Parse(context.Background(), content, rs, pre...); err != nil {
+		t.Fatalf("TestWithPreprocessors: got err == %s", err)
+	}
+
+	b, err := json.Marshal(rs)
+	if err != nil {
+		t.Fatalf("TestWithPreprocessors: json.Marshal() got err == %s", err)
+	}
+	want := `[{"name":"ge-3/0/2","enabled":true,"stats":{"mtu":1522,"load":0.5},"addresses":["10.0.0.1","10.0.0.2"]}]`
+	if string(b) != want {
+		t.Errorf("TestWithPreprocessors: got:\n%s\nwant:\n%s", b, want)
+	}
+
+	// Line numbers must refer to the original content.
+	v, _ := rs.Records[0].Get("addresses")
+	gotLines := []int{}
+	for _, e := range v.V.([]Value) {
+		gotLines = append(gotLines, e.LineNum)
+	}
+	if diff := pretty.Compare([]int{3, 4}, gotLines); diff != "" {
+		t.Errorf("TestWithPreprocessors(LineNum): -want/+got:\n%s", diff)
+	}
+}
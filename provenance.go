@@ -0,0 +1,131 @@
+package halfpike
+
+import (
+	"fmt"
+	"regexp"
+	"unicode"
+)
+
+// Source describes where in the content a value was decoded from.
+type Source struct {
+	// LineNum is the Line.LineNum the value was decoded from.
+	LineNum int
+	// Index is the index in Line.Items the value was decoded from. This is -1 if the
+	// value did not begin at an Item, such as a regex match in the middle of an Item.
+	Index int
+	// Raw is the text in the content that the value was decoded from.
+	Raw string
+}
+
+// String implements fmt.Stringer.
+func (s Source) String() string {
+	return fmt.Sprintf("[Line %d, Item %d]: %q", s.LineNum, s.Index, s.Raw)
+}
+
+// Provenance maps the path of a value to the Source it was decoded from. Paths
+// are in the form of "name", "name.sub" or "[0].name.sub[1]".
+type Provenance map[string]Source
+
+// Provenance returns where each value assigned through the Parser's helper methods came from.
+// This is nil unless the Parser was created with WithProvenance().
+func (p *Parser) Provenance() Provenance {
+	return p.prov
+}
+
+// SetSource records that the value stored at "path" was decoded from line.Items[index]. If
+// index is -1, the source is recorded as the entire line. This is a no-op if the Parser is not
+// recording provenance. Use this when storing values without using one of the Parser's helpers.
+func (p *Parser) SetSource(path string, line Line, index int) {
+	if p.prov == nil {
+		return
+	}
+
+	src := Source{LineNum: line.LineNum, Index: index}
+	switch {
+	case index == -1:
+		src.Raw = ItemJoin(line, -1, -1)
+	case index >= 0 && index < len(line.Items):
+		src.Raw = line.Items[index].Val
+	}
+	p.prov[path] = src
+}
+
+// SetItem calls rec.SetItem() and records the provenance of the field. The provenance path
+// comes from rec.Path(), so attach a sub-Record to its parent before setting its fields.
+func (p *Parser) SetItem(rec *Record, name string, line Line, index int) error {
+	if err := rec.SetItem(name, line, index); err != nil {
+		return err
+	}
+	p.SetSource(joinPath(rec.path, name), line, index)
+	return nil
+}
+
+// AppendItem calls rec.AppendItem() and records the provenance of the new list entry.
+func (p *Parser) AppendItem(rec *Record, name string, line Line, index int) error {
+	if err := rec.AppendItem(name, line, index); err != nil {
+		return err
+	}
+	v, _ := rec.Get(name)
+	p.SetSource(fmt.Sprintf("%s[%d]", joinPath(rec.path, name), len(v.V.([]Value))-1), line, index)
+	return nil
+}
+
+// Match calls Match() against line.Raw and records the provenance of each named submatch
+// at path "path.<submatch name>". If path is empty, the submatch name is used as the path.
+func (p *Parser) Match(path string, re *regexp.Regexp, line Line) (map[string]string, error) {
+	m, err := Match(re, line.Raw)
+	if err != nil {
+		return nil, fmt.Errorf("[Line %d]: %w", line.LineNum, err)
+	}
+	if p.prov == nil {
+		return m, nil
+	}
+
+	locs := re.FindStringSubmatchIndex(line.Raw)
+	for i, name := range re.SubexpNames() {
+		if _, ok := m[name]; !ok || name == "" {
+			continue
+		}
+		start, end := locs[i*2], locs[i*2+1]
+		p.prov[joinPath(path, name)] = Source{
+			LineNum: line.LineNum,
+			Index:   itemIndexAt(line.Raw, start),
+			Raw:     line.Raw[start:end],
+		}
+	}
+	return m, nil
+}
+
+// itemIndexAt returns the index of the Item that begins at byte offset "offset" in "raw".
+// If an Item does not begin at "offset", -1 is returned.
+func itemIndexAt(raw string, offset int) int {
+	index := -1
+	inItem := false
+	for i, r := range raw {
+		if unicode.IsSpace(r) {
+			inItem = false
+			continue
+		}
+		if !inItem {
+			inItem = true
+			index++
+			if i == offset {
+				return index
+			}
+		}
+		if i >= offset {
+			return -1
+		}
+	}
+	return -1
+}
+
+func joinPath(path, name string) string {
+	switch {
+	case path == "":
+		return name
+	case name == "":
+		return path
+	}
+	return path + "." + name
+}
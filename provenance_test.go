@@ -0,0 +1,93 @@
+package halfpike
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+var provInterRE = regexp.MustCompile(`(?P<blade>\d+)/(?P<pic>\d+)/(?P<port>\d+)`)
+
+func TestProvenance(t *testing.T) {
+	rs := &RecordSet{}
+	rs.StartFn = func(ctx context.Context, p *Parser) ParseFn {
+		for {
+			line, err := p.FindStart([]string{"Physical", "interface:", Skip})
+			if err != nil {
+				return nil
+			}
+			rec := rs.New()
+			if err := p.SetItem(rec, "name", line, 2); err != nil {
+				return p.Errorf("%s", err)
+			}
+			if _, err := p.Match(rec.Path(), provInterRE, line); err != nil {
+				return p.Errorf("%s", err)
+			}
+
+			line = p.Next()
+			stats := NewRecord()
+			if err := rec.Set("stats", stats, line.LineNum); err != nil {
+				return p.Errorf("%s", err)
+			}
+			if err := p.SetItem(stats, "mtu", line, 1); err != nil {
+				return p.Errorf("%s", err)
+			}
+			for line = p.Next(); p.IsAtStart(line, []string{"Address:", Skip}); line = p.Next() {
+				if err := p.AppendItem(rec, "addresses", line, 1); err != nil {
+					return p.Errorf("%s", err)
+				}
+				p.SetSource(rec.Path()+".lastAddrLine", line, -1)
+			}
+			p.Backup()
+		}
+	}
+
+	prov := Provenance{}
+	if err := Parse(context.Background(), recordInput, rs, WithProvenance(prov)); err != nil {
+		t.Fatalf("TestProvenance: got err == %s", err)
+	}
+
+	want := Provenance{
+		"[0].name":         {LineNum: 1, Index: 2, Raw: "ge-3/0/2,"},
+		"[0].blade":        {LineNum: 1, Index: -1, Raw: "3"},
+		"[0].pic":          {LineNum: 1, Index: -1, Raw: "0"},
+		"[0].port":         {LineNum: 1, Index: -1, Raw: "2"},
+		"[0].stats.mtu":    {LineNum: 2, Index: 1, Raw: "1522"},
+		"[0].addresses[0]": {LineNum: 3, Index: 1, Raw: "10.0.0.1"},
+		"[0].addresses[1]": {LineNum: 4, Index: 1, Raw: "10.0.0.2"},
+		"[0].lastAddrLine": {LineNum: 4, Index: -1, Raw: "Address: 10.0.0.2"},
+		"[1].name":         {LineNum: 5, Index: 2, Raw: "ge-3/0/3,"},
+		"[1].blade":        {LineNum: 5, Index: -1, Raw: "3"},
+		"[1].pic":          {LineNum: 5, Index: -1, Raw: "0"},
+		"[1].port":         {LineNum: 5, Index: -1, Raw: "3"},
+		"[1].stats.mtu":    {LineNum: 6, Index: 1, Raw: "9192"},
+	}
+
+	if diff := pretty.Compare(want, prov); diff != "" {
+		t.Errorf("TestProvenance: -want/+got:\n%s", diff)
+	}
+}
+
+func TestItemIndexAt(t *testing.T) {
+	raw := "  Peer: 10.10.10.2+179 AS 22\n"
+	tests := []struct {
+		offset int
+		want   int
+	}{
+		{offset: 2, want: 0},
+		{offset: 8, want: 1},
+		{offset: 10, want: -1},
+		{offset: 23, want: 2},
+		{offset: 26, want: 3},
+		{offset: 27, want: -1},
+		{offset: 1, want: -1},
+	}
+
+	for _, test := range tests {
+		if got := itemIndexAt(raw, test.offset); got != test.want {
+			t.Errorf("TestItemIndexAt(%d): got %d, want %d", test.offset, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,522 @@
+package halfpike
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Value is a single value stored in a Record along with the line it was decoded from.
+type Value struct {
+	// V is the value. It must be one of: nil, string, bool, int, int64, uint64, float64,
+	// *Record or []Value (a list).
+	V interface{}
+	// LineNum is the Line.LineNum that the value was decoded from.
+	LineNum int
+}
+
+// Field is a named Value within a Record.
+type Field struct {
+	// Name is the name of the field.
+	Name string
+	Value
+}
+
+// Record is a generic, ordered set of typed fields. It is used when writing a dedicated
+// struct for the output of a parser is not worth the effort, such as ad-hoc parsers whose
+// output is simply going to be converted to JSON or YAML. Fields keep the order they were
+// first set in and each remembers the LineNum it was decoded from.
+type Record struct {
+	fields []Field
+	index  map[string]int
+
+	// path is the path to this Record from the top of the output, used for Provenance.
+	path string
+}
+
+// NewRecord is the constructor for Record.
+func NewRecord() *Record {
+	return &Record{index: map[string]int{}}
+}
+
+// Len returns the number of fields in the Record.
+func (r *Record) Len() int {
+	return len(r.fields)
+}
+
+// Fields returns a copy of the fields in the Record in the order they were added.
+func (r *Record) Fields() []Field {
+	out := make([]Field, len(r.fields))
+	copy(out, r.fields)
+	return out
+}
+
+// Path returns the path to this Record from its RecordSet, such as "[0].stats". It is empty
+// for a Record that is not stored in a RecordSet or another Record.
+func (r *Record) Path() string {
+	return r.path
+}
+
+// Get returns the Value stored at field "name".
+func (r *Record) Get(name string) (Value, bool) {
+	i, ok := r.index[name]
+	if !ok {
+		return Value{}, false
+	}
+	return r.fields[i].Value, true
+}
+
+// Set sets field "name" to "v", which was found on line "lineNum". If the field already
+// exists, its value is replaced but it keeps its position.
+func (r *Record) Set(name string, v interface{}, lineNum int) error {
+	if name == "" {
+		return fmt.Errorf("Record.Set(): name cannot be empty")
+	}
+	if err := checkValue(v); err != nil {
+		return fmt.Errorf("Record.Set(%s): %w", name, err)
+	}
+
+	if r.index == nil {
+		r.index = map[string]int{}
+	}
+	if sub, ok := v.(*Record); ok && sub != nil {
+		sub.path = joinPath(r.path, name)
+	}
+	if i, ok := r.index[name]; ok {
+		r.fields[i].Value = Value{V: v, LineNum: lineNum}
+		return nil
+	}
+	r.index[name] = len(r.fields)
+	r.fields = append(r.fields, Field{Name: name, Value: Value{V: v, LineNum: lineNum}})
+	return nil
+}
+
+// SetItem sets field "name" to the Item at line.Items[index]. ItemInt is stored as an int,
+// ItemFloat as a float64 and everything else as a string. The field records line.LineNum.
+func (r *Record) SetItem(name string, line Line, index int) error {
+	v, err := itemValue(line, index)
+	if err != nil {
+		return fmt.Errorf("Record.SetItem(%s): %w", name, err)
+	}
+	return r.Set(name, v, line.LineNum)
+}
+
+// Append adds "v" to the list stored at field "name", creating the list if needed. It is an
+// error if "name" exists and is not a list.
+func (r *Record) Append(name string, v interface{}, lineNum int) error {
+	if err := checkValue(v); err != nil {
+		return fmt.Errorf("Record.Append(%s): %w", name, err)
+	}
+
+	i, ok := r.index[name]
+	if !ok {
+		if err := r.Set(name, []Value{}, lineNum); err != nil {
+			return err
+		}
+		i = r.index[name]
+	}
+
+	list, ok := r.fields[i].V.([]Value)
+	if !ok {
+		return fmt.Errorf("Record.Append(%s): field is a %T, not a list", name, r.fields[i].V)
+	}
+	if sub, ok := v.(*Record); ok && sub != nil {
+		sub.path = fmt.Sprintf("%s[%d]", joinPath(r.path, name), len(list))
+	}
+	r.fields[i].V = append(list, Value{V: v, LineNum: lineNum})
+	return nil
+}
+
+// AppendItem is like Append(), but converts line.Items[index] the same way SetItem() does.
+func (r *Record) AppendItem(name string, line Line, index int) error {
+	v, err := itemValue(line, index)
+	if err != nil {
+		return fmt.Errorf("Record.AppendItem(%s): %w", name, err)
+	}
+	return r.Append(name, v, line.LineNum)
+}
+
+// MarshalJSON implements json.Marshaler. Fields are output in the order they were added.
+func (r *Record) MarshalJSON() ([]byte, error) {
+	buff := &bytes.Buffer{}
+	if err := writeJSONRecord(buff, r); err != nil {
+		return nil, err
+	}
+	return buff.Bytes(), nil
+}
+
+// YAML returns the Record as a YAML document. Fields are output in the order they were added.
+func (r *Record) YAML() ([]byte, error) {
+	buff := &bytes.Buffer{}
+	if err := writeYAMLRecord(buff, r, 0); err != nil {
+		return nil, err
+	}
+	return buff.Bytes(), nil
+}
+
+// RecordSet is a ParseObject that collects Records. This allows writing a parser without
+// defining a type to hold the output. Simply set StartFn to your first ParseFn and
+// call RecordSet.New() whenever you find a new entry in the content.
+type RecordSet struct {
+	// StartFn is the ParseFn that Start() will return. This must be set.
+	StartFn ParseFn
+	// ValidateFn, if set, is called by Validate() to check the Records.
+	ValidateFn func(rs *RecordSet) error
+
+	// Records are the Records that were decoded.
+	Records []*Record
+}
+
+// Start implements ParseObject.Start().
+func (r *RecordSet) Start(ctx context.Context, p *Parser) ParseFn {
+	if r.StartFn == nil {
+		return p.Errorf("RecordSet.StartFn cannot be nil")
+	}
+	return r.StartFn
+}
+
+// Validate implements Validator.Validate().
+func (r *RecordSet) Validate() error {
+	if r.ValidateFn == nil {
+		return nil
+	}
+	return r.ValidateFn(r)
+}
+
+// New adds a new Record to the RecordSet and returns it.
+func (r *RecordSet) New() *Record {
+	rec := NewRecord()
+	rec.path = fmt.Sprintf("[%d]", len(r.Records))
+	r.Records = append(r.Records, rec)
+	return rec
+}
+
+// Last returns the last Record added to the RecordSet or nil if there are none.
+func (r *RecordSet) Last() *Record {
+	if len(r.Records) == 0 {
+		return nil
+	}
+	return r.Records[len(r.Records)-1]
+}
+
+// MarshalJSON implements json.Marshaler. The Records are output as a JSON array.
+func (r *RecordSet) MarshalJSON() ([]byte, error) {
+	buff := &bytes.Buffer{}
+	buff.WriteByte('[')
+	for i, rec := range r.Records {
+		if i > 0 {
+			buff.WriteByte(',')
+		}
+		if err := writeJSONRecord(buff, rec); err != nil {
+			return nil, err
+		}
+	}
+	buff.WriteByte(']')
+	return buff.Bytes(), nil
+}
+
+// YAML returns the Records as a YAML sequence.
+func (r *RecordSet) YAML() ([]byte, error) {
+	buff := &bytes.Buffer{}
+	if len(r.Records) == 0 {
+		buff.WriteString("[]\n")
+		return buff.Bytes(), nil
+	}
+	for _, rec := range r.Records {
+		if err := writeYAMLListEntry(buff, rec, 0); err != nil {
+			return nil, err
+		}
+	}
+	return buff.Bytes(), nil
+}
+
+// WriteNDJSON writes each Record as a single line of JSON to "w" (newline-delimited JSON).
+func (r *RecordSet) WriteNDJSON(w io.Writer) error {
+	buff := &bytes.Buffer{}
+	for _, rec := range r.Records {
+		buff.Reset()
+		if err := writeJSONRecord(buff, rec); err != nil {
+			return err
+		}
+		buff.WriteByte('\n')
+		if _, err := w.Write(buff.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// itemValue converts line.Items[index] into a value that can be stored in a Record.
+func itemValue(line Line, index int) (interface{}, error) {
+	if index < 0 || index >= len(line.Items) {
+		return nil, fmt.Errorf("[Line %d] index %d is out of bounds for a line with %d items", line.LineNum, index, len(line.Items))
+	}
+
+	item := line.Items[index]
+	switch item.Type {
+	case ItemEOL, ItemEOF:
+		return nil, fmt.Errorf("[Line %d] index %d is the end of the line, not a value", line.LineNum, index)
+	case ItemInt:
+		return item.ToInt()
+	case ItemFloat:
+		return item.ToFloat()
+	}
+	return item.Val, nil
+}
+
+func checkValue(v interface{}) error {
+	switch t := v.(type) {
+	case nil, string, bool, int, int64, uint64, *Record:
+	case float64:
+		if math.IsNaN(t) || math.IsInf(t, 0) {
+			return fmt.Errorf("float64 value %v cannot be represented", t)
+		}
+	case []Value:
+		for _, e := range t {
+			if err := checkValue(e.V); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("type %T is not a supported Record value", v)
+	}
+	return nil
+}
+
+func writeJSONRecord(buff *bytes.Buffer, r *Record) error {
+	if r == nil {
+		buff.WriteString("null")
+		return nil
+	}
+
+	buff.WriteByte('{')
+	for i, f := range r.fields {
+		if i > 0 {
+			buff.WriteByte(',')
+		}
+		writeJSONString(buff, f.Name)
+		buff.WriteByte(':')
+		if err := writeJSONValue(buff, f.V); err != nil {
+			return fmt.Errorf("field %s: %w", f.Name, err)
+		}
+	}
+	buff.WriteByte('}')
+	return nil
+}
+
+func writeJSONValue(buff *bytes.Buffer, v interface{}) error {
+	switch t := v.(type) {
+	case nil:
+		buff.WriteString("null")
+	case string:
+		writeJSONString(buff, t)
+	case bool:
+		buff.WriteString(strconv.FormatBool(t))
+	case int:
+		buff.WriteString(strconv.Itoa(t))
+	case int64:
+		buff.WriteString(strconv.FormatInt(t, 10))
+	case uint64:
+		buff.WriteString(strconv.FormatUint(t, 10))
+	case float64:
+		b, err := json.Marshal(t)
+		if err != nil {
+			return err
+		}
+		buff.Write(b)
+	case *Record:
+		return writeJSONRecord(buff, t)
+	case []Value:
+		buff.WriteByte('[')
+		for i, e := range t {
+			if i > 0 {
+				buff.WriteByte(',')
+			}
+			if err := writeJSONValue(buff, e.V); err != nil {
+				return err
+			}
+		}
+		buff.WriteByte(']')
+	default:
+		return fmt.Errorf("type %T is not a supported Record value", v)
+	}
+	return nil
+}
+
+func writeJSONString(buff *bytes.Buffer, s string) {
+	// json.Marshal of a string cannot fail.
+	b, _ := json.Marshal(s)
+	buff.Write(b)
+}
+
+func writeYAMLRecord(buff *bytes.Buffer, r *Record, indent int) error {
+	if r == nil || len(r.fields) == 0 {
+		buff.WriteString(strings.Repeat(" ", indent))
+		buff.WriteString("{}\n")
+		return nil
+	}
+	for i, f := range r.fields {
+		if i > 0 || indent > 0 {
+			buff.WriteString(strings.Repeat(" ", indent))
+		}
+		if err := writeYAMLField(buff, f, indent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeYAMLField writes "name: value". The caller is responsible for the indentation of
+// the first line.
+func writeYAMLField(buff *bytes.Buffer, f Field, indent int) error {
+	buff.WriteString(yamlString(f.Name))
+	buff.WriteByte(':')
+
+	switch t := f.V.(type) {
+	case *Record:
+		if t == nil || len(t.fields) == 0 {
+			buff.WriteString(" {}\n")
+			return nil
+		}
+		buff.WriteByte('\n')
+		return writeYAMLRecord(buff, t, indent+2)
+	case []Value:
+		if len(t) == 0 {
+			buff.WriteString(" []\n")
+			return nil
+		}
+		buff.WriteByte('\n')
+		for _, e := range t {
+			if err := writeYAMLListEntry(buff, e.V, indent+2); err != nil {
+				return fmt.Errorf("field %s: %w", f.Name, err)
+			}
+		}
+		return nil
+	}
+
+	s, err := yamlScalar(f.V)
+	if err != nil {
+		return fmt.Errorf("field %s: %w", f.Name, err)
+	}
+	buff.WriteByte(' ')
+	buff.WriteString(s)
+	buff.WriteByte('\n')
+	return nil
+}
+
+func writeYAMLListEntry(buff *bytes.Buffer, v interface{}, indent int) error {
+	buff.WriteString(strings.Repeat(" ", indent))
+	buff.WriteString("-")
+
+	switch t := v.(type) {
+	case *Record:
+		if t == nil || len(t.fields) == 0 {
+			buff.WriteString(" {}\n")
+			return nil
+		}
+		buff.WriteByte(' ')
+		for i, f := range t.fields {
+			if i > 0 {
+				buff.WriteString(strings.Repeat(" ", indent+2))
+			}
+			if err := writeYAMLField(buff, f, indent+2); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []Value:
+		if len(t) == 0 {
+			buff.WriteString(" []\n")
+			return nil
+		}
+		buff.WriteByte('\n')
+		for _, e := range t {
+			if err := writeYAMLListEntry(buff, e.V, indent+2); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	s, err := yamlScalar(v)
+	if err != nil {
+		return err
+	}
+	buff.WriteByte(' ')
+	buff.WriteString(s)
+	buff.WriteByte('\n')
+	return nil
+}
+
+func yamlScalar(v interface{}) (string, error) {
+	switch t := v.(type) {
+	case nil:
+		return "null", nil
+	case string:
+		return yamlString(t), nil
+	case bool:
+		return strconv.FormatBool(t), nil
+	case int:
+		return strconv.Itoa(t), nil
+	case int64:
+		return strconv.FormatInt(t, 10), nil
+	case uint64:
+		return strconv.FormatUint(t, 10), nil
+	case float64:
+		return strconv.FormatFloat(t, 'g', -1, 64), nil
+	}
+	return "", fmt.Errorf("type %T is not a supported Record value", v)
+}
+
+// yamlReserved are plain scalars that a YAML decoder would not read back as a string.
+var yamlReserved = map[string]bool{
+	"": true, "~": true, "null": true, "true": true, "false": true,
+	"yes": true, "no": true, "on": true, "off": true, "y": true, "n": true,
+}
+
+// yamlNumberRE matches the YAML 1.1 numbers that strconv does not parse: sexagesimal numbers such as
+// "12:30" or "1:02:03.5", and ".inf" and ".nan". YAML 1.1 decoders would read an uptime like "1:02:03"
+// back as the integer 3723.
+var yamlNumberRE = regexp.MustCompile(`(?i)^[-+]?([0-9][0-9_]*(:[0-5]?[0-9])+(\.[0-9_]*)?|\.(inf|nan))$`)
+
+// yamlString returns "s" as a plain scalar if that is safe, otherwise as a double quoted scalar.
+func yamlString(s string) string {
+	if yamlPlainSafe(s) {
+		return s
+	}
+	// A JSON string is a valid YAML double quoted scalar.
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+func yamlPlainSafe(s string) bool {
+	if yamlReserved[strings.ToLower(s)] {
+		return false
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return false
+	}
+	if _, err := strconv.ParseInt(s, 0, 64); err == nil {
+		return false
+	}
+	if yamlNumberRE.MatchString(s) {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '_' || r == '/' || r == '.' || r == '+' || r == '(' || r == ')':
+		case (r == '-' || r == ':') && i > 0:
+		default:
+			return false
+		}
+	}
+	// "key: value" and trailing colons are read as mappings.
+	return !strings.Contains(s, ": ") && !strings.HasSuffix(s, ":")
+}
@@ -0,0 +1,215 @@
+package halfpike
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+const recordInput = `
+Physical interface: ge-3/0/2, Enabled, Physical link is Up
+  MTU: 1522 Load: 0.5
+  Address: 10.0.0.1
+  Address: 10.0.0.2
+Physical interface: ge-3/0/3, Disabled, Physical link is Down
+  MTU: 9192 Load: 1.25
+`
+
+// recordParser is an example of an ad-hoc parser that writes to a RecordSet.
+func recordParser(rs *RecordSet) ParseFn {
+	var findAttrs ParseFn
+
+	findInter := func(ctx context.Context, p *Parser) ParseFn {
+		line, err := p.FindStart([]string{"Physical", "interface:", Skip, Skip})
+		if err != nil {
+			if len(rs.Records) == 0 {
+				return p.Errorf("no interfaces found")
+			}
+			return nil
+		}
+		rec := rs.New()
+		if err := rec.Set("name", strings.TrimSuffix(line.Items[2].Val, ","), line.LineNum); err != nil {
+			return p.Errorf("%s", err)
+		}
+		if err := rec.Set("enabled", line.Items[3].Val == "Enabled,", line.LineNum); err != nil {
+			return p.Errorf("%s", err)
+		}
+		return findAttrs
+	}
+
+	findAttrs = func(ctx context.Context, p *Parser) ParseFn {
+		rec := rs.Last()
+		for {
+			line := p.Next()
+			switch {
+			case p.IsAtStart(line, []string{"MTU:", Skip, "Load:", Skip}):
+				stats := NewRecord()
+				if err := stats.SetItem("mtu", line, 1); err != nil {
+					return p.Errorf("%s", err)
+				}
+				if err := stats.SetItem("load", line, 3); err != nil {
+					return p.Errorf("%s", err)
+				}
+				if err := rec.Set("stats", stats, line.LineNum); err != nil {
+					return p.Errorf("%s", err)
+				}
+			case p.IsAtStart(line, []string{"Address:", Skip}):
+				if err := rec.AppendItem("addresses", line, 1); err != nil {
+					return p.Errorf("%s", err)
+				}
+			default:
+				p.Backup()
+				return findInter
+			}
+		}
+	}
+
+	return findInter
+}
+
+func TestRecordSet(t *testing.T) {
+	rs := &RecordSet{}
+	rs.StartFn = recordParser(rs)
+
+	if err := Parse(context.Background(), recordInput, rs); err != nil {
+		t.Fatalf("TestRecordSet: got err == %s", err)
+	}
+
+	wantJSON := `[{"name":"ge-3/0/2","enabled":true,"stats":{"mtu":1522,"load":0.5},"addresses":["10.0.0.1","10.0.0.2"]},` +
+		`{"name":"ge-3/0/3","enabled":false,"stats":{"mtu":9192,"load":1.25}}]`
+
+	b, err := json.Marshal(rs)
+	if err != nil {
+		t.Fatalf("TestRecordSet: json.Marshal() got err == %s", err)
+	}
+	if string(b) != wantJSON {
+		t.Errorf("TestRecordSet(JSON): got:\n%s\nwant:\n%s", b, wantJSON)
+	}
+
+	wantYAML := `- name: ge-3/0/2
+  enabled: true
+  stats:
+    mtu: 1522
+    load: 0.5
+  addresses:
+    - 10.0.0.1
+    - 10.0.0.2
+- name: ge-3/0/3
+  enabled: false
+  stats:
+    mtu: 9192
+    load: 1.25
+`
+	b, err = rs.YAML()
+	if err != nil {
+		t.Fatalf("TestRecordSet: YAML() got err == %s", err)
+	}
+	if diff := pretty.Compare(wantYAML, string(b)); diff != "" {
+		t.Errorf("TestRecordSet(YAML): -want/+got:\n%s", diff)
+	}
+
+	buff := &bytes.Buffer{}
+	if err := rs.WriteNDJSON(buff); err != nil {
+		t.Fatalf("TestRecordSet: WriteNDJSON() got err == %s", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buff.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("TestRecordSet(NDJSON): got %d lines, want 2", len(lines))
+	}
+	for i, l := range lines {
+		b, _ := json.Marshal(rs.Records[i])
+		if l != string(b) {
+			t.Errorf("TestRecordSet(NDJSON): line %d: got %s, want %s", i, l, b)
+		}
+	}
+
+	// Make sure that line numbers were recorded.
+	v, ok := rs.Records[0].Get("addresses")
+	if !ok {
+		t.Fatalf("TestRecordSet: could not find 'addresses' field")
+	}
+	gotLines := []int{}
+	for _, e := range v.V.([]Value) {
+		gotLines = append(gotLines, e.LineNum)
+	}
+	if diff := pretty.Compare([]int{3, 4}, gotLines); diff != "" {
+		t.Errorf("TestRecordSet(LineNum): -want/+got:\n%s", diff)
+	}
+}
+
+func TestRecord(t *testing.T) {
+	r := NewRecord()
+	if err := r.Set("a", 1, 1); err != nil {
+		t.Fatalf("TestRecord: Set(a) got err == %s", err)
+	}
+	if err := r.Set("b", struct{}{}, 1); err == nil {
+		t.Errorf("TestRecord: Set(b) with unsupported type: got err == nil, want err != nil")
+	}
+	if err := r.Append("a", 2, 2); err == nil {
+		t.Errorf("TestRecord: Append(a) on a non-list: got err == nil, want err != nil")
+	}
+	if err := r.Set("c", "yes", 3); err != nil {
+		t.Fatalf("TestRecord: Set(c) got err == %s", err)
+	}
+	if err := r.Set("d", "key: value", 3); err != nil {
+		t.Fatalf("TestRecord: Set(d) got err == %s", err)
+	}
+	if err := r.Set("e", "", 3); err != nil {
+		t.Fatalf("TestRecord: Set(e) got err == %s", err)
+	}
+	if err := r.Set("f", NewRecord(), 4); err != nil {
+		t.Fatalf("TestRecord: Set(f) got err == %s", err)
+	}
+	// Replacing a value keeps its position.
+	if err := r.Set("a", "22", 5); err != nil {
+		t.Fatalf("TestRecord: Set(a) got err == %s", err)
+	}
+
+	want := "a: \"22\"\nc: \"yes\"\nd: \"key: value\"\ne: \"\"\nf: {}\n"
+	b, err := r.YAML()
+	if err != nil {
+		t.Fatalf("TestRecord: YAML() got err == %s", err)
+	}
+	if diff := pretty.Compare(want, string(b)); diff != "" {
+		t.Errorf("TestRecord(YAML): -want/+got:\n%s", diff)
+	}
+
+	v, _ := r.Get("a")
+	if v.LineNum != 5 {
+		t.Errorf("TestRecord: Get(a).LineNum: got %d, want 5", v.LineNum)
+	}
+}
+
+func TestYAMLString(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"ge-3/0/2", "ge-3/0/2"},
+		{"10.0.0.1", "10.0.0.1"},
+		{"fe80::1", "fe80::1"},
+		{"", `""`},
+		{"yes", `"yes"`},
+		{"1500", `"1500"`},
+		{"1.5", `"1.5"`},
+		{"12:30", `"12:30"`},
+		{"1:02:03", `"1:02:03"`},
+		{"1:02:03.5", `"1:02:03.5"`},
+		{".inf", `".inf"`},
+		{".Inf", `".Inf"`},
+		{"-.inf", `"-.inf"`},
+		{".nan", `".nan"`},
+		{".NaN", `".NaN"`},
+		{"12:75", "12:75"},
+		{"key: value", `"key: value"`},
+	}
+
+	for _, test := range tests {
+		if got := yamlString(test.in); got != test.want {
+			t.Errorf("TestYAMLString(%s): got %s, want %s", test.in, got, test.want)
+		}
+	}
+}
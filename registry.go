@@ -0,0 +1,419 @@
+package halfpike
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// ErrNoParser is returned (wrapped) by Registry when no parser is registered that matches a request.
+var ErrNoParser = errors.New("no parser registered")
+
+// Registration is a parser registered with a Registry.
+type Registration struct {
+	// Vendor is the vendor the parser is for, like "juniper" or "cisco". This is always lower case.
+	Vendor string
+	// Command is the normalized command the parser decodes the output of, like "show interfaces".
+	Command string
+	// Constraint is the version constraint the parser was registered with, like ">=18.1R3,<20.0".
+	// An empty Constraint matches all versions.
+	Constraint string
+	// New returns a new ParseObject for the parser.
+	New func() ParseObject
+
+	constraints []constraint
+	order       int
+}
+
+// Registry maps a vendor, command and OS version to a parser. It is safe for concurrent use.
+type Registry struct {
+	mu   sync.RWMutex
+	regs map[string][]*Registration // keyed by vendor
+	n    int
+}
+
+// NewRegistry creates a new Registry.
+func NewRegistry() *Registry {
+	return &Registry{regs: map[string][]*Registration{}}
+}
+
+// Register registers a parser for "command" output from "vendor" devices running an OS version
+// matching "constraint". "newFn" must return a new ParseObject on every call.
+//
+// A constraint is a comma separated list of an operator (>=, >, <=, <, =, !=) followed by a version,
+// all of which must match, like ">=18.1R3,<20.0". A version without an operator must match exactly.
+// An empty constraint matches any version.
+//
+// Commands are normalized with NormalizeCommand().
+func (r *Registry) Register(vendor, command, constraint string, newFn func() ParseObject) error {
+	if newFn == nil {
+		return fmt.Errorf("Register(%s, %s): newFn cannot be nil", vendor, command)
+	}
+	vendor = strings.ToLower(strings.TrimSpace(vendor))
+	if vendor == "" {
+		return fmt.Errorf("Register(%s): vendor cannot be empty", command)
+	}
+	cmd := NormalizeCommand(command)
+	if cmd == "" {
+		return fmt.Errorf("Register(%s): command cannot be empty", vendor)
+	}
+
+	cons, err := parseConstraints(constraint)
+	if err != nil {
+		return fmt.Errorf("Register(%s, %s): %w", vendor, cmd, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, reg := range r.regs[vendor] {
+		if reg.Command == cmd && sameConstraints(reg.constraints, cons) {
+			return fmt.Errorf("Register(%s, %s): constraint %q is already registered", vendor, cmd, constraint)
+		}
+	}
+
+	r.n++
+	r.regs[vendor] = append(
+		r.regs[vendor],
+		&Registration{
+			Vendor:      vendor,
+			Command:     cmd,
+			Constraint:  strings.TrimSpace(constraint),
+			New:         newFn,
+			constraints: cons,
+			order:       r.n,
+		},
+	)
+	return nil
+}
+
+// Lookup returns the Registration that best matches the vendor, OS version and command.
+//
+// The command may be abbreviated the same way a device's CLI allows, such as "sh int" for
+// "show interfaces", as long as only one registered command matches.
+//
+// If several Registrations have a constraint matching the version, the most specific is used:
+// a Registration with a constraint is preferred over one without, then the one with the highest
+// lower bound, then the one with the most constraints, and finally the one registered first.
+// If version is empty, only Registrations without a constraint match.
+func (r *Registry) Lookup(vendor, version, command string) (*Registration, error) {
+	vendor = strings.ToLower(strings.TrimSpace(vendor))
+	cmd := NormalizeCommand(command)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	regs := r.regs[vendor]
+	if len(regs) == 0 {
+		return nil, fmt.Errorf("vendor %q: %w", vendor, ErrNoParser)
+	}
+
+	cmd, err := resolveCommand(regs, cmd)
+	if err != nil {
+		return nil, fmt.Errorf("vendor %q: %w", vendor, err)
+	}
+
+	v := parseVersion(version)
+	var best *Registration
+	for _, reg := range regs {
+		if reg.Command != cmd || !reg.matches(v) {
+			continue
+		}
+		if best == nil || reg.moreSpecific(best) {
+			best = reg
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("vendor %q, command %q, version %q: %w", vendor, cmd, version, ErrNoParser)
+	}
+	return best, nil
+}
+
+// Parse finds the Registration for the vendor, OS version and command with Lookup(), creates a
+// new ParseObject from it and parses "content" into it. The ParseObject and the Registration that
+// was used are returned.
+func (r *Registry) Parse(ctx context.Context, vendor, version, command, content string, options ...Option) (ParseObject, *Registration, error) {
+	reg, err := r.Lookup(vendor, version, command)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	obj := reg.New()
+	if err := Parse(ctx, content, obj, options...); err != nil {
+		return nil, reg, err
+	}
+	return obj, reg, nil
+}
+
+// NormalizeCommand normalizes a command so that it can be compared to other commands. This lower cases
+// the command, collapses whitespace and removes a trailing "| no-more".
+func NormalizeCommand(command string) string {
+	cmd := strings.Join(strings.Fields(strings.ToLower(command)), " ")
+	return strings.TrimSpace(strings.TrimSuffix(cmd, "| no-more"))
+}
+
+// resolveCommand returns the registered command that "cmd" is or is an abbreviation of.
+func resolveCommand(regs []*Registration, cmd string) (string, error) {
+	for _, reg := range regs {
+		if reg.Command == cmd {
+			return cmd, nil
+		}
+	}
+
+	words := strings.Fields(cmd)
+	found := ""
+	for _, reg := range regs {
+		if reg.Command == found || !isAbbrev(words, strings.Fields(reg.Command)) {
+			continue
+		}
+		if found != "" {
+			return "", fmt.Errorf("command %q is ambiguous, matches %q and %q", cmd, found, reg.Command)
+		}
+		found = reg.Command
+	}
+	if found == "" {
+		return "", fmt.Errorf("command %q: %w", cmd, ErrNoParser)
+	}
+	return found, nil
+}
+
+// isAbbrev returns true if each word in "abbrev" is a prefix of the word in the same position in "words".
+func isAbbrev(abbrev, words []string) bool {
+	if len(abbrev) != len(words) {
+		return false
+	}
+	for i, w := range abbrev {
+		if !strings.HasPrefix(words[i], w) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Registration) matches(v version) bool {
+	// Without a version, we cannot tell if a constraint matches.
+	if len(v) == 0 && len(r.constraints) > 0 {
+		return false
+	}
+	for _, c := range r.constraints {
+		if !c.matches(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// moreSpecific returns true if "r" should be used instead of "other" when both match.
+func (r *Registration) moreSpecific(other *Registration) bool {
+	switch {
+	case len(r.constraints) > 0 && len(other.constraints) == 0:
+		return true
+	case len(r.constraints) == 0 && len(other.constraints) > 0:
+		return false
+	}
+
+	rLow, oLow := r.lowerBound(), other.lowerBound()
+	switch {
+	case rLow != nil && oLow == nil:
+		return true
+	case rLow == nil && oLow != nil:
+		return false
+	case rLow != nil && oLow != nil:
+		if c := compareVersions(rLow, oLow); c != 0 {
+			return c > 0
+		}
+	}
+
+	if len(r.constraints) != len(other.constraints) {
+		return len(r.constraints) > len(other.constraints)
+	}
+	return r.order < other.order
+}
+
+// lowerBound returns the highest version that a matching version must be at or above.
+func (r *Registration) lowerBound() version {
+	var low version
+	for _, c := range r.constraints {
+		switch c.op {
+		case ">=", ">", "=":
+			if low == nil || compareVersions(c.v, low) > 0 {
+				low = c.v
+			}
+		}
+	}
+	return low
+}
+
+// constraint is a single version constraint, like ">=18.1R3".
+type constraint struct {
+	op string
+	v  version
+}
+
+func (c constraint) matches(v version) bool {
+	cmp := compareVersions(v, c.v)
+	switch c.op {
+	case ">=":
+		return cmp >= 0
+	case ">":
+		return cmp > 0
+	case "<=":
+		return cmp <= 0
+	case "<":
+		return cmp < 0
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	}
+	panic(fmt.Sprintf("unsupported constraint operator %q", c.op))
+}
+
+// sameConstraints reports if "a" and "b" match the same versions because they hold the same
+// constraints, such as "=18.1" and "18.1" or ">=18.1,<20.0" and "<20.0,>=18.1".
+func sameConstraints(a, b []constraint) bool {
+	return containsConstraints(a, b) && containsConstraints(b, a)
+}
+
+// containsConstraints reports if every constraint in "b" is in "a".
+func containsConstraints(a, b []constraint) bool {
+	for _, cb := range b {
+		found := false
+		for _, ca := range a {
+			if ca.op == cb.op && compareVersions(ca.v, cb.v) == 0 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// constraintOps are the supported operators. Two character operators must come first.
+var constraintOps = []string{">=", "<=", "!=", "==", ">", "<", "="}
+
+func parseConstraints(s string) ([]constraint, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+
+	var cons []constraint
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		c := constraint{op: "="}
+		for _, op := range constraintOps {
+			if strings.HasPrefix(part, op) {
+				c.op = op
+				part = strings.TrimSpace(strings.TrimPrefix(part, op))
+				break
+			}
+		}
+		if c.op == "==" {
+			c.op = "="
+		}
+		if part == "" {
+			return nil, fmt.Errorf("constraint %q has an empty version", s)
+		}
+		c.v = parseVersion(part)
+		cons = append(cons, c)
+	}
+	return cons, nil
+}
+
+// version is a version split into numeric and non-numeric segments. "18.1R3-S2" is
+// [18 1 R 3 S 2] and "15.2(7)E4" is [15 2 7 E 4].
+type version []versionSeg
+
+type versionSeg struct {
+	num   int
+	str   string
+	isNum bool
+}
+
+// parseVersion splits a version into segments. Punctuation separates segments and is discarded.
+func parseVersion(s string) version {
+	var (
+		v   version
+		cur strings.Builder
+		num bool
+	)
+	flush := func() {
+		if cur.Len() == 0 {
+			return
+		}
+		seg := versionSeg{str: strings.ToLower(cur.String()), isNum: num}
+		if num {
+			n, err := strconv.Atoi(cur.String())
+			if err != nil {
+				// Too large for an int, compare it as a string instead.
+				seg.isNum = false
+			}
+			seg.num = n
+		}
+		v = append(v, seg)
+		cur.Reset()
+	}
+
+	for _, r := range s {
+		switch {
+		case unicode.IsDigit(r):
+			if !num {
+				flush()
+			}
+			num = true
+			cur.WriteRune(r)
+		case unicode.IsLetter(r):
+			if num {
+				flush()
+			}
+			num = false
+			cur.WriteRune(r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return v
+}
+
+// compareVersions returns -1 if a < b, 0 if a == b and 1 if a > b. Numeric segments are compared as
+// numbers and others lexically, with numeric segments being less than non-numeric ones. If one version
+// is a prefix of the other, the shorter one is less.
+func compareVersions(a, b version) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		x, y := a[i], b[i]
+		switch {
+		case x.isNum && y.isNum:
+			if x.num != y.num {
+				if x.num < y.num {
+					return -1
+				}
+				return 1
+			}
+		case x.isNum != y.isNum:
+			if x.isNum {
+				return -1
+			}
+			return 1
+		default:
+			if c := strings.Compare(x.str, y.str); c != 0 {
+				return c
+			}
+		}
+	}
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,272 @@
+package halfpike
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"18.1R3", "18.1R3", 0},
+		{"18.1R3", "18.1R2", 1},
+		{"18.1R3", "18.1R10", -1},
+		{"18.1R3", "18.1R3-S2", -1},
+		{"18.1R3-S2", "18.2R1", -1},
+		{"18.1", "18.1R1", -1},
+		{"15.2(7)E4", "15.2(4)E10", 1},
+		{"17.03.04a", "17.3.4", 1},
+		{"17.3.4a", "17.3.4A", 0},
+		{"12.2", "12.2.0", -1},
+	}
+
+	for _, test := range tests {
+		if got := compareVersions(parseVersion(test.a), parseVersion(test.b)); got != test.want {
+			t.Errorf("TestCompareVersions(%s, %s): got %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestNormalizeCommand(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"show interfaces", "show interfaces"},
+		{"  Show   Interfaces  Extensive ", "show interfaces extensive"},
+		{"show bgp neighbor | no-more", "show bgp neighbor"},
+		{"show configuration | display set", "show configuration | display set"},
+	}
+
+	for _, test := range tests {
+		if got := NormalizeCommand(test.in); got != test.want {
+			t.Errorf("TestNormalizeCommand(%s): got %q, want %q", test.in, got, test.want)
+		}
+	}
+}
+
+// regObj is a ParseObject that records the Registration it was created for.
+type regObj struct {
+	name  string
+	lines int
+}
+
+func (r *regObj) Start(ctx context.Context, p *Parser) ParseFn {
+	return func(ctx context.Context, p *Parser) ParseFn {
+		for {
+			line := p.Next()
+			if p.EOF(line) {
+				return nil
+			}
+			r.lines++
+		}
+	}
+}
+
+func (r *regObj) Validate() error {
+	if r.lines == 0 {
+		return errors.New("no lines")
+	}
+	return nil
+}
+
+func newRegObj(name string) func() ParseObject {
+	return func() ParseObject { return &regObj{name: name} }
+}
+
+func TestRegistryLookup(t *testing.T) {
+	r := NewRegistry()
+	regs := []struct {
+		vendor, command, constraint string
+	}{
+		{"Juniper", "show interfaces", ""},
+		{"juniper", "show interfaces", ">=18.1R3"},
+		{"juniper", "show interfaces", ">=18.1R3,<20.0"},
+		{"juniper", "show interfaces", ">=20.1"},
+		{"juniper", "show interfaces extensive", ""},
+		{"juniper", "show isis", ""},
+		{"cisco", "show version", "<15.0"},
+		{"cisco", "show version", ">=15.0"},
+	}
+	for _, reg := range regs {
+		if err := r.Register(reg.vendor, reg.command, reg.constraint, newRegObj(reg.constraint)); err != nil {
+			t.Fatalf("TestRegistryLookup: Register(%s, %s, %s): got err == %s", reg.vendor, reg.command, reg.constraint, err)
+		}
+	}
+
+	tests := []struct {
+		desc           string
+		vendor         string
+		version        string
+		command        string
+		wantCommand    string
+		wantConstraint string
+		wantErr        bool
+	}{
+		{
+			desc:        "No version uses the registration without a constraint",
+			vendor:      "juniper",
+			command:     "show interfaces",
+			wantCommand: "show interfaces",
+		},
+		{
+			desc:        "Version below all constraints",
+			vendor:      "juniper",
+			version:     "17.4R1",
+			command:     "show interfaces",
+			wantCommand: "show interfaces",
+		},
+		{
+			desc:           "More constraints win when the lower bound is the same",
+			vendor:         "juniper",
+			version:        "18.4R2-S1",
+			command:        "show interfaces",
+			wantCommand:    "show interfaces",
+			wantConstraint: ">=18.1R3,<20.0",
+		},
+		{
+			desc:           "Higher lower bound wins",
+			vendor:         "Juniper",
+			version:        "21.2R1",
+			command:        "SHOW  interfaces",
+			wantCommand:    "show interfaces",
+			wantConstraint: ">=20.1",
+		},
+		{
+			desc:           "Version between constraints",
+			vendor:         "juniper",
+			version:        "20.0R1",
+			command:        "show interfaces",
+			wantCommand:    "show interfaces",
+			wantConstraint: ">=18.1R3",
+		},
+		{
+			desc:        "Abbreviated command",
+			vendor:      "juniper",
+			command:     "sh int ext",
+			wantCommand: "show interfaces extensive",
+		},
+		{
+			desc:    "Ambiguous abbreviation",
+			vendor:  "juniper",
+			command: "sh i",
+			wantErr: true,
+		},
+		{
+			desc:           "Cisco version",
+			vendor:         "cisco",
+			version:        "15.2(7)E4",
+			command:        "show ver",
+			wantCommand:    "show version",
+			wantConstraint: ">=15.0",
+		},
+		{
+			desc:    "Cisco without a version does not match constraints",
+			vendor:  "cisco",
+			command: "show version",
+			wantErr: true,
+		},
+		{
+			desc:    "Unknown vendor",
+			vendor:  "arista",
+			command: "show version",
+			wantErr: true,
+		},
+		{
+			desc:    "Unknown command",
+			vendor:  "juniper",
+			command: "show route",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		reg, err := r.Lookup(test.vendor, test.version, test.command)
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestRegistryLookup(%s): got err == nil, want err != nil", test.desc)
+			continue
+		case err != nil && !test.wantErr:
+			t.Errorf("TestRegistryLookup(%s): got err == %s, want err == nil", test.desc, err)
+			continue
+		case err != nil:
+			continue
+		}
+
+		if reg.Command != test.wantCommand || reg.Constraint != test.wantConstraint {
+			t.Errorf("TestRegistryLookup(%s): got (%q, %q), want (%q, %q)", test.desc, reg.Command, reg.Constraint, test.wantCommand, test.wantConstraint)
+		}
+		if reg.New().(*regObj).name != test.wantConstraint {
+			t.Errorf("TestRegistryLookup(%s): Registration.New() did not return the registered ParseObject", test.desc)
+		}
+	}
+}
+
+func TestRegistryRegister(t *testing.T) {
+	r := NewRegistry()
+	for _, c := range [][2]string{{"show version", ">=18.1"}, {"show route", "=18.1"}, {"show route", ">=19.1,<20.0"}} {
+		if err := r.Register("juniper", c[0], c[1], newRegObj("")); err != nil {
+			t.Fatalf("TestRegistryRegister: got err == %s", err)
+		}
+	}
+
+	tests := []struct {
+		desc                        string
+		vendor, command, constraint string
+		newFn                       func() ParseObject
+	}{
+		{"Duplicate", "juniper", "show  version", ">=18.1", newRegObj("")},
+		{"Duplicate written differently", "juniper", "show version", " >= 18.1 ", newRegObj("")},
+		{"Duplicate with == operator", "juniper", "show route", "==18.1", newRegObj("")},
+		{"Duplicate without an operator", "juniper", "show route", "18.1", newRegObj("")},
+		{"Duplicate in another order", "juniper", "show route", "<20.0,>=19.1", newRegObj("")},
+		{"Empty vendor", "", "show version", "", newRegObj("")},
+		{"Empty command", "juniper", " ", "", newRegObj("")},
+		{"Nil newFn", "juniper", "show route", "", nil},
+		{"Empty constraint version", "juniper", "show route", ">=", newRegObj("")},
+	}
+
+	for _, test := range tests {
+		if err := r.Register(test.vendor, test.command, test.constraint, test.newFn); err == nil {
+			t.Errorf("TestRegistryRegister(%s): got err == nil, want err != nil", test.desc)
+		}
+	}
+}
+
+func TestRegistryParse(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register("juniper", "show version", "", newRegObj("any")); err != nil {
+		t.Fatalf("TestRegistryParse: got err == %s", err)
+	}
+
+	obj, reg, err := r.Parse(context.Background(), "juniper", "18.1R3", "show version", "Hostname: router1\nModel: mx960\n")
+	if err != nil {
+		t.Fatalf("TestRegistryParse: got err == %s", err)
+	}
+	if reg.Command != "show version" {
+		t.Errorf("TestRegistryParse: got Registration.Command %q, want %q", reg.Command, "show version")
+	}
+	if got := obj.(*regObj); got.name != "any" || got.lines != 2 {
+		t.Errorf("TestRegistryParse: got %+v, want name 'any' with 2 lines", got)
+	}
+
+	// Each call must get a new ParseObject.
+	obj2, _, err := r.Parse(context.Background(), "juniper", "18.1R3", "show version", "Hostname: router1\n")
+	if err != nil {
+		t.Fatalf("TestRegistryParse: got err == %s", err)
+	}
+	if obj == obj2 || obj2.(*regObj).lines != 1 {
+		t.Errorf("TestRegistryParse: Parse() did not use a new ParseObject")
+	}
+
+	if _, reg, err := r.Parse(context.Background(), "juniper", "18.1R3", "show version", ""); err == nil || reg == nil {
+		t.Errorf("TestRegistryParse(validation failure): got (reg == %v, err == %v), want reg != nil and err != nil", reg, err)
+	}
+
+	if _, _, err := r.Parse(context.Background(), "juniper", "18.1R3", "show route", "x\n"); !errors.Is(err, ErrNoParser) {
+		t.Errorf("TestRegistryParse(unknown command): got err == %v, want ErrNoParser", err)
+	}
+}
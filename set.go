@@ -0,0 +1,130 @@
+package halfpike
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// WithRequireSet causes Parse() to return an *UnsetError, before Validate() is called, if a field of the
+// ParseObject with an `hp:"required"` or `hp:"nonzero"` tag was never assigned with Set() or another
+// Parser helper that records assignments. This catches the fields a parser forgot to fill in, even
+// when their zero value is a legitimate value, such as a count of 0. See Parser.Unset().
+func WithRequireSet() Option {
+	return func(p *Parser) {
+		p.requireSet = true
+	}
+}
+
+// UnsetError is returned by Parse() when WithRequireSet() is used and required fields were never set.
+type UnsetError struct {
+	// Fields are the paths of the fields, such as "Peers[0].PeerAS".
+	Fields []string
+}
+
+// Error implements error.Error().
+func (u *UnsetError) Error() string {
+	return fmt.Sprintf("required fields were never set: %s", strings.Join(u.Fields, ", "))
+}
+
+// Set stores "v" in "dst" and records that "dst" was set, so that a field that was parsed as its zero
+// value can be told apart from one that was never parsed:
+//
+//	halfpike.Set(p, &n.PeerAS, as)
+//
+// Assignments are tracked by the address of the field, so the struct holding the field must not be
+// copied after it is set. For example, use a []*Neighbor instead of a []Neighbor that is appended to.
+func Set[T any](p *Parser, dst *T, v T) {
+	*dst = v
+	p.MarkSet(dst)
+}
+
+// MarkSet records that each of "ptrs", which are pointers to fields, was set. Use this when storing
+// a value without Set().
+func (p *Parser) MarkSet(ptrs ...interface{}) {
+	if p.assigned == nil {
+		p.assigned = map[interface{}]bool{}
+	}
+	for _, ptr := range ptrs {
+		p.assigned[ptr] = true
+	}
+}
+
+// IsSet reports if "ptr", a pointer to a field, was set with Set(), MarkSet() or Parser.MatchInto().
+func (p *Parser) IsSet(ptr interface{}) bool {
+	return p.assigned[ptr]
+}
+
+// Unset returns the paths of the fields in "obj", a pointer to a struct, that have an `hp:"required"` or
+// `hp:"nonzero"` tag but were never set. Like ValidateStruct(), it recurses into nested structs, pointers
+// to structs and the elements of slices and arrays. The struct values of a map cannot be set through
+// a pointer, so they are skipped, but pointers to structs in a map are checked.
+func (p *Parser) Unset(obj interface{}) ([]string, error) {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return nil, fmt.Errorf("Parser.Unset: must be passed a non-nil pointer, not %T", obj)
+	}
+
+	us := &unsetState{p: p, seen: map[uintptr]bool{}}
+	if err := us.walk("", v); err != nil {
+		return nil, err
+	}
+	return us.unset, nil
+}
+
+// unsetState is the state of a Parser.Unset() call.
+type unsetState struct {
+	p     *Parser
+	unset []string
+	seen  map[uintptr]bool
+}
+
+func (us *unsetState) walk(path string, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Interface:
+		if !v.IsNil() {
+			return us.walk(path, v.Elem())
+		}
+	case reflect.Ptr:
+		if v.IsNil() || us.seen[v.Pointer()] {
+			return nil
+		}
+		us.seen[v.Pointer()] = true
+		return us.walk(path, v.Elem())
+	case reflect.Struct:
+		if !v.CanAddr() {
+			return nil
+		}
+		fields, err := structRules(v.Type())
+		if err != nil {
+			return err
+		}
+		for _, f := range fields {
+			fv := v.Field(f.index)
+			fpath := joinPath(path, f.name)
+			if f.required() && !us.p.IsSet(fv.Addr().Interface()) {
+				us.unset = append(us.unset, fpath)
+			}
+			if f.skip {
+				continue
+			}
+			if err := us.walk(fpath, fv); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := us.walk(fmt.Sprintf("%s[%d]", path, i), v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := us.walk(fmt.Sprintf("%s[%v]", path, iter.Key()), iter.Value()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package halfpike
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+type setStats struct {
+	Received   int `hp:"required"`
+	Suppressed int `hp:"required"`
+	Active     int
+}
+
+type setPeer struct {
+	Name   string `hp:"required"`
+	Stats  setStats
+	Tables []*setStats
+	ByName map[string]*setStats
+	Values map[string]setStats
+	Skip   *setStats `hp:"-"`
+}
+
+func TestUnset(t *testing.T) {
+	p := NewParser()
+	peer := &setPeer{
+		Tables: []*setStats{{}, {}},
+		ByName: map[string]*setStats{"inet.0": {}},
+		Values: map[string]setStats{"inet6.0": {}},
+		Skip:   &setStats{},
+	}
+
+	Set(p, &peer.Name, "peer1")
+	Set(p, &peer.Stats.Received, 0)
+	Set(p, &peer.Tables[0].Received, 10)
+	peer.Tables[0].Suppressed = 0
+	p.MarkSet(&peer.Tables[0].Suppressed, &peer.ByName["inet.0"].Received)
+
+	if peer.Name != "peer1" || peer.Tables[0].Received != 10 {
+		t.Errorf("TestUnset: Set() did not store the value: %+v", peer)
+	}
+	if !p.IsSet(&peer.Stats.Received) || p.IsSet(&peer.Stats.Suppressed) {
+		t.Errorf("TestUnset: IsSet() got (%v, %v), want (true, false)", p.IsSet(&peer.Stats.Received), p.IsSet(&peer.Stats.Suppressed))
+	}
+
+	got, err := p.Unset(peer)
+	if err != nil {
+		t.Fatalf("TestUnset: got err == %s", err)
+	}
+	want := []string{
+		"Stats.Suppressed",
+		"Tables[1].Received",
+		"Tables[1].Suppressed",
+		"ByName[inet.0].Suppressed",
+	}
+	if diff := pretty.Compare(want, got); diff != "" {
+		t.Errorf("TestUnset: -want/+got:\n%s", diff)
+	}
+
+	if _, err := p.Unset(*peer); err == nil {
+		t.Errorf("TestUnset(not a pointer): got err == nil, want err != nil")
+	}
+}
+
+// setObj is a ParseObject that sets its fields with Set() and Parser.MatchInto().
+type setObj struct {
+	Name  string `hp:"required"`
+	Count int    `hp:"required"`
+	MTU   int    `hp:"required" re:"mtu"`
+}
+
+var setMTURE = regexp.MustCompile(`MTU: (?P<mtu>\d+)`)
+
+func (s *setObj) Start(ctx context.Context, p *Parser) ParseFn {
+	for {
+		line := p.Next()
+		switch {
+		case p.EOF(line):
+			return nil
+		case p.IsAtStart(line, []string{"Name:"}):
+			Set(p, &s.Name, line.Items[1].Val)
+		case p.IsAtStart(line, []string{"Count:"}):
+			n, err := line.Items[1].ToInt()
+			if err != nil {
+				return p.Errorf("%s", err)
+			}
+			Set(p, &s.Count, n)
+		case p.IsAtStart(line, []string{"MTU:"}):
+			if err := p.MatchInto("", setMTURE, line, s); err != nil {
+				return p.Errorf("%s", err)
+			}
+		}
+	}
+}
+
+func (s *setObj) Validate() error {
+	return nil
+}
+
+func TestParseRequireSet(t *testing.T) {
+	tests := []struct {
+		desc    string
+		content string
+		opts    []Option
+		want    []string
+	}{
+		{
+			desc:    "All set, including a zero value",
+			content: "Name: router1\nCount: 0\nMTU: 1514\n",
+			opts:    []Option{WithRequireSet()},
+		},
+		{
+			desc:    "Count never set",
+			content: "Name: router1\nMTU: 1514\n",
+			opts:    []Option{WithRequireSet()},
+			want:    []string{"Count"},
+		},
+		{
+			desc:    "Nothing set",
+			content: "Other: stuff\n",
+			opts:    []Option{WithRequireSet()},
+			want:    []string{"Name", "Count", "MTU"},
+		},
+		{
+			desc:    "Without WithRequireSet",
+			content: "Other: stuff\n",
+		},
+	}
+
+	for _, test := range tests {
+		err := Parse(context.Background(), test.content, &setObj{}, test.opts...)
+		var got []string
+		if err != nil {
+			var uErr *UnsetError
+			if !errors.As(err, &uErr) {
+				t.Errorf("TestParseRequireSet(%s): got err == %s, want *UnsetError", test.desc, err)
+				continue
+			}
+			got = uErr.Fields
+		}
+		if diff := pretty.Compare(test.want, got); diff != "" {
+			t.Errorf("TestParseRequireSet(%s): -want/+got:\n%s", test.desc, diff)
+		}
+	}
+
+	// Assignments must not carry over when a Parser is reused.
+	p := NewParser(WithRequireSet())
+	if err := p.Parse(context.Background(), "Name: router1\nCount: 0\nMTU: 1514\n", &setObj{}); err != nil {
+		t.Fatalf("TestParseRequireSet(reuse): got err == %s", err)
+	}
+	if err := p.Parse(context.Background(), "Name: router1\n", &setObj{}); err == nil {
+		t.Errorf("TestParseRequireSet(reuse): got err == nil, want *UnsetError")
+	}
+}
@@ -0,0 +1,139 @@
+package halfpike
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Segment is a command and its output within a capture of several commands, as returned by SplitCommands().
+type Segment struct {
+	// Prompt is the prompt that the command was entered at, such as "user@router> ".
+	Prompt string
+	// Command is the command, such as "show version".
+	Command string
+	// Output is the output of the command. Each line ends with "\n".
+	Output string
+	// StartLine is the Line.LineNum of the prompt line in the capture. The first line of Output
+	// is StartLine+1.
+	StartLine int
+}
+
+// SplitCommands splits a capture of several commands, such as a file with the output of
+// "router> show version" followed by "router> show interfaces", into a Segment per command.
+// A line that starts with a match for one of "prompts" starts a new Segment, with the text
+// after the match being the command. See JunosPrompt and IOSPrompt.
+//
+// A matching line with no command, such as the final prompt of a session or a JunosBanner,
+// ends the current Segment and the lines that follow it are discarded until the next command.
+// Lines before the first command are also discarded.
+//
+// The capture is decoded the same way as Parse() decodes content, so an error is returned if
+// it is not valid UTF-8.
+func SplitCommands(content string, prompts ...*regexp.Regexp) ([]Segment, error) {
+	content, err := decode(content, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		segs   []Segment
+		cur    *Segment
+		output strings.Builder
+	)
+	end := func() {
+		if cur != nil {
+			cur.Output = output.String()
+			segs = append(segs, *cur)
+		}
+		cur = nil
+		output.Reset()
+	}
+
+	lines := strings.Split(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+Lines:
+	for i, l := range lines {
+		text := strings.TrimRight(l, "\r")
+		for _, re := range prompts {
+			loc := re.FindStringIndex(text)
+			if loc == nil || loc[0] != 0 {
+				continue
+			}
+			end()
+			if cmd := strings.TrimSpace(text[loc[1]:]); cmd != "" {
+				cur = &Segment{Prompt: text[:loc[1]], Command: cmd, StartLine: i}
+			}
+			continue Lines
+		}
+		if cur != nil {
+			output.WriteString(l)
+			output.WriteString("\n")
+		}
+	}
+	end()
+
+	return segs, nil
+}
+
+// WithLineOffset adds "offset" to the Line.LineNum of every line. This is used to parse part of a
+// larger content, such as a Segment, while reporting line numbers in the larger content.
+func WithLineOffset(offset int) Option {
+	return func(p *Parser) {
+		p.lineOffset = offset
+	}
+}
+
+// ParseSegment parses the Output of "seg" into "parseObject". Line.LineNum, and so errors that report it,
+// refers to the line in the capture that "seg" was split from.
+func ParseSegment(ctx context.Context, seg Segment, parseObject ParseObject, options ...Option) error {
+	options = append(options[:len(options):len(options)], WithLineOffset(seg.StartLine+1))
+	if err := Parse(ctx, seg.Output, parseObject, options...); err != nil {
+		return fmt.Errorf("command %q at line %d: %w", seg.Command, seg.StartLine, err)
+	}
+	return nil
+}
+
+// SegmentResult is the result of parsing a Segment with Registry.ParseSegments().
+type SegmentResult struct {
+	// Segment is the Segment that was parsed.
+	Segment Segment
+	// Object is the ParseObject the Segment was parsed into. This is nil if Err != nil.
+	Object ParseObject
+	// Registration is the Registration used to parse the Segment. This is nil if no parser was registered.
+	Registration *Registration
+	// Err is the error parsing the Segment, if any. If no parser is registered for the Segment's
+	// command, this wraps ErrNoParser.
+	Err error
+}
+
+// ParseSegments parses each Segment with the parser registered for the vendor, OS version and the
+// Segment's Command. Results are in the same order as "segs". Line numbers refer to the capture the
+// Segments were split from, as with ParseSegment().
+func (r *Registry) ParseSegments(ctx context.Context, vendor, version string, segs []Segment, options ...Option) []SegmentResult {
+	results := make([]SegmentResult, 0, len(segs))
+	for _, seg := range segs {
+		res := SegmentResult{Segment: seg}
+
+		reg, err := r.Lookup(vendor, version, seg.Command)
+		if err != nil {
+			res.Err = err
+			results = append(results, res)
+			continue
+		}
+		res.Registration = reg
+
+		obj := reg.New()
+		if err := ParseSegment(ctx, seg, obj, options...); err != nil {
+			res.Err = err
+		} else {
+			res.Object = obj
+		}
+		results = append(results, res)
+	}
+	return results
+}
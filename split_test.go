@@ -0,0 +1,141 @@
+package halfpike
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+const junosCapture = `
+{master:0}
+user@router> show version
+Hostname: router
+Model: mx960
+
+{master:0}
+user@router> show interfaces terse | no-more
+Interface               Admin Link Proto    Local
+ge-0/0/0                up    up
+
+{master:0}
+user@router>
+`
+
+func TestSplitCommands(t *testing.T) {
+	tests := []struct {
+		desc    string
+		content string
+		prompts []*regexp.Regexp
+		want    []Segment
+	}{
+		{
+			desc:    "Junos with banners",
+			content: junosCapture,
+			prompts: []*regexp.Regexp{JunosPrompt, JunosBanner},
+			want: []Segment{
+				{
+					Prompt:    "user@router>",
+					Command:   "show version",
+					Output:    "Hostname: router\nModel: mx960\n\n",
+					StartLine: 2,
+				},
+				{
+					Prompt:    "user@router>",
+					Command:   "show interfaces terse | no-more",
+					Output:    "Interface               Admin Link Proto    Local\nge-0/0/0                up    up\n\n",
+					StartLine: 7,
+				},
+			},
+		},
+		{
+			desc:    "IOS with Windows line endings and a preamble",
+			content: "login banner\r\nrouter#show version\r\nCisco IOS Software\r\nrouter#show inventory\r\nrouter#\r\n",
+			prompts: []*regexp.Regexp{IOSPrompt},
+			want: []Segment{
+				{
+					Prompt:    "router#",
+					Command:   "show version",
+					Output:    "Cisco IOS Software\r\n",
+					StartLine: 1,
+				},
+				{
+					Prompt:    "router#",
+					Command:   "show inventory",
+					StartLine: 3,
+				},
+			},
+		},
+		{
+			desc:    "No prompts",
+			content: "Hostname: router\n",
+			prompts: []*regexp.Regexp{JunosPrompt},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := SplitCommands(test.content, test.prompts...)
+		if err != nil {
+			t.Errorf("TestSplitCommands(%s): got err == %s", test.desc, err)
+			continue
+		}
+		if diff := pretty.Compare(test.want, got); diff != "" {
+			t.Errorf("TestSplitCommands(%s): -want/+got:\n%s", test.desc, diff)
+		}
+	}
+
+	if _, err := SplitCommands("router#show version\n\xff\n", IOSPrompt); err == nil {
+		t.Errorf("TestSplitCommands(invalid UTF-8): got err == nil, want err != nil")
+	}
+}
+
+func TestParseSegment(t *testing.T) {
+	segs, err := SplitCommands(junosCapture, JunosPrompt, JunosBanner)
+	if err != nil {
+		t.Fatalf("TestParseSegment: got err == %s", err)
+	}
+
+	obj := &lineObj{}
+	if err := ParseSegment(context.Background(), segs[1], obj); err != nil {
+		t.Fatalf("TestParseSegment: got err == %s", err)
+	}
+	if diff := pretty.Compare([]int{8, 9}, obj.nums); diff != "" {
+		t.Errorf("TestParseSegment: LineNums -want/+got:\n%s", diff)
+	}
+
+	// Errors from a ParseFn report the line in the capture.
+	seg := Segment{Command: "show bad", Output: "Address: 10.0.0.1\nbad\n", StartLine: 20}
+	err = ParseSegment(context.Background(), seg, &batchObj{})
+	if err == nil || !strings.Contains(err.Error(), "[Line 22]") {
+		t.Errorf("TestParseSegment: got err == %v, want error for [Line 22]", err)
+	}
+}
+
+func TestRegistryParseSegments(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register("juniper", "show version", "", newRegObj("version")); err != nil {
+		t.Fatalf("TestRegistryParseSegments: got err == %s", err)
+	}
+
+	segs, err := SplitCommands(junosCapture, JunosPrompt, JunosBanner)
+	if err != nil {
+		t.Fatalf("TestRegistryParseSegments: got err == %s", err)
+	}
+
+	results := r.ParseSegments(context.Background(), "juniper", "18.1R3", segs)
+	if len(results) != 2 {
+		t.Fatalf("TestRegistryParseSegments: got %d results, want 2", len(results))
+	}
+	if results[0].Err != nil {
+		t.Fatalf("TestRegistryParseSegments(show version): got err == %s", results[0].Err)
+	}
+	if got := results[0].Object.(*regObj); got.name != "version" || got.lines != 2 {
+		t.Errorf("TestRegistryParseSegments(show version): got %+v, want name 'version' with 2 lines", got)
+	}
+	if !errors.Is(results[1].Err, ErrNoParser) || results[1].Object != nil {
+		t.Errorf("TestRegistryParseSegments(show interfaces): got err == %v, want ErrNoParser", results[1].Err)
+	}
+}
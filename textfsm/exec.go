@@ -0,0 +1,259 @@
+package textfsm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/johnsiilver/halfpike"
+)
+
+// ExecOptions are options for Template.Execute() and Template.RecordSet().
+type ExecOptions struct {
+	// Optional are the names of Values that may never match. Other Values that never match
+	// cause an error if any records are produced.
+	Optional []string
+	// ParseOptions are passed to halfpike.Parse() by Execute().
+	ParseOptions []halfpike.Option
+}
+
+// UnmatchedError is returned when Values never matched the content.
+type UnmatchedError struct {
+	// Values are the names of the Values, in template order.
+	Values []string
+}
+
+// Error implements error.Error().
+func (u *UnmatchedError) Error() string {
+	return fmt.Sprintf("Values never matched the content: %s", strings.Join(u.Values, ", "))
+}
+
+// Execute runs the template on "content" and returns a Record for each record the template produced.
+// Each field is a string, or a list of strings for a List Value, with the LineNum it matched on.
+func (t *Template) Execute(ctx context.Context, content string, opts ExecOptions) (*halfpike.RecordSet, error) {
+	rs := t.RecordSet(opts)
+	if err := halfpike.Parse(ctx, content, rs, opts.ParseOptions...); err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+// RecordSet returns a new *halfpike.RecordSet that runs the template when passed to halfpike.Parse().
+// This allows a template to be used anywhere a halfpike.ParseObject is, such as with a halfpike.Registry.
+// opts.ParseOptions is not used.
+func (t *Template) RecordSet(opts ExecOptions) *halfpike.RecordSet {
+	rs := &halfpike.RecordSet{}
+	e := &execution{t: t, rs: rs, optional: map[string]bool{}}
+	for _, name := range opts.Optional {
+		e.optional[name] = true
+	}
+	rs.StartFn = e.start
+	rs.ValidateFn = e.validate
+	return rs
+}
+
+// cell is the state of a Value while executing.
+type cell struct {
+	set     bool
+	v       string
+	lineNum int
+	list    []halfpike.Value
+}
+
+func (c cell) empty() bool {
+	return !c.set && len(c.list) == 0
+}
+
+// execution is a single run of a Template.
+type execution struct {
+	t        *Template
+	rs       *halfpike.RecordSet
+	optional map[string]bool
+
+	state   *State
+	cur     []cell
+	rows    [][]cell
+	matched []bool
+}
+
+func (e *execution) start(ctx context.Context, p *halfpike.Parser) halfpike.ParseFn {
+	e.state = e.t.States["Start"]
+	e.cur = make([]cell, len(e.t.Values))
+	e.rows = nil
+	e.matched = make([]bool, len(e.t.Values))
+	return e.lines
+}
+
+// lines runs the current state's rules on each line.
+func (e *execution) lines(ctx context.Context, p *halfpike.Parser) halfpike.ParseFn {
+	for {
+		line := p.Next()
+		if p.EOF(line) {
+			// Without an EOF state, TextFSM records the Values at the end of input.
+			if _, ok := e.t.States["EOF"]; !ok {
+				e.record()
+			}
+			return e.finish
+		}
+
+		text := lineText(line)
+	Rules:
+		for _, rule := range e.state.Rules {
+			m := rule.Regex.FindStringSubmatchIndex(text)
+			if m == nil {
+				continue
+			}
+			e.assign(rule, text, m, line.LineNum)
+
+			if rule.Error {
+				msg := rule.ErrorMsg
+				if msg == "" {
+					msg = "state " + e.state.Name
+				}
+				return p.Errorf("[Line %d]: template line %d: Error: %s: %q", line.LineNum, rule.LineNum, msg, text)
+			}
+
+			switch rule.RecordOp {
+			case Record:
+				e.record()
+			case Clear:
+				e.clear(false)
+			case Clearall:
+				e.clear(true)
+			}
+
+			switch rule.NewState {
+			case "":
+			case "End":
+				return e.finish
+			case "EOF":
+				// As at the end of input, TextFSM records the Values if there is no EOF state.
+				if _, ok := e.t.States["EOF"]; !ok {
+					e.record()
+				}
+				return e.finish
+			default:
+				e.state = e.t.States[rule.NewState]
+			}
+
+			if rule.LineOp == Next {
+				break Rules
+			}
+		}
+	}
+}
+
+// lineText returns the text of the line as it was in the content. Line.Raw can hold a skipped
+// blank line that only had whitespace, so only the text after the last "\n" is used.
+func lineText(line halfpike.Line) string {
+	raw := strings.TrimRight(line.Raw, "\r\n")
+	if i := strings.LastIndex(raw, "\n"); i >= 0 {
+		raw = raw[i+1:]
+	}
+	return raw
+}
+
+// assign sets the Values in the rule's named groups that took part in the match.
+func (e *execution) assign(rule *Rule, text string, m []int, lineNum int) {
+	for gi, name := range rule.Regex.SubexpNames() {
+		if name == "" || m[gi*2] < 0 {
+			continue
+		}
+		vi, ok := e.t.valueIndex[name]
+		if !ok {
+			continue
+		}
+		v := text[m[gi*2]:m[gi*2+1]]
+		val := e.t.Values[vi]
+		e.matched[vi] = true
+
+		if val.Has(List) {
+			e.cur[vi].list = append(e.cur[vi].list, halfpike.Value{V: v, LineNum: lineNum})
+		} else {
+			e.cur[vi] = cell{set: true, v: v, lineNum: lineNum}
+		}
+
+		if val.Has(Fillup) {
+			for i := len(e.rows) - 1; i >= 0 && e.rows[i][vi].empty(); i-- {
+				e.rows[i][vi] = e.cur[vi]
+			}
+		}
+	}
+}
+
+// record saves the current Values as a row, following TextFSM's rules for skipping rows.
+func (e *execution) record() {
+	allEmpty := true
+	for i, v := range e.t.Values {
+		if e.cur[i].empty() {
+			if v.Has(Required) {
+				e.clear(false)
+				return
+			}
+			continue
+		}
+		allEmpty = false
+	}
+	if allEmpty {
+		return
+	}
+
+	row := make([]cell, len(e.cur))
+	copy(row, e.cur)
+	e.rows = append(e.rows, row)
+	e.clear(false)
+}
+
+// clear clears the Values that are not Filldown, or all Values if "all" is set.
+func (e *execution) clear(all bool) {
+	for i, v := range e.t.Values {
+		if all || !v.Has(Filldown) {
+			e.cur[i] = cell{}
+		}
+	}
+}
+
+// finish converts the rows to Records.
+func (e *execution) finish(ctx context.Context, p *halfpike.Parser) halfpike.ParseFn {
+	for _, row := range e.rows {
+		rec := e.rs.New()
+		for i, v := range e.t.Values {
+			c := row[i]
+			var err error
+			switch {
+			case v.Has(List) && len(c.list) > 0:
+				list := make([]halfpike.Value, len(c.list))
+				copy(list, c.list)
+				lineNum := 0
+				if len(list) > 0 {
+					lineNum = list[0].LineNum
+				}
+				err = rec.Set(v.Name, list, lineNum)
+			case c.set:
+				err = rec.Set(v.Name, c.v, c.lineNum)
+			}
+			if err != nil {
+				return p.Errorf("%s", err)
+			}
+		}
+	}
+	return nil
+}
+
+// validate returns an *UnmatchedError if records were produced but Values never matched.
+func (e *execution) validate(rs *halfpike.RecordSet) error {
+	if len(rs.Records) == 0 {
+		return nil
+	}
+
+	var missing []string
+	for i, v := range e.t.Values {
+		if !e.matched[i] && !e.optional[v.Name] {
+			missing = append(missing, v.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return &UnmatchedError{Values: missing}
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+package textfsm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/johnsiilver/halfpike"
+)
+
+func mustTemplate(t *testing.T, s string) *Template {
+	t.Helper()
+	tmpl, err := ParseTemplate(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("ParseTemplate(): got err == %s", err)
+	}
+	return tmpl
+}
+
+func TestExecuteFile(t *testing.T) {
+	f, err := os.Open("testdata/cisco_ios_show_ip_interface_brief.textfsm")
+	if err != nil {
+		t.Fatalf("TestExecuteFile: got err == %s", err)
+	}
+	defer f.Close()
+	tmpl, err := ParseTemplate(f)
+	if err != nil {
+		t.Fatalf("TestExecuteFile: got err == %s", err)
+	}
+	content, err := os.ReadFile("testdata/cisco_ios_show_ip_interface_brief.txt")
+	if err != nil {
+		t.Fatalf("TestExecuteFile: got err == %s", err)
+	}
+
+	rs, err := tmpl.Execute(context.Background(), string(content), ExecOptions{})
+	if err != nil {
+		t.Fatalf("TestExecuteFile: got err == %s", err)
+	}
+	b, err := json.Marshal(rs)
+	if err != nil {
+		t.Fatalf("TestExecuteFile: got err == %s", err)
+	}
+	want := `[{"INTF":"GigabitEthernet0/0","IPADDR":"10.1.1.1","STATUS":"up","PROTO":"up"},` +
+		`{"INTF":"GigabitEthernet0/1","IPADDR":"unassigned","STATUS":"administratively down","PROTO":"down"},` +
+		`{"INTF":"Loopback0","IPADDR":"192.0.2.1","STATUS":"up","PROTO":"up"}]`
+	if string(b) != want {
+		t.Errorf("TestExecuteFile: got:\n%s\nwant:\n%s", b, want)
+	}
+	if v, _ := rs.Records[2].Get("INTF"); v.LineNum != 4 {
+		t.Errorf("TestExecuteFile: got LineNum %d for Loopback0, want 4", v.LineNum)
+	}
+
+	// A line the template does not expect triggers its Error rule.
+	_, err = tmpl.Execute(context.Background(), string(content)+"Tunnel0 is broken\n", ExecOptions{})
+	if err == nil || !strings.Contains(err.Error(), "unrecognized interface line") || !strings.Contains(err.Error(), "[Line 5]") {
+		t.Errorf("TestExecuteFile(Error rule): got err == %v, want the template's error for line 5", err)
+	}
+}
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		desc    string
+		tmpl    string
+		content string
+		opts    ExecOptions
+		want    string
+		err     bool
+	}{
+		{
+			desc: "Filldown, List and Required",
+			tmpl: `Value Filldown Chassis (\S+)
+Value Required Slot (\d+)
+Value List Ports (\S+)
+
+Start
+  ^Chassis ${Chassis}
+  ^Slot -> Continue.Record
+  ^Slot ${Slot}
+  ^Port ${Ports}
+`,
+			content: "Chassis mx960\nSlot 0\nPort ge-0/0/0\nPort ge-0/0/1\nSlot 1\nPort xe-1/0/0\n",
+			// The first Record has no Slot, so it is dropped as TextFSM does.
+			want: `[{"Chassis":"mx960","Slot":"0","Ports":["ge-0/0/0","ge-0/0/1"]},{"Chassis":"mx960","Slot":"1","Ports":["xe-1/0/0"]}]`,
+		},
+		{
+			desc: "Values without a match are not set",
+			tmpl: `Value Name (\S+)
+Value Description (.+)
+
+Start
+  ^Interface -> Continue.Record
+  ^Interface ${Name}
+  ^  Description ${Description}
+`,
+			content: "Interface ge-0/0/0\n  Description uplink\nInterface ge-0/0/1\n",
+			want:    `[{"Name":"ge-0/0/0","Description":"uplink"},{"Name":"ge-0/0/1"}]`,
+		},
+		{
+			desc: "Fillup",
+			tmpl: `Value Name (\S+)
+Value Fillup Area (\S+)
+
+Start
+  ^Interface ${Name} -> Record
+  ^Area ${Area} -> Record
+`,
+			content: "Interface ge-0/0/0\nInterface ge-0/0/1\nArea 0.0.0.0\n",
+			want:    `[{"Name":"ge-0/0/0","Area":"0.0.0.0"},{"Name":"ge-0/0/1","Area":"0.0.0.0"},{"Area":"0.0.0.0"}]`,
+		},
+		{
+			desc: "States, Clearall and End",
+			tmpl: `Value Filldown Group (\S+)
+Value Peer (\S+)
+
+Start
+  ^Group ${Group} -> Peers
+
+Peers
+  ^Peer ${Peer} -> Record
+  ^Reset -> Clearall
+  ^Stop -> End
+`,
+			content: "Group a\nPeer 10.0.0.1\nReset\nPeer 10.0.0.2\nStop\nPeer 10.0.0.3\n",
+			want:    `[{"Group":"a","Peer":"10.0.0.1"},{"Peer":"10.0.0.2"}]`,
+		},
+		{
+			desc: "Transition to EOF records",
+			tmpl: `Value Name (\S+)
+
+Start
+  ^Name ${Name}
+  ^Done -> EOF
+`,
+			content: "Name a\nDone\nName b\n",
+			want:    `[{"Name":"a"}]`,
+		},
+		{
+			desc: "Transition to an empty EOF state does not record",
+			tmpl: `Value Name (\S+)
+
+Start
+  ^Name ${Name}
+  ^Done -> EOF
+
+EOF
+`,
+			content: "Name a\nDone\nName b\n",
+			want:    `[]`,
+		},
+		{
+			desc: "Empty EOF state suppresses the implicit Record",
+			tmpl: `Value Name (\S+)
+
+Start
+  ^Name ${Name}
+
+EOF
+`,
+			content: "Name a\n",
+			want:    `[]`,
+		},
+		{
+			desc: "Implicit Record at EOF",
+			tmpl: `Value Name (\S+)
+
+Start
+  ^Name ${Name}
+`,
+			content: "Name a\n",
+			want:    `[{"Name":"a"}]`,
+		},
+		{
+			desc: "Value that never matched",
+			tmpl: `Value Name (\S+)
+Value MTU (\d+)
+
+Start
+  ^Interface ${Name} -> Record
+  ^  MTU ${MTU}
+`,
+			content: "Interface ge-0/0/0\n  MTU: 1500\n",
+			err:     true,
+		},
+		{
+			desc: "Optional Value that never matched",
+			tmpl: `Value Name (\S+)
+Value MTU (\d+)
+
+Start
+  ^Interface ${Name} -> Record
+  ^  MTU ${MTU}
+`,
+			content: "Interface ge-0/0/0\n  MTU: 1500\n",
+			opts:    ExecOptions{Optional: []string{"MTU"}},
+			want:    `[{"Name":"ge-0/0/0"}]`,
+		},
+	}
+
+	for _, test := range tests {
+		tmpl := mustTemplate(t, test.tmpl)
+		rs, err := tmpl.Execute(context.Background(), test.content, test.opts)
+		switch {
+		case err == nil && test.err:
+			t.Errorf("TestExecute(%s): got err == nil, want err != nil", test.desc)
+			continue
+		case err != nil && !test.err:
+			t.Errorf("TestExecute(%s): got err == %s", test.desc, err)
+			continue
+		case err != nil:
+			continue
+		}
+
+		b, err := json.Marshal(rs)
+		if err != nil {
+			t.Fatalf("TestExecute(%s): got err == %s", test.desc, err)
+		}
+		if string(b) != test.want {
+			t.Errorf("TestExecute(%s): got:\n%s\nwant:\n%s", test.desc, b, test.want)
+		}
+	}
+}
+
+func TestUnmatchedError(t *testing.T) {
+	tmpl := mustTemplate(t, "Value A (\\S+)\nValue B (\\S+)\nValue C (\\S+)\n\nStart\n  ^A ${A} -> Record\n  ^B ${B}\n  ^C ${C}\n")
+	_, err := tmpl.Execute(context.Background(), "A 1\n", ExecOptions{})
+
+	uErr := &UnmatchedError{}
+	if !errors.As(err, &uErr) {
+		t.Fatalf("TestUnmatchedError: got err == %v, want *UnmatchedError", err)
+	}
+	if strings.Join(uErr.Values, ",") != "B,C" {
+		t.Errorf("TestUnmatchedError: got Values %v, want [B C]", uErr.Values)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	tmpl := mustTemplate(t, "Value Name (\\S+)\n\nStart\n  ^Name ${Name} -> Record\n")
+
+	r := halfpike.NewRegistry()
+	err := r.Register("cisco", "show names", "", func() halfpike.ParseObject { return tmpl.RecordSet(ExecOptions{}) })
+	if err != nil {
+		t.Fatalf("TestRegistry: got err == %s", err)
+	}
+
+	obj, _, err := r.Parse(context.Background(), "cisco", "", "show names", "Name a\nName b\n")
+	if err != nil {
+		t.Fatalf("TestRegistry: got err == %s", err)
+	}
+	if got := len(obj.(*halfpike.RecordSet).Records); got != 2 {
+		t.Errorf("TestRegistry: got %d Records, want 2", got)
+	}
+}
@@ -0,0 +1,417 @@
+/*
+Package textfsm runs TextFSM templates, such as those from ntc-templates, with halfpike.
+
+TextFSM is forgiving in ways that hide broken templates: a Value whose regex no longer matches the
+output is silently recorded as "". This package parses the same template files, but executes them
+on halfpike's lexed Lines with stricter semantics:
+
+  - Rule regexes must compile as Go (RE2) regexes when the template is parsed, so templates using
+    Python only features, such as lookaheads, fail up front rather than at runtime.
+  - A Value that has no match is not set in the Record, instead of being set to "".
+  - If records are produced, a Value that never matched anywhere in the content is an error unless
+    it is listed in ExecOptions.Optional.
+  - The "Error" action returns an error with the line number of the content and template.
+
+Blank lines are skipped by halfpike's lexer, so rules that only match a blank line, like "^$$", never match.
+
+Usage:
+
+	tmpl, err := textfsm.ParseTemplate(f)
+	if err != nil {
+		// Do something
+	}
+	rs, err := tmpl.Execute(ctx, content, textfsm.ExecOptions{})
+	if err != nil {
+		// Do something
+	}
+	for _, rec := range rs.Records {
+		...
+	}
+*/
+package textfsm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Option is an option on a Value.
+type Option string
+
+// Value options.
+const (
+	// Filldown keeps the Value's last match for following records.
+	Filldown Option = "Filldown"
+	// Key marks the Value as part of the record's identity. It does not change execution.
+	Key Option = "Key"
+	// Required drops records where the Value has no match.
+	Required Option = "Required"
+	// List makes the Value a list of every match in the record.
+	List Option = "List"
+	// Fillup sets the Value in previous records that did not have a match, until one that did.
+	Fillup Option = "Fillup"
+)
+
+var validOptions = map[Option]bool{Filldown: true, Key: true, Required: true, List: true, Fillup: true}
+
+// Value is a Value definition in a template.
+type Value struct {
+	// Name is the name of the Value.
+	Name string
+	// Options are the Value's options.
+	Options []Option
+	// Regex is the regex for the Value, including its enclosing parentheses.
+	Regex string
+}
+
+// Has returns true if the Value has option "o".
+func (v *Value) Has(o Option) bool {
+	for _, opt := range v.Options {
+		if opt == o {
+			return true
+		}
+	}
+	return false
+}
+
+// LineOp is the action taken on the line after a rule matches.
+type LineOp uint8
+
+const (
+	// Next moves to the next line and the first rule of the state. This is the default.
+	Next LineOp = 0
+	// Continue keeps the line and tries the next rule in the state.
+	Continue LineOp = 1
+)
+
+// RecordOp is the action taken on the record after a rule matches.
+type RecordOp uint8
+
+const (
+	// NoRecord does nothing. This is the default.
+	NoRecord RecordOp = 0
+	// Record saves the Values as a record and clears Values that are not Filldown.
+	Record RecordOp = 1
+	// Clear clears Values that are not Filldown.
+	Clear RecordOp = 2
+	// Clearall clears all Values.
+	Clearall RecordOp = 3
+)
+
+// Rule is a rule in a state.
+type Rule struct {
+	// Match is the rule's regex as written in the template.
+	Match string
+	// Regex is Match with the Values substituted.
+	Regex *regexp.Regexp
+	// LineOp is the line action.
+	LineOp LineOp
+	// RecordOp is the record action.
+	RecordOp RecordOp
+	// NewState is the state to change to, if not "".
+	NewState string
+	// Error is set if the rule's action is "Error". ErrorMsg is the optional message.
+	Error    bool
+	ErrorMsg string
+	// LineNum is the line in the template the rule is on, starting at 1.
+	LineNum int
+}
+
+// State is a named list of rules.
+type State struct {
+	// Name is the name of the state.
+	Name string
+	// Rules are the rules, in order.
+	Rules []*Rule
+}
+
+// Template is a parsed TextFSM template. It is safe for concurrent use.
+type Template struct {
+	// Values are the Value definitions in the order they were defined, which is the field order of records.
+	Values []*Value
+	// States are the states by name.
+	States map[string]*State
+
+	valueIndex map[string]int
+}
+
+var (
+	stateNameRE = regexp.MustCompile(`^\w+$`)
+	valueNameRE = regexp.MustCompile(`^\w+$`)
+	actionRE    = regexp.MustCompile(`^(.*?)\s+->\s*(.*)$`)
+)
+
+// ParseTemplate parses a TextFSM template.
+func ParseTemplate(r io.Reader) (*Template, error) {
+	t := &Template{States: map[string]*State{}, valueIndex: map[string]int{}}
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	next := func() (string, bool) {
+		for scanner.Scan() {
+			lineNum++
+			line := strings.TrimRight(scanner.Text(), " \t\r")
+			if strings.HasPrefix(strings.TrimSpace(line), "#") {
+				continue
+			}
+			return line, true
+		}
+		return "", false
+	}
+
+	// Values are at the top of the template and end at the first blank line.
+	var (
+		line string
+		ok   bool
+	)
+	for line, ok = next(); ok && line != ""; line, ok = next() {
+		if err := t.parseValue(line); err != nil {
+			return nil, fmt.Errorf("template line %d: %w", lineNum, err)
+		}
+	}
+	if len(t.Values) == 0 {
+		return nil, fmt.Errorf("template has no Values")
+	}
+
+	// States are separated by blank lines.
+	var state *State
+	for line, ok = next(); ok; line, ok = next() {
+		switch {
+		case line == "":
+			state = nil
+		case state == nil:
+			if !stateNameRE.MatchString(line) {
+				return nil, fmt.Errorf("template line %d: %q is not a valid state name", lineNum, line)
+			}
+			if _, ok := t.States[line]; ok {
+				return nil, fmt.Errorf("template line %d: state %q is defined twice", lineNum, line)
+			}
+			state = &State{Name: line}
+			t.States[line] = state
+		default:
+			rule, err := t.parseRule(line, lineNum)
+			if err != nil {
+				return nil, fmt.Errorf("template line %d: %w", lineNum, err)
+			}
+			state.Rules = append(state.Rules, rule)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := t.validate(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// parseValue parses a line like "Value Filldown,Required Name (\S+)".
+func (t *Template) parseValue(line string) error {
+	if !strings.HasPrefix(line, "Value ") {
+		return fmt.Errorf("expected a Value definition or a blank line, got %q", line)
+	}
+	rest := strings.TrimSpace(strings.TrimPrefix(line, "Value "))
+
+	v := &Value{}
+	first, rest := splitWord(rest)
+	second, regex := splitWord(rest)
+	if strings.HasPrefix(second, "(") {
+		// There are no options, so "second" is the start of the regex.
+		v.Name, regex = first, rest
+	} else {
+		v.Name = second
+		for _, o := range strings.Split(first, ",") {
+			opt := Option(o)
+			if !validOptions[opt] {
+				return fmt.Errorf("Value %s has unknown option %q", second, o)
+			}
+			v.Options = append(v.Options, opt)
+		}
+	}
+	v.Regex = strings.TrimSpace(regex)
+
+	switch {
+	case !valueNameRE.MatchString(v.Name):
+		return fmt.Errorf("%q is not a valid Value name", v.Name)
+	case !strings.HasPrefix(v.Regex, "(") || !strings.HasSuffix(v.Regex, ")"):
+		return fmt.Errorf("Value %s regex %q must be enclosed in parentheses", v.Name, v.Regex)
+	}
+	if _, err := regexp.Compile(v.Regex); err != nil {
+		return fmt.Errorf("Value %s regex %q: %w", v.Name, v.Regex, err)
+	}
+	if _, ok := t.valueIndex[v.Name]; ok {
+		return fmt.Errorf("Value %s is defined twice", v.Name)
+	}
+
+	t.valueIndex[v.Name] = len(t.Values)
+	t.Values = append(t.Values, v)
+	return nil
+}
+
+func splitWord(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexAny(s, " \t")
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], s[i+1:]
+}
+
+// parseRule parses a line like "  ^Interface ${Name} -> Continue.Record".
+func (t *Template) parseRule(line string, lineNum int) (*Rule, error) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == line || !strings.HasPrefix(trimmed, "^") {
+		return nil, fmt.Errorf("rules must be indented and start with '^', got %q", line)
+	}
+
+	r := &Rule{Match: trimmed, LineNum: lineNum}
+	action := ""
+	if m := actionRE.FindStringSubmatch(trimmed); m != nil {
+		r.Match, action = m[1], m[2]
+	}
+	if err := r.parseAction(action); err != nil {
+		return nil, err
+	}
+
+	expanded, err := t.expand(r.Match)
+	if err != nil {
+		return nil, err
+	}
+	r.Regex, err = regexp.Compile(expanded)
+	if err != nil {
+		return nil, fmt.Errorf("rule %q is not a valid Go regex: %w", r.Match, err)
+	}
+	return r, nil
+}
+
+var lineOps = map[string]LineOp{"Next": Next, "Continue": Continue}
+var recordOps = map[string]RecordOp{"NoRecord": NoRecord, "Record": Record, "Clear": Clear, "Clearall": Clearall}
+
+// parseAction parses the text after "->", such as "Next.Record State" or "Error \"message\"".
+func (r *Rule) parseAction(action string) error {
+	action = strings.TrimSpace(action)
+	if action == "" {
+		return nil
+	}
+
+	if action == "Error" || strings.HasPrefix(action, "Error ") {
+		r.Error = true
+		r.ErrorMsg = strings.Trim(strings.TrimSpace(strings.TrimPrefix(action, "Error")), `"`)
+		return nil
+	}
+
+	words := strings.Fields(action)
+	if len(words) > 2 {
+		return fmt.Errorf("action %q has too many words", action)
+	}
+
+	ops := words[0]
+	lineOp, isLineOp := lineOps[strings.SplitN(ops, ".", 2)[0]]
+	recordOp, isRecordOp := recordOps[ops]
+	switch {
+	case strings.Contains(ops, "."):
+		parts := strings.SplitN(ops, ".", 2)
+		var ok bool
+		if r.LineOp, ok = lineOps[parts[0]]; !ok {
+			return fmt.Errorf("action %q has unknown line action %q", action, parts[0])
+		}
+		if r.RecordOp, ok = recordOps[parts[1]]; !ok {
+			return fmt.Errorf("action %q has unknown record action %q", action, parts[1])
+		}
+		words = words[1:]
+	case isLineOp:
+		r.LineOp = lineOp
+		words = words[1:]
+	case isRecordOp:
+		r.RecordOp = recordOp
+		words = words[1:]
+	}
+
+	switch len(words) {
+	case 0:
+	case 1:
+		r.NewState = words[0]
+	default:
+		return fmt.Errorf("action %q is not in the form [LineOp][.RecordOp] [NewState]", action)
+	}
+	if r.LineOp == Continue && r.NewState != "" {
+		return fmt.Errorf("action %q cannot change state with Continue", action)
+	}
+	return nil
+}
+
+// expand substitutes ${Name} and $Name with the Value's regex as a named group and "$$" with "$".
+func (t *Template) expand(match string) (string, error) {
+	b := strings.Builder{}
+	for i := 0; i < len(match); i++ {
+		if match[i] != '$' {
+			b.WriteByte(match[i])
+			continue
+		}
+
+		rest := match[i+1:]
+		var name string
+		switch {
+		case strings.HasPrefix(rest, "$"):
+			b.WriteByte('$')
+			i++
+			continue
+		case strings.HasPrefix(rest, "{"):
+			end := strings.IndexByte(rest, '}')
+			if end < 0 {
+				return "", fmt.Errorf("rule %q has an unterminated ${", match)
+			}
+			name = rest[1:end]
+			i += end + 1
+		default:
+			end := 0
+			for end < len(rest) && isWordByte(rest[end]) {
+				end++
+			}
+			if end == 0 {
+				return "", fmt.Errorf("rule %q has a '$' that is not '$$' or a Value", match)
+			}
+			name = rest[:end]
+			i += end
+		}
+
+		vi, ok := t.valueIndex[name]
+		if !ok {
+			return "", fmt.Errorf("rule %q uses undefined Value %q", match, name)
+		}
+		b.WriteString("(?P<" + name + ">")
+		b.WriteString(t.Values[vi].Regex[1:])
+	}
+	return b.String(), nil
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// validate checks the states once the whole template is parsed.
+func (t *Template) validate() error {
+	if _, ok := t.States["Start"]; !ok {
+		return fmt.Errorf("template has no Start state")
+	}
+	for _, name := range []string{"End", "EOF"} {
+		if s, ok := t.States[name]; ok && len(s.Rules) > 0 {
+			return fmt.Errorf("template line %d: the %s state cannot have rules", s.Rules[0].LineNum, name)
+		}
+	}
+	for _, s := range t.States {
+		for _, r := range s.Rules {
+			if r.NewState == "" || r.NewState == "End" || r.NewState == "EOF" {
+				continue
+			}
+			if _, ok := t.States[r.NewState]; !ok {
+				return fmt.Errorf("template line %d: state %q does not exist", r.LineNum, r.NewState)
+			}
+		}
+	}
+	return nil
+}
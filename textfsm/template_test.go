@@ -0,0 +1,92 @@
+package textfsm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestParseTemplate(t *testing.T) {
+	tmpl := `# A comment.
+Value Filldown,Required Chassis (\S+)
+Value List Slots (\d+)
+Value Name (\S+ \S+)
+
+Start
+  # Comments are allowed in states.
+  ^Chassis ${Chassis} -> Continue
+  ^Slot $Slots
+  ^Name ${Name}\s*$$ -> Next.Record Other
+  ^Bad -> Error "bad line"
+
+Other
+  ^Done -> End
+`
+	got, err := ParseTemplate(strings.NewReader(tmpl))
+	if err != nil {
+		t.Fatalf("TestParseTemplate: got err == %s", err)
+	}
+
+	wantValues := []*Value{
+		{Name: "Chassis", Options: []Option{Filldown, Required}, Regex: `(\S+)`},
+		{Name: "Slots", Options: []Option{List}, Regex: `(\d+)`},
+		{Name: "Name", Regex: `(\S+ \S+)`},
+	}
+	if diff := pretty.Compare(wantValues, got.Values); diff != "" {
+		t.Errorf("TestParseTemplate(Values): -want/+got:\n%s", diff)
+	}
+
+	type rule struct {
+		Regex    string
+		LineOp   LineOp
+		RecordOp RecordOp
+		NewState string
+		Error    bool
+		ErrorMsg string
+		LineNum  int
+	}
+	var gotRules []rule
+	for _, r := range got.States["Start"].Rules {
+		gotRules = append(gotRules, rule{r.Regex.String(), r.LineOp, r.RecordOp, r.NewState, r.Error, r.ErrorMsg, r.LineNum})
+	}
+	wantRules := []rule{
+		{Regex: `^Chassis (?P<Chassis>\S+)`, LineOp: Continue, LineNum: 8},
+		{Regex: `^Slot (?P<Slots>\d+)`, LineNum: 9},
+		{Regex: `^Name (?P<Name>\S+ \S+)\s*$`, RecordOp: Record, NewState: "Other", LineNum: 10},
+		{Regex: `^Bad`, Error: true, ErrorMsg: "bad line", LineNum: 11},
+	}
+	if diff := pretty.Compare(wantRules, gotRules); diff != "" {
+		t.Errorf("TestParseTemplate(Start rules): -want/+got:\n%s", diff)
+	}
+	if r := got.States["Other"].Rules[0]; r.NewState != "End" {
+		t.Errorf("TestParseTemplate(Other): got NewState %q, want End", r.NewState)
+	}
+}
+
+func TestParseTemplateErrors(t *testing.T) {
+	tests := []struct {
+		desc string
+		tmpl string
+	}{
+		{"No Values", "Start\n  ^x\n"},
+		{"Unknown option", "Value Sticky Name (\\S+)\n\nStart\n  ^${Name}\n"},
+		{"Regex without parentheses", "Value Name \\S+\n\nStart\n  ^${Name}\n"},
+		{"Duplicate Value", "Value Name (\\S+)\nValue Name (\\S+)\n\nStart\n  ^${Name}\n"},
+		{"No Start state", "Value Name (\\S+)\n\nOther\n  ^${Name}\n"},
+		{"Undefined Value", "Value Name (\\S+)\n\nStart\n  ^${Other}\n"},
+		{"Python only regex", "Value Name (\\S+)\n\nStart\n  ^(?=x)${Name}\n"},
+		{"Unknown state", "Value Name (\\S+)\n\nStart\n  ^${Name} -> Missing\n"},
+		{"Continue with state change", "Value Name (\\S+)\n\nStart\n  ^${Name} -> Continue Other\n\nOther\n  ^x\n"},
+		{"Unknown record action", "Value Name (\\S+)\n\nStart\n  ^${Name} -> Next.Save\n"},
+		{"Rule not indented", "Value Name (\\S+)\n\nStart\n^${Name}\n"},
+		{"Rules in EOF", "Value Name (\\S+)\n\nStart\n  ^${Name}\n\nEOF\n  ^x -> Record\n"},
+		{"Bare $", "Value Name (\\S+)\n\nStart\n  ^${Name} $ -> Record\n"},
+	}
+
+	for _, test := range tests {
+		if _, err := ParseTemplate(strings.NewReader(test.tmpl)); err == nil {
+			t.Errorf("TestParseTemplateErrors(%s): got err == nil, want err != nil", test.desc)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package halfpike
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultTimeLayouts are the layouts Line.Time() uses when none are given. They are the timestamps
+// commonly found in device output.
+var DefaultTimeLayouts = []string{
+	"Jan _2 15:04:05 MST 2006",     // Oct 16 12:53:04 UTC 2026
+	"Mon Jan _2 15:04:05 MST 2006", // Fri Oct 16 12:53:04 UTC 2026
+	"Mon Jan _2 15:04:05 2006",     // Fri Oct 16 12:53:04 2026
+	"15:04:05 MST Mon Jan _2 2006", // 12:53:04.123 UTC Fri Oct 16 2026 (Cisco "show clock")
+	"2006-01-02 15:04:05 MST",      // 2026-10-16 12:53:04 PDT
+	"2006-01-02 15:04:05 -0700",    // 2026-10-16 12:53:04 -0700
+	"2006-01-02 15:04:05",          // 2026-10-16 12:53:04
+	"2006-01-02T15:04:05Z07:00",    // 2026-10-16T12:53:04Z
+	"Jan _2 2006 15:04:05",         // Oct 16 2026 12:53:04
+	"Jan _2 15:04:05 2006",         // Oct 16 12:53:04 2026
+	"Jan _2 15:04:05",              // Oct 16 12:53:04 (syslog, the year is 0)
+}
+
+// TimeZones maps the time zone abbreviations found in device output to a location. time.Parse() does
+// not know what most abbreviations mean, so Line.Time() uses this table instead. An abbreviation that
+// is not in the table is an error. Add entries for your devices during init(), as the table is not
+// safe to change while parsing.
+var TimeZones = map[string]*time.Location{
+	"UTC":  time.UTC,
+	"GMT":  time.UTC,
+	"Z":    time.UTC,
+	"EST":  time.FixedZone("EST", -5*3600),
+	"EDT":  time.FixedZone("EDT", -4*3600),
+	"CST":  time.FixedZone("CST", -6*3600),
+	"CDT":  time.FixedZone("CDT", -5*3600),
+	"MST":  time.FixedZone("MST", -7*3600),
+	"MDT":  time.FixedZone("MDT", -6*3600),
+	"PST":  time.FixedZone("PST", -8*3600),
+	"PDT":  time.FixedZone("PDT", -7*3600),
+	"AKST": time.FixedZone("AKST", -9*3600),
+	"AKDT": time.FixedZone("AKDT", -8*3600),
+	"HST":  time.FixedZone("HST", -10*3600),
+	"WET":  time.FixedZone("WET", 0),
+	"WEST": time.FixedZone("WEST", 1*3600),
+	"BST":  time.FixedZone("BST", 1*3600),
+	"CET":  time.FixedZone("CET", 1*3600),
+	"CEST": time.FixedZone("CEST", 2*3600),
+	"EET":  time.FixedZone("EET", 2*3600),
+	"EEST": time.FixedZone("EEST", 3*3600),
+	"MSK":  time.FixedZone("MSK", 3*3600),
+	"IST":  time.FixedZone("IST", 5*3600+1800),
+	"SGT":  time.FixedZone("SGT", 8*3600),
+	"HKT":  time.FixedZone("HKT", 8*3600),
+	"JST":  time.FixedZone("JST", 9*3600),
+	"KST":  time.FixedZone("KST", 9*3600),
+	"AEST": time.FixedZone("AEST", 10*3600),
+	"AEDT": time.FixedZone("AEDT", 11*3600),
+	"NZST": time.FixedZone("NZST", 12*3600),
+	"NZDT": time.FixedZone("NZDT", 13*3600),
+}
+
+// Time parses a timestamp that begins at l.Items[start] and may span several Items, such as
+// "Oct 16 12:53:04 UTC 2026". Each layout, which is a time.Parse() layout, is tried against as many
+// Items as it has space separated fields. If more than one layout matches, the one that uses the
+// most Items wins. If no layouts are given, DefaultTimeLayouts is used.
+//
+// Time zone abbreviations are looked up in TimeZones. The returned index is that of the first Item
+// after the timestamp, so for "Last flapped: 2026-10-01 03:04:05 UTC (2w0d ago)", Time(2) returns 5,
+// the index of "(2w0d".
+func (l Line) Time(start int, layouts ...string) (time.Time, int, error) {
+	t, next, _, err := l.TimeLayout(start, layouts...)
+	return t, next, err
+}
+
+// TimeLayout is like Time(), but also returns the layout that matched.
+func (l Line) TimeLayout(start int, layouts ...string) (t time.Time, next int, layout string, err error) {
+	if len(layouts) == 0 {
+		layouts = DefaultTimeLayouts
+	}
+	items := lineItems(l)
+	if start < 0 || start >= len(items) {
+		return time.Time{}, start, "", fmt.Errorf("[Line %d]: no Item at index %d to parse a time from", l.LineNum, start)
+	}
+
+	var firstErr error
+	found := false
+	for _, lay := range layouts {
+		n := len(strings.Fields(lay))
+		if n == 0 || start+n > len(items) || found && start+n <= next {
+			continue
+		}
+
+		vals := make([]string, 0, n)
+		for _, item := range items[start : start+n] {
+			vals = append(vals, item.Val)
+		}
+		got, err := parseTime(lay, strings.Join(vals, " "))
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		t, next, layout, found = got, start+n, lay, true
+	}
+
+	if !found {
+		if firstErr == nil {
+			firstErr = fmt.Errorf("not enough Items")
+		}
+		return time.Time{}, start, "", fmt.Errorf("[Line %d]: Item %d does not begin a time in any of the layouts %q: %w", l.LineNum, start, layouts, firstErr)
+	}
+	return t, next, layout, nil
+}
+
+// parseTime is time.Parse(), but uses TimeZones for time zone abbreviations.
+func parseTime(layout, value string) (time.Time, error) {
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !strings.Contains(layout, "MST") {
+		return t, nil
+	}
+
+	name, _ := t.Zone()
+	loc, ok := TimeZones[name]
+	if !ok {
+		return time.Time{}, fmt.Errorf("time zone %q in %q is not in TimeZones", name, value)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc), nil
+}
@@ -0,0 +1,115 @@
+package halfpike
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLineTime(t *testing.T) {
+	pdt := time.FixedZone("PDT", -7*3600)
+
+	tests := []struct {
+		desc       string
+		line       string
+		start      int
+		layouts    []string
+		want       time.Time
+		wantNext   int
+		wantLayout string
+		wantErr    bool
+	}{
+		{
+			desc:       "Month first with zone and year",
+			line:       "Time Now: Oct 16 12:53:04 UTC 2026\n",
+			start:      2,
+			want:       time.Date(2026, 10, 16, 12, 53, 4, 0, time.UTC),
+			wantNext:   7,
+			wantLayout: "Jan _2 15:04:05 MST 2006",
+		},
+		{
+			desc:       "Zone abbreviation from the table",
+			line:       "2026-10-16 12:53:04 PDT\n",
+			want:       time.Date(2026, 10, 16, 12, 53, 4, 0, pdt),
+			wantNext:   3,
+			wantLayout: "2006-01-02 15:04:05 MST",
+		},
+		{
+			desc:       "Greedy match stops before trailing items",
+			line:       "Last flapped: 2026-10-01 03:04:05 UTC (2w0d ago)\n",
+			start:      2,
+			want:       time.Date(2026, 10, 1, 3, 4, 5, 0, time.UTC),
+			wantNext:   5,
+			wantLayout: "2006-01-02 15:04:05 MST",
+		},
+		{
+			desc:       "Shorter layout when there is no zone",
+			line:       "Last flapped: 2026-10-01 03:04:05 (2w0d ago)\n",
+			start:      2,
+			want:       time.Date(2026, 10, 1, 3, 4, 5, 0, time.UTC),
+			wantNext:   4,
+			wantLayout: "2006-01-02 15:04:05",
+		},
+		{
+			desc:       "Space padded day with fractional seconds",
+			line:       "Booted Oct  6 01:02:03.250 UTC 2026\n",
+			start:      1,
+			want:       time.Date(2026, 10, 6, 1, 2, 3, 250*int(time.Millisecond), time.UTC),
+			wantNext:   6,
+			wantLayout: "Jan _2 15:04:05 MST 2006",
+		},
+		{
+			desc:       "Caller layouts",
+			line:       "16/10/2026 12:53\n",
+			layouts:    []string{"02/01/2006 15:04"},
+			want:       time.Date(2026, 10, 16, 12, 53, 0, 0, time.UTC),
+			wantNext:   2,
+			wantLayout: "02/01/2006 15:04",
+		},
+		{
+			desc:    "Unknown zone abbreviation",
+			line:    "2026-10-16 12:53:04 XYZT\n",
+			layouts: []string{"2006-01-02 15:04:05 MST"},
+			wantErr: true,
+		},
+		{
+			desc:    "Not a time",
+			line:    "Peer: 10.10.10.2+179 AS 22\n",
+			wantErr: true,
+		},
+		{
+			desc:    "Start past the end",
+			line:    "Oct 16\n",
+			start:   5,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		line := firstLine(t, test.line)
+		got, next, layout, err := line.TimeLayout(test.start, test.layouts...)
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestLineTime(%s): got err == nil, want err != nil", test.desc)
+			continue
+		case err != nil && !test.wantErr:
+			t.Errorf("TestLineTime(%s): got err == %s, want err == nil", test.desc, err)
+			continue
+		case err != nil:
+			continue
+		}
+
+		if !got.Equal(test.want) || got.Location().String() != test.want.Location().String() {
+			t.Errorf("TestLineTime(%s): got %v, want %v", test.desc, got, test.want)
+		}
+		if next != test.wantNext {
+			t.Errorf("TestLineTime(%s): got next index %d, want %d", test.desc, next, test.wantNext)
+		}
+		if layout != test.wantLayout {
+			t.Errorf("TestLineTime(%s): got layout %q, want %q", test.desc, layout, test.wantLayout)
+		}
+
+		if got2, next2, err := line.Time(test.start, test.layouts...); err != nil || !got2.Equal(got) || next2 != next {
+			t.Errorf("TestLineTime(%s): Time() and TimeLayout() differ", test.desc)
+		}
+	}
+}
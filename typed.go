@@ -0,0 +1,77 @@
+package halfpike
+
+import "context"
+
+// ParseAs creates a new T, parses "content" into it and returns it. *T must implement ParseObject.
+// This saves declaring the object before calling Parse():
+//
+//	neighbors, err := halfpike.ParseAs[junos.BGPNeighbors](ctx, content)
+func ParseAs[T any, PT interface {
+	*T
+	ParseObject
+}](ctx context.Context, content string, options ...Option) (*T, error) {
+	t := new(T)
+	if err := Parse(ctx, content, PT(t), options...); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// StateFn is a ParseFn that receives the state of a StateMachine, instead of finding its state
+// through a method receiver or closure.
+type StateFn[S any] func(ctx context.Context, p *Parser, state S) StateFn[S]
+
+// StateMachine is a ParseObject that runs StateFns, passing each the same state of type S. S is
+// usually a pointer to the struct being decoded into. Use NewStateMachine() to create one.
+type StateMachine[S any] struct {
+	// State is the state passed to each StateFn.
+	State S
+
+	start    StateFn[S]
+	validate func(S) error
+}
+
+// NewStateMachine returns a StateMachine that begins with "start" and, once parsing is done, calls
+// "validate" with the state. "validate" may be nil.
+func NewStateMachine[S any](state S, start StateFn[S], validate func(S) error) *StateMachine[S] {
+	return &StateMachine[S]{State: state, start: start, validate: validate}
+}
+
+// Start implements ParseObject.Start().
+func (m *StateMachine[S]) Start(ctx context.Context, p *Parser) ParseFn {
+	return m.ParseFn(m.start)
+}
+
+// Validate implements ParseObject.Validate().
+func (m *StateMachine[S]) Validate() error {
+	if m.validate == nil {
+		return nil
+	}
+	return m.validate(m.State)
+}
+
+// ParseFn converts "fn" into a ParseFn that runs "fn" with the StateMachine's state, for use
+// where a ParseFn is required. A nil "fn" returns a nil ParseFn.
+func (m *StateMachine[S]) ParseFn(fn StateFn[S]) ParseFn {
+	if fn == nil {
+		return nil
+	}
+	return func(ctx context.Context, p *Parser) ParseFn {
+		// Loop detection should name "fn", not this wrapper.
+		p.stepFunc = fn
+		return m.ParseFn(fn(ctx, p, m.State))
+	}
+}
+
+// Lift converts the ParseFn chain starting at "fn" into a StateFn. Each ParseFn in the chain is
+// run as a step until one returns nil, then "next" is returned. This allows existing ParseFns to be
+// used within a StateMachine.
+func Lift[S any](fn ParseFn, next StateFn[S]) StateFn[S] {
+	if fn == nil {
+		return next
+	}
+	return func(ctx context.Context, p *Parser, state S) StateFn[S] {
+		p.stepFunc = fn
+		return Lift(fn(ctx, p), next)
+	}
+}
@@ -0,0 +1,126 @@
+package halfpike
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestParseAs(t *testing.T) {
+	obj, err := ParseAs[batchObj](context.Background(), "Address: 10.0.0.1\nAddress: 10.0.0.2\n")
+	if err != nil {
+		t.Fatalf("TestParseAs: got err == %s", err)
+	}
+	if obj.addrs != 2 {
+		t.Errorf("TestParseAs: got %d addresses, want 2", obj.addrs)
+	}
+
+	obj, err = ParseAs[batchObj](context.Background(), "bad\n")
+	if err == nil || obj != nil {
+		t.Errorf("TestParseAs(bad line): got (%v, %v), want (nil, error)", obj, err)
+	}
+}
+
+type smInterface struct {
+	Name  string
+	Addrs []string
+}
+
+func smFindInterface(ctx context.Context, p *Parser, state *smInterface) StateFn[*smInterface] {
+	line, err := p.FindStart([]string{"Interface:", Skip})
+	if err != nil {
+		return nil
+	}
+	state.Name = line.Items[1].Val
+	return smAddresses
+}
+
+func smAddresses(ctx context.Context, p *Parser, state *smInterface) StateFn[*smInterface] {
+	line := p.Next()
+	if !p.IsAtStart(line, []string{"Address:", Skip}) {
+		p.Backup()
+		return nil
+	}
+	state.Addrs = append(state.Addrs, line.Items[1].Val)
+	return smAddresses
+}
+
+func smStuck(ctx context.Context, p *Parser, state *smInterface) StateFn[*smInterface] {
+	return smStuck
+}
+
+func smValidate(state *smInterface) error {
+	if state.Name == "" {
+		return errors.New("no interface found")
+	}
+	return nil
+}
+
+// skipHeader is a plain ParseFn that skips the first line.
+func skipHeader(ctx context.Context, p *Parser) ParseFn {
+	p.Next()
+	return nil
+}
+
+func TestStateMachine(t *testing.T) {
+	tests := []struct {
+		desc    string
+		content string
+		start   StateFn[*smInterface]
+		want    *smInterface
+		err     bool
+	}{
+		{
+			desc:    "Success",
+			content: "Interface: ge-0/0/0\nAddress: 10.0.0.1\nAddress: 10.0.0.2\nMTU: 1500\n",
+			start:   smFindInterface,
+			want:    &smInterface{Name: "ge-0/0/0", Addrs: []string{"10.0.0.1", "10.0.0.2"}},
+		},
+		{
+			desc:    "Lifted ParseFn",
+			content: "Interface: header\nInterface: ge-0/0/1\nAddress: 10.0.0.3\n",
+			start:   Lift[*smInterface](skipHeader, smFindInterface),
+			want:    &smInterface{Name: "ge-0/0/1", Addrs: []string{"10.0.0.3"}},
+		},
+		{
+			desc:    "Validation failure",
+			content: "MTU: 1500\n",
+			start:   smFindInterface,
+			err:     true,
+		},
+	}
+
+	for _, test := range tests {
+		m := NewStateMachine(&smInterface{}, test.start, smValidate)
+		err := Parse(context.Background(), test.content, m)
+		switch {
+		case err == nil && test.err:
+			t.Errorf("TestStateMachine(%s): got err == nil, want err != nil", test.desc)
+			continue
+		case err != nil && !test.err:
+			t.Errorf("TestStateMachine(%s): got err == %s", test.desc, err)
+			continue
+		case err != nil:
+			continue
+		}
+		if diff := pretty.Compare(test.want, m.State); diff != "" {
+			t.Errorf("TestStateMachine(%s): -want/+got:\n%s", test.desc, diff)
+		}
+	}
+}
+
+func TestStateMachineLoop(t *testing.T) {
+	m := NewStateMachine(&smInterface{}, smStuck, nil)
+	err := Parse(context.Background(), "Interface: ge-0/0/0\n", m, WithNoProgressLimit(5))
+
+	lErr := &LoopError{}
+	if !errors.As(err, &lErr) {
+		t.Fatalf("TestStateMachineLoop: got err == %v, want *LoopError", err)
+	}
+	if !strings.HasSuffix(lErr.Func, "smStuck") {
+		t.Errorf("TestStateMachineLoop: got Func %q, want it to name smStuck", lErr.Func)
+	}
+}
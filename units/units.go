@@ -0,0 +1,385 @@
+// Package units converts the numbers with units found in device output, such as "1000mbps", "10Gbps",
+// "1.5K", "4096 bytes", "2w3d 04:05:06" or "90s", into values.
+//
+// Each kind of value has a table of the unit spellings vendors use. Units are matched without regard to
+// case, except for units like "MB" and "Mb", where "B" is bytes and "b" is bits. So "Mb" is not a byte
+// size and "MB" is not a bit rate. A unit that is not in the table is an error, instead of being ignored.
+package units
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// BitRate is a rate in bits per second.
+type BitRate int64
+
+// Common bit rates.
+const (
+	Bps  BitRate = 1
+	Kbps         = 1000 * Bps
+	Mbps         = 1000 * Kbps
+	Gbps         = 1000 * Mbps
+	Tbps         = 1000 * Gbps
+)
+
+// String implements fmt.Stringer. It uses the largest unit that represents the rate without a fraction,
+// such as "10Gbps" or "1500Mbps".
+func (b BitRate) String() string {
+	return format(int64(b), []suffix{{"Tbps", int64(Tbps)}, {"Gbps", int64(Gbps)}, {"Mbps", int64(Mbps)}, {"Kbps", int64(Kbps)}}, "bps")
+}
+
+// ByteSize is a size in bytes.
+type ByteSize int64
+
+// Common byte sizes. Like devices report memory and buffers, these are powers of 1024.
+const (
+	Byte ByteSize = 1
+	KB            = 1024 * Byte
+	MB            = 1024 * KB
+	GB            = 1024 * MB
+	TB            = 1024 * GB
+)
+
+// String implements fmt.Stringer. It uses the largest unit that represents the size without a fraction,
+// such as "4KB" or "1536B".
+func (b ByteSize) String() string {
+	return format(int64(b), []suffix{{"TB", int64(TB)}, {"GB", int64(GB)}, {"MB", int64(MB)}, {"KB", int64(KB)}}, "B")
+}
+
+type suffix struct {
+	name string
+	size int64
+}
+
+func format(n int64, suffixes []suffix, base string) string {
+	for _, s := range suffixes {
+		if n != 0 && n%s.size == 0 {
+			return strconv.FormatInt(n/s.size, 10) + s.name
+		}
+	}
+	return strconv.FormatInt(n, 10) + base
+}
+
+// table maps the spellings of units to a multiplier.
+type table struct {
+	// kind is the kind of value, used in errors.
+	kind string
+	// units maps a unit, in lower case, to its multiplier.
+	units map[string]float64
+	// exact maps a unit that is matched with case to its multiplier.
+	exact map[string]float64
+	// bare is the multiplier for a number without a unit. If 0, a unit is required.
+	bare float64
+}
+
+// unit is an entry in a table.
+type unit struct {
+	names []string
+	mult  float64
+	// exact is set if the names are matched with case.
+	exact bool
+}
+
+func newTable(kind string, bare float64, entries []unit) *table {
+	t := &table{kind: kind, units: map[string]float64{}, exact: map[string]float64{}, bare: bare}
+	for _, e := range entries {
+		m := t.units
+		if e.exact {
+			m = t.exact
+		}
+		for _, n := range e.names {
+			if _, ok := m[n]; ok {
+				panic(fmt.Sprintf("units: %s unit %q is in the table twice", kind, n))
+			}
+			m[n] = e.mult
+		}
+	}
+	for n := range t.exact {
+		if _, ok := t.units[strings.ToLower(n)]; ok {
+			panic(fmt.Sprintf("units: %s unit %q is matched both with and without case", kind, n))
+		}
+	}
+	return t
+}
+
+// prefixed returns the table entries for each of the prefixes k, m, g and t, which are powers of
+// "base", combined with each of "names". The names without a prefix are included.
+func prefixed(base float64, names ...string) []unit {
+	var entries []unit
+	mult := 1.0
+	for _, pre := range []string{"", "k", "m", "g", "t"} {
+		u := unit{mult: mult}
+		for _, n := range names {
+			u.names = append(u.names, pre+n)
+		}
+		entries = append(entries, u)
+		mult *= base
+	}
+	return entries
+}
+
+// bUnits returns the table entries for "b", which is "B" for bytes or "b" for bits, with each of the
+// prefixes K (or k), M, G and T, which are powers of "base", and followed by each of "suffixes". These
+// are matched with case, so that "MB" and "Mb" are not confused.
+func bUnits(b string, base float64, suffixes ...string) []unit {
+	var entries []unit
+	mult := 1.0
+	for _, pres := range [][]string{{""}, {"K", "k"}, {"M"}, {"G"}, {"T"}} {
+		u := unit{mult: mult, exact: true}
+		for _, pre := range pres {
+			for _, suf := range suffixes {
+				u.names = append(u.names, pre+b+suf)
+			}
+		}
+		entries = append(entries, u)
+		mult *= base
+	}
+	return entries
+}
+
+var bitRates = newTable(
+	"bit rate",
+	1,
+	append(
+		append(
+			prefixed(1000, "bps", "bit", "bits", "bit/s", "bits/s", "bit/sec", "bits/sec"),
+			bUnits("b", 1000, "", "/s")...,
+		),
+		unit{names: []string{"k"}, mult: 1000},
+		unit{names: []string{"m"}, mult: 1000 * 1000},
+		unit{names: []string{"g"}, mult: 1000 * 1000 * 1000},
+		unit{names: []string{"t"}, mult: 1000 * 1000 * 1000 * 1000},
+	),
+)
+
+var byteSizes = newTable(
+	"byte size",
+	1,
+	append(
+		append(prefixed(1024, "byte", "bytes"), bUnits("B", 1024, "")...),
+		unit{names: []string{"k", "kib"}, mult: 1024},
+		unit{names: []string{"m", "mib"}, mult: 1024 * 1024},
+		unit{names: []string{"g", "gib"}, mult: 1024 * 1024 * 1024},
+		unit{names: []string{"t", "tib"}, mult: 1024 * 1024 * 1024 * 1024},
+	),
+)
+
+var durations = newTable(
+	"duration",
+	0,
+	[]unit{
+		{names: []string{"y", "yr", "yrs", "year", "years"}, mult: float64(365 * 24 * time.Hour)},
+		{names: []string{"w", "wk", "wks", "week", "weeks"}, mult: float64(7 * 24 * time.Hour)},
+		{names: []string{"d", "day", "days"}, mult: float64(24 * time.Hour)},
+		{names: []string{"h", "hr", "hrs", "hour", "hours"}, mult: float64(time.Hour)},
+		{names: []string{"m", "min", "mins", "minute", "minutes"}, mult: float64(time.Minute)},
+		{names: []string{"s", "sec", "secs", "second", "seconds"}, mult: float64(time.Second)},
+		{names: []string{"ms", "msec", "msecs", "millisecond", "milliseconds"}, mult: float64(time.Millisecond)},
+		{names: []string{"us", "usec", "usecs", "µs", "microsecond", "microseconds"}, mult: float64(time.Microsecond)},
+	},
+)
+
+var percents = newTable(
+	"percent",
+	0,
+	[]unit{
+		{names: []string{"%", "percent", "pct"}, mult: 1},
+	},
+)
+
+// split splits "s" into a number and the unit that follows it. Space between the two is removed.
+func split(s string) (num, unit string) {
+	i := 0
+	for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+		i++
+	}
+	return s[:i], strings.TrimSpace(s[i:])
+}
+
+// parse parses a single number with an optional unit, such as "1.5K" or "4096 bytes".
+func (t *table) parse(s string) (float64, error) {
+	num, u := split(strings.TrimSpace(s))
+	if num == "" {
+		return 0, fmt.Errorf("%q is not a %s: does not begin with a number", s, t.kind)
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a %s: %w", s, t.kind, err)
+	}
+
+	if u == "" {
+		if t.bare == 0 {
+			return 0, fmt.Errorf("%q is not a %s: it has no unit", s, t.kind)
+		}
+		return f * t.bare, nil
+	}
+	mult, ok := t.exact[u]
+	if !ok {
+		mult, ok = t.units[strings.ToLower(u)]
+	}
+	if !ok {
+		return 0, fmt.Errorf("%q is not a %s: unknown unit %q", s, t.kind, u)
+	}
+	return f * mult, nil
+}
+
+func toInt64(f float64, s, kind string) (int64, error) {
+	f = math.Round(f)
+	if f > math.MaxInt64 {
+		return 0, fmt.Errorf("%q is too large for a %s", s, kind)
+	}
+	return int64(f), nil
+}
+
+// ParseBitRate parses a bit rate such as "1000mbps", "10Gbps", "1000000 Kbit/sec", "100Mb" or "100M". A
+// number without a unit is in bits per second. The prefixes k, m, g and t are powers of 1000. "MB" is
+// bytes, so it is an error.
+func ParseBitRate(s string) (BitRate, error) {
+	f, err := bitRates.parse(s)
+	if err != nil {
+		return 0, err
+	}
+	n, err := toInt64(f, s, bitRates.kind)
+	return BitRate(n), err
+}
+
+// ParseByteSize parses a size such as "1.5K", "4096 bytes", "512MB" or "2GiB". A number without a unit
+// is in bytes. The prefixes k, m, g and t are powers of 1024, as that is what devices use for memory.
+// "Mb" is bits, so it is an error.
+func ParseByteSize(s string) (ByteSize, error) {
+	f, err := byteSizes.parse(s)
+	if err != nil {
+		return 0, err
+	}
+	n, err := toInt64(f, s, byteSizes.kind)
+	return ByteSize(n), err
+}
+
+// ParseDuration parses a duration made of numbers with units, such as "90s", "2w3d", "1h2m3s",
+// "3 days, 4 hours" or "1 year, 2 weeks". Unlike time.ParseDuration(), it understands days, weeks and
+// years (365 days) and units that are words. A number without a unit is an error.
+func ParseDuration(s string) (time.Duration, error) {
+	return parseDuration(s, false)
+}
+
+// ParseUptime is like ParseDuration(), but also understands the clocks used in uptimes, such as
+// "2w3d 04:05:06", "3 days, 4:05" or "04:05:06". A clock is hours:minutes[:seconds].
+func ParseUptime(s string) (time.Duration, error) {
+	return parseDuration(s, true)
+}
+
+func parseDuration(s string, clocks bool) (time.Duration, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool { return unicode.IsSpace(r) || r == ',' })
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("%q is not a duration: it is empty", s)
+	}
+
+	var total float64
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		if strings.Contains(f, ":") {
+			if !clocks {
+				return 0, fmt.Errorf("%q is not a duration: %q is a clock, use ParseUptime()", s, f)
+			}
+			d, err := parseClock(f)
+			if err != nil {
+				return 0, fmt.Errorf("%q is not an uptime: %w", s, err)
+			}
+			total += float64(d)
+			continue
+		}
+
+		// A number followed by a unit that is its own field, such as "3 days". The next field must
+		// be only a unit, so "1 2w" is not read as "12w".
+		if num, u := split(f); u == "" && i+1 < len(fields) {
+			if _, ok := durations.units[strings.ToLower(fields[i+1])]; !ok {
+				return 0, fmt.Errorf("%q is not a duration: %q is not followed by a unit", s, f)
+			}
+			d, err := durations.parse(num + fields[i+1])
+			if err != nil {
+				return 0, fmt.Errorf("%q is not a duration: %w", s, err)
+			}
+			total += d
+			i++
+			continue
+		}
+
+		d, err := parseCompact(f)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a duration: %w", s, err)
+		}
+		total += d
+	}
+
+	if total > math.MaxInt64 {
+		return 0, fmt.Errorf("%q is too large for a duration", s)
+	}
+	return time.Duration(math.Round(total)), nil
+}
+
+// parseCompact parses a run of numbers and units without spaces, such as "2w3d" or "1h2m3s".
+func parseCompact(s string) (float64, error) {
+	var total float64
+	for rest := s; rest != ""; {
+		num, after := split(rest)
+		if num == "" {
+			return 0, fmt.Errorf("%q does not begin with a number", rest)
+		}
+		i := 0
+		for i < len(after) && !(after[i] >= '0' && after[i] <= '9') {
+			i++
+		}
+		d, err := durations.parse(num + after[:i])
+		if err != nil {
+			return 0, err
+		}
+		total += d
+		rest = after[i:]
+	}
+	return total, nil
+}
+
+// parseClock parses hours:minutes[:seconds], such as "04:05:06".
+func parseClock(s string) (time.Duration, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("clock %q must be hours:minutes[:seconds]", s)
+	}
+
+	mults := []time.Duration{time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, p := range parts {
+		n, err := strconv.ParseUint(p, 10, 32)
+		if err != nil || p == "" || i > 0 && n > 59 {
+			return 0, fmt.Errorf("clock %q has a bad value %q", s, p)
+		}
+		d += time.Duration(n) * mults[i]
+	}
+	return d, nil
+}
+
+// ParsePercent parses a percentage such as "45%", "12.5 percent" or "10pct". A ratio such as
+// "255/255", which Cisco uses for reliability and load, is converted to a percentage. A number
+// without a unit is an error.
+func ParsePercent(s string) (float64, error) {
+	if num, den, ok := strings.Cut(strings.TrimSpace(s), "/"); ok {
+		n, err := strconv.ParseFloat(num, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a percent: bad numerator: %w", s, err)
+		}
+		d, err := strconv.ParseFloat(den, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a percent: bad denominator: %w", s, err)
+		}
+		if d == 0 {
+			return 0, fmt.Errorf("%q is not a percent: denominator is 0", s)
+		}
+		return n / d * 100, nil
+	}
+	return percents.parse(s)
+}
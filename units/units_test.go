@@ -0,0 +1,206 @@
+package units
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseBitRate(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    BitRate
+		wantErr bool
+	}{
+		{in: "1000mbps", want: Gbps},
+		{in: "10Gbps", want: 10 * Gbps},
+		{in: "1000000 Kbit/sec", want: Gbps},
+		{in: "100M", want: 100 * Mbps},
+		{in: "1.5Gbps", want: 1500 * Mbps},
+		{in: "64000", want: 64 * Kbps},
+		{in: "9.6kbit/s", want: 9600},
+		{in: "10 bits/sec", want: 10},
+		{in: "100Mb", want: 100 * Mbps},
+		{in: "64kb", want: 64 * Kbps},
+		{in: "10 Gb/s", want: 10 * Gbps},
+		{in: "10GB", wantErr: true},
+		{in: "10MB/s", wantErr: true},
+		{in: "10Gbpsx", wantErr: true},
+		{in: "Auto", wantErr: true},
+		{in: "", wantErr: true},
+		{in: "1.2.3Mbps", wantErr: true},
+	}
+
+	for _, test := range tests {
+		got, err := ParseBitRate(test.in)
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestParseBitRate(%s): got err == nil, want err != nil", test.in)
+			continue
+		case err != nil && !test.wantErr:
+			t.Errorf("TestParseBitRate(%s): got err == %s, want err == nil", test.in, err)
+			continue
+		case err != nil:
+			continue
+		}
+		if got != test.want {
+			t.Errorf("TestParseBitRate(%s): got %d, want %d", test.in, got, test.want)
+		}
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    ByteSize
+		wantErr bool
+	}{
+		{in: "1.5K", want: 1536},
+		{in: "4096 bytes", want: 4 * KB},
+		{in: "512MB", want: 512 * MB},
+		{in: "2GiB", want: 2 * GB},
+		{in: "1 TB", want: TB},
+		{in: "100", want: 100},
+		{in: "64kbytes", want: 64 * KB},
+		{in: "4kB", want: 4 * KB},
+		{in: "10 bits", wantErr: true},
+		{in: "100Mb", wantErr: true},
+		{in: "64kb", wantErr: true},
+		{in: "bytes", wantErr: true},
+	}
+
+	for _, test := range tests {
+		got, err := ParseByteSize(test.in)
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestParseByteSize(%s): got err == nil, want err != nil", test.in)
+			continue
+		case err != nil && !test.wantErr:
+			t.Errorf("TestParseByteSize(%s): got err == %s, want err == nil", test.in, err)
+			continue
+		case err != nil:
+			continue
+		}
+		if got != test.want {
+			t.Errorf("TestParseByteSize(%s): got %d, want %d", test.in, got, test.want)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	const day = 24 * time.Hour
+
+	tests := []struct {
+		in         string
+		want       time.Duration
+		wantErr    bool
+		uptimeOnly bool
+	}{
+		{in: "90s", want: 90 * time.Second},
+		{in: "2w3d", want: 17 * day},
+		{in: "1h2m3s", want: time.Hour + 2*time.Minute + 3*time.Second},
+		{in: "10ms", want: 10 * time.Millisecond},
+		{in: "1.5h", want: 90 * time.Minute},
+		{in: "3 days, 4 hours", want: 3*day + 4*time.Hour},
+		{in: "1 year, 2 weeks, 3 days, 4 hours, 5 minutes", want: 365*day + 17*day + 4*time.Hour + 5*time.Minute},
+		{in: "2w3d 04:05:06", want: 17*day + 4*time.Hour + 5*time.Minute + 6*time.Second, uptimeOnly: true},
+		{in: "04:05:06", want: 4*time.Hour + 5*time.Minute + 6*time.Second, uptimeOnly: true},
+		{in: "3 days, 4:05", want: 3*day + 4*time.Hour + 5*time.Minute, uptimeOnly: true},
+		{in: "1d 02:03", want: day + 2*time.Hour + 3*time.Minute, uptimeOnly: true},
+		{in: "90", wantErr: true},
+		{in: "2x", wantErr: true},
+		{in: "3 fortnights", wantErr: true},
+		{in: "04:65:00", wantErr: true},
+		{in: "1:2:3:4", wantErr: true},
+		{in: "", wantErr: true},
+		{in: "never", wantErr: true},
+		{in: "1 2w", wantErr: true},
+		{in: "5 5s", wantErr: true},
+	}
+
+	for _, test := range tests {
+		got, err := ParseUptime(test.in)
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestParseDuration(ParseUptime(%s)): got err == nil, want err != nil", test.in)
+			continue
+		case err != nil && !test.wantErr:
+			t.Errorf("TestParseDuration(ParseUptime(%s)): got err == %s, want err == nil", test.in, err)
+			continue
+		case err == nil && got != test.want:
+			t.Errorf("TestParseDuration(ParseUptime(%s)): got %v, want %v", test.in, got, test.want)
+		}
+
+		got, err = ParseDuration(test.in)
+		switch {
+		case err == nil && (test.wantErr || test.uptimeOnly):
+			t.Errorf("TestParseDuration(ParseDuration(%s)): got err == nil, want err != nil", test.in)
+		case err != nil && !(test.wantErr || test.uptimeOnly):
+			t.Errorf("TestParseDuration(ParseDuration(%s)): got err == %s, want err == nil", test.in, err)
+		case err == nil && got != test.want:
+			t.Errorf("TestParseDuration(ParseDuration(%s)): got %v, want %v", test.in, got, test.want)
+		}
+	}
+}
+
+func TestParsePercent(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    float64
+		wantErr bool
+	}{
+		{in: "45%", want: 45},
+		{in: "12.5 percent", want: 12.5},
+		{in: "10pct", want: 10},
+		{in: "255/255", want: 100},
+		{in: "1/4", want: 25},
+		{in: "45", wantErr: true},
+		{in: "1/0", wantErr: true},
+		{in: "a/b", wantErr: true},
+		{in: "45%%", wantErr: true},
+	}
+
+	for _, test := range tests {
+		got, err := ParsePercent(test.in)
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestParsePercent(%s): got err == nil, want err != nil", test.in)
+			continue
+		case err != nil && !test.wantErr:
+			t.Errorf("TestParsePercent(%s): got err == %s, want err == nil", test.in, err)
+			continue
+		case err != nil:
+			continue
+		}
+		if got != test.want {
+			t.Errorf("TestParsePercent(%s): got %v, want %v", test.in, got, test.want)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		in   interface{ String() string }
+		want string
+	}{
+		{in: 10 * Gbps, want: "10Gbps"},
+		{in: 1500 * Mbps, want: "1500Mbps"},
+		{in: BitRate(9600), want: "9600bps"},
+		{in: BitRate(0), want: "0bps"},
+		{in: 4 * KB, want: "4KB"},
+		{in: ByteSize(1536), want: "1536B"},
+		{in: 2 * TB, want: "2TB"},
+	}
+
+	for _, test := range tests {
+		if got := test.in.String(); got != test.want {
+			t.Errorf("TestString(%s): got %s, want %s", test.want, got, test.want)
+		}
+	}
+
+	for _, s := range []string{"10Gbps", "1500Mbps", "9600bps"} {
+		b, err := ParseBitRate(s)
+		if err != nil || b.String() != s {
+			t.Errorf("TestString(round trip %s): got (%s, %v)", s, b, err)
+		}
+	}
+}
@@ -0,0 +1,408 @@
+package halfpike
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// FieldError is a field that failed validation in ValidateStruct().
+type FieldError struct {
+	// Path is the path to the field, such as "Peers[0].PeerAS" or "Tables[inet.0].Received".
+	Path string
+	// Rule is the hp tag rule that failed, such as "required" or "min=1".
+	Rule string
+	// Err describes the failure.
+	Err error
+}
+
+// Error implements error.Error().
+func (f FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", f.Path, f.Err)
+}
+
+// Unwrap returns the underlying error.
+func (f FieldError) Unwrap() error {
+	return f.Err
+}
+
+// ValidationErrors is returned by ValidateStruct() with every field that failed validation.
+type ValidationErrors []FieldError
+
+// Error implements error.Error().
+func (v ValidationErrors) Error() string {
+	s := make([]string, 0, len(v))
+	for _, f := range v {
+		s = append(s, f.Error())
+	}
+	return strings.Join(s, "; ")
+}
+
+// ValidateStruct validates the fields of the struct "v" (or pointer to a struct) using their `hp` tags,
+// so that most types can implement Validator with:
+//
+//	func (b *BGPNeighbor) Validate() error { return halfpike.ValidateStruct(b) }
+//
+// The rules in a tag are separated by commas, such as `hp:"required,min=1,max=65535"`:
+//
+//	required - the field must not be its zero value: nil, "", 0, false or a struct of zero values.
+//	           A slice or map must not be empty.
+//	nonzero - like required, but a pointer must also point to a value that is not its zero value.
+//	min=n, max=n - a number must be >= or <= n. A string, slice or map must have a length >= or <= n.
+//	oneof=a b c - the value, as text, must be one of the space separated values. A type with a String()
+//	              method, such as an Enum, is compared using it.
+//	regexp=re - the value, as text, must match the regexp. This must be the last rule, as "re" may
+//	            contain commas.
+//
+// A nil pointer is only checked by required and nonzero; other rules are applied to the value it
+// points to. Fields without a tag and unexported fields are not checked, but ValidateStruct recurses
+// into exported structs, pointers to structs and the elements of slices, arrays and maps. If one of
+// those implements Validator, its Validate() method is called instead. `hp:"-"` stops the recursion.
+//
+// All failures are returned as ValidationErrors. A tag that cannot be parsed returns an error that is
+// not a ValidationErrors.
+func ValidateStruct(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("halfpike.ValidateStruct: must validate a struct or *struct, not %T", v)
+	}
+
+	vs := &validateState{seen: map[seenKey]bool{}}
+	if p := reflect.ValueOf(v); p.Kind() == reflect.Ptr {
+		vs.seen[keyOf(p)] = true
+	}
+	if err := vs.validateStruct("", rv); err != nil {
+		return err
+	}
+	if len(vs.errs) > 0 {
+		return vs.errs
+	}
+	return nil
+}
+
+// validateState is the state of a ValidateStruct() call.
+type validateState struct {
+	errs ValidationErrors
+	// seen holds pointers that have been recursed into, to stop on cycles.
+	seen map[seenKey]bool
+}
+
+// seenKey identifies a pointer that has been recursed into. The type is part of the key because a
+// struct and its first field have the same address.
+type seenKey struct {
+	addr uintptr
+	typ  reflect.Type
+}
+
+func keyOf(p reflect.Value) seenKey {
+	return seenKey{addr: p.Pointer(), typ: p.Type()}
+}
+
+func (vs *validateState) fail(path, rule, format string, a ...interface{}) {
+	vs.errs = append(vs.errs, FieldError{Path: path, Rule: rule, Err: fmt.Errorf(format, a...)})
+}
+
+func (vs *validateState) validateStruct(path string, v reflect.Value) error {
+	fields, err := structRules(v.Type())
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		fv := v.Field(f.index)
+		fpath := joinPath(path, f.name)
+		for _, r := range f.rules {
+			r.check(vs, fpath, fv)
+		}
+		if f.skip {
+			continue
+		}
+		if err := vs.recurse(fpath, fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var validatorType = reflect.TypeOf((*Validator)(nil)).Elem()
+
+// recurse validates the structs within "v".
+func (vs *validateState) recurse(path string, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return vs.recurse(path, v.Elem())
+	case reflect.Ptr:
+		if v.IsNil() || vs.seen[keyOf(v)] {
+			return nil
+		}
+		vs.seen[keyOf(v)] = true
+		if v.Type().Implements(validatorType) {
+			return vs.validator(path, v)
+		}
+		return vs.recurse(path, v.Elem())
+	case reflect.Struct:
+		if v.CanAddr() && v.Addr().Type().Implements(validatorType) {
+			return vs.validator(path, v.Addr())
+		}
+		if v.Type().Implements(validatorType) {
+			return vs.validator(path, v)
+		}
+		return vs.validateStruct(path, v)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := vs.recurse(fmt.Sprintf("%s[%d]", path, i), v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := vs.recurse(fmt.Sprintf("%s[%v]", path, iter.Key()), iter.Value()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (vs *validateState) validator(path string, v reflect.Value) error {
+	err := v.Interface().(Validator).Validate()
+	if err == nil {
+		return nil
+	}
+	if errs, ok := err.(ValidationErrors); ok {
+		for _, f := range errs {
+			f.Path = joinPath(path, f.Path)
+			vs.errs = append(vs.errs, f)
+		}
+		return nil
+	}
+	vs.errs = append(vs.errs, FieldError{Path: path, Rule: "Validate()", Err: err})
+	return nil
+}
+
+// fieldRules are the rules for a struct field.
+type fieldRules struct {
+	index int
+	name  string
+	rules []rule
+	// skip is set by `hp:"-"`.
+	skip bool
+}
+
+// required reports if the field has a required or nonzero rule.
+func (f fieldRules) required() bool {
+	for _, r := range f.rules {
+		if r.text == "required" || r.text == "nonzero" {
+			return true
+		}
+	}
+	return false
+}
+
+// rule is a single rule from an hp tag.
+type rule struct {
+	text  string
+	check func(vs *validateState, path string, v reflect.Value)
+}
+
+// rulesCache caches the []fieldRules for a reflect.Type.
+var rulesCache sync.Map
+
+func structRules(t reflect.Type) ([]fieldRules, error) {
+	if fr, ok := rulesCache.Load(t); ok {
+		return fr.([]fieldRules), nil
+	}
+
+	var fields []fieldRules
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		fr := fieldRules{index: i, name: sf.Name}
+		tag := sf.Tag.Get("hp")
+		if tag == "-" {
+			fr.skip = true
+			fields = append(fields, fr)
+			continue
+		}
+
+		for tag != "" {
+			var text string
+			if strings.HasPrefix(tag, "regexp=") {
+				text, tag = tag, ""
+			} else {
+				text, tag, _ = strings.Cut(tag, ",")
+			}
+			r, err := newRule(text, sf.Type)
+			if err != nil {
+				return nil, fmt.Errorf("halfpike.ValidateStruct: %s.%s: hp tag rule %q: %w", t, sf.Name, text, err)
+			}
+			fr.rules = append(fr.rules, r)
+		}
+		fields = append(fields, fr)
+	}
+
+	rulesCache.Store(t, fields)
+	return fields, nil
+}
+
+func newRule(text string, t reflect.Type) (rule, error) {
+	name, arg, hasArg := strings.Cut(text, "=")
+	r := rule{text: text}
+
+	switch name {
+	case "required", "nonzero":
+		if hasArg {
+			return r, fmt.Errorf("takes no argument")
+		}
+		nonzero := name == "nonzero"
+		r.check = func(vs *validateState, path string, v reflect.Value) {
+			if isEmpty(v, nonzero) {
+				vs.fail(path, text, "is required, but was not set")
+			}
+		}
+	case "min", "max":
+		if !hasArg {
+			return r, fmt.Errorf("needs an argument")
+		}
+		n, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return r, fmt.Errorf("argument is not a number")
+		}
+		if !hasLen(elem(t)) && !isNumber(elem(t)) {
+			return r, fmt.Errorf("cannot be used on type %s", t)
+		}
+		isMin := name == "min"
+		r.check = func(vs *validateState, path string, v reflect.Value) {
+			v, ok := deref(v)
+			if !ok {
+				return
+			}
+			var got float64
+			what := "is"
+			if hasLen(v.Type()) {
+				got, what = float64(v.Len()), "has length"
+			} else {
+				got = toFloat(v)
+			}
+			switch {
+			case isMin && got < n:
+				vs.fail(path, text, "%s %v, must be at least %v", what, got, arg)
+			case !isMin && got > n:
+				vs.fail(path, text, "%s %v, must be at most %v", what, got, arg)
+			}
+		}
+	case "oneof":
+		if !hasArg || strings.TrimSpace(arg) == "" {
+			return r, fmt.Errorf("needs an argument")
+		}
+		valid := strings.Fields(arg)
+		r.check = func(vs *validateState, path string, v reflect.Value) {
+			v, ok := deref(v)
+			if !ok {
+				return
+			}
+			s := fmt.Sprint(v.Interface())
+			for _, o := range valid {
+				if s == o {
+					return
+				}
+			}
+			vs.fail(path, text, "is %q, must be one of %q", s, valid)
+		}
+	case "regexp":
+		re, err := regexp.Compile(arg)
+		if err != nil {
+			return r, err
+		}
+		r.check = func(vs *validateState, path string, v reflect.Value) {
+			v, ok := deref(v)
+			if !ok {
+				return
+			}
+			s := fmt.Sprint(v.Interface())
+			if !re.MatchString(s) {
+				vs.fail(path, text, "is %q, must match regexp %q", s, re)
+			}
+		}
+	default:
+		return r, fmt.Errorf("unknown rule")
+	}
+	return r, nil
+}
+
+// deref returns the value "v" points to. If "v" is a nil pointer or interface, ok is false.
+func deref(v reflect.Value) (reflect.Value, bool) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return v, false
+		}
+		v = v.Elem()
+	}
+	return v, true
+}
+
+// elem returns the type "t" points to.
+func elem(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// isEmpty reports if "v" is its zero value or an empty slice or map. If "deref" is set, pointers
+// and interfaces are empty if what they point to is empty.
+func isEmpty(v reflect.Value, deref bool) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return true
+		}
+		if deref {
+			return isEmpty(v.Elem(), deref)
+		}
+		return false
+	}
+	return v.IsZero()
+}
+
+func hasLen(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return true
+	}
+	return false
+}
+
+func isNumber(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func toFloat(v reflect.Value) float64 {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint())
+	}
+	return v.Float()
+}
@@ -0,0 +1,222 @@
+package halfpike
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+type vPeer struct {
+	PeerIP net.IP    `hp:"required"`
+	PeerAS int       `hp:"min=1,max=4294967295"`
+	State  enumState `hp:"required,oneof=Up Testing"`
+	Desc   string    `hp:"max=10,regexp=^[a-z]+(,[a-z]+)*$"`
+	Hold   *int      `hp:"nonzero"`
+	Flaps  *int      `hp:"min=0"`
+	Tables map[string]*vTable
+	Ignore *vTable  `hp:"-"`
+	Opts   []string `hp:"required"`
+	other  int
+}
+
+type vTable struct {
+	Received int    `hp:"min=0"`
+	Name     string `hp:"required"`
+}
+
+// vChecked implements Validator, so ValidateStruct() calls it instead of recursing.
+type vChecked struct {
+	Bad bool
+}
+
+func (v vChecked) Validate() error {
+	if v.Bad {
+		return errors.New("vChecked is bad")
+	}
+	return nil
+}
+
+type vTop struct {
+	Peers   []vPeer
+	Checked vChecked
+	Nested  *vTop
+}
+
+func TestValidateStruct(t *testing.T) {
+	zero, hold, neg := 0, 90, -1
+
+	good := func() vPeer {
+		return vPeer{
+			PeerIP: net.ParseIP("10.0.0.1"),
+			PeerAS: 65000,
+			State:  esUp,
+			Desc:   "core,edge",
+			Hold:   &hold,
+			Tables: map[string]*vTable{"inet.0": {Received: 1, Name: "inet.0"}},
+			Ignore: &vTable{Received: -1},
+			Opts:   []string{"Preference"},
+		}
+	}
+
+	tests := []struct {
+		desc  string
+		v     interface{}
+		want  []string // Path: Rule of each FieldError.
+		other bool
+	}{
+		{
+			desc: "Valid",
+			v:    func() *vPeer { p := good(); return &p }(),
+		},
+		{
+			desc: "Valid struct value",
+			v:    good(),
+		},
+		{
+			desc: "Every rule fails",
+			v: &vPeer{
+				PeerAS: 0,
+				State:  esDown,
+				Desc:   "Core-router",
+				Hold:   &zero,
+				Flaps:  &neg,
+				Tables: map[string]*vTable{"inet.0": {Received: -1}},
+				Ignore: &vTable{Received: -1},
+			},
+			want: []string{
+				"PeerIP: required",
+				"PeerAS: min=1",
+				"State: oneof=Up Testing",
+				"Desc: max=10",
+				"Desc: regexp=^[a-z]+(,[a-z]+)*$",
+				"Hold: nonzero",
+				"Flaps: min=0",
+				"Tables[inet.0].Received: min=0",
+				"Tables[inet.0].Name: required",
+				"Opts: required",
+			},
+		},
+		{
+			desc: "Recurses into slices and calls Validator",
+			v: &vTop{
+				Peers:   []vPeer{good(), func() vPeer { p := good(); p.PeerAS = 0; return p }()},
+				Checked: vChecked{Bad: true},
+				Nested:  &vTop{Checked: vChecked{Bad: true}},
+			},
+			want: []string{
+				"Peers[1].PeerAS: min=1",
+				"Checked: Validate()",
+				"Nested.Checked: Validate()",
+			},
+		},
+		{
+			desc:  "Not a struct",
+			v:     5,
+			other: true,
+		},
+	}
+
+	for _, test := range tests {
+		err := ValidateStruct(test.v)
+		if test.other {
+			if _, ok := err.(ValidationErrors); err == nil || ok {
+				t.Errorf("TestValidateStruct(%s): got err == %v, want an error that is not ValidationErrors", test.desc, err)
+			}
+			continue
+		}
+
+		var got []string
+		if err != nil {
+			errs, ok := err.(ValidationErrors)
+			if !ok {
+				t.Errorf("TestValidateStruct(%s): got err == %s, want ValidationErrors", test.desc, err)
+				continue
+			}
+			for _, f := range errs {
+				got = append(got, fmt.Sprintf("%s: %s", f.Path, f.Rule))
+			}
+		}
+		if diff := pretty.Compare(test.want, got); diff != "" {
+			t.Errorf("TestValidateStruct(%s): -want/+got:\n%s", test.desc, diff)
+		}
+	}
+}
+
+func TestValidateStructBadTag(t *testing.T) {
+	tests := []struct {
+		desc string
+		v    interface{}
+	}{
+		{"Unknown rule", struct {
+			A int `hp:"sometimes"`
+		}{}},
+		{"min without a number", struct {
+			A int `hp:"min=one"`
+		}{}},
+		{"min on a bool", struct {
+			A bool `hp:"min=1"`
+		}{}},
+		{"required with an argument", struct {
+			A int `hp:"required=1"`
+		}{}},
+		{"Bad regexp", struct {
+			A string `hp:"regexp=("`
+		}{}},
+		{"Empty oneof", struct {
+			A string `hp:"oneof="`
+		}{}},
+	}
+
+	for _, test := range tests {
+		err := ValidateStruct(test.v)
+		if _, ok := err.(ValidationErrors); err == nil || ok {
+			t.Errorf("TestValidateStructBadTag(%s): got err == %v, want a tag error", test.desc, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), "hp tag") {
+			t.Errorf("TestValidateStructBadTag(%s): got err == %s, want it to describe the hp tag", test.desc, err)
+		}
+	}
+}
+
+type vCycle struct {
+	Name string `hp:"required"`
+	Next *vCycle
+}
+
+func TestValidateStructCycle(t *testing.T) {
+	a := &vCycle{Name: "a"}
+	b := &vCycle{Next: a}
+	a.Next = b
+
+	err := ValidateStruct(a)
+	errs, ok := err.(ValidationErrors)
+	if !ok || len(errs) != 1 || errs[0].Path != "Next.Name" {
+		t.Errorf("TestValidateStructCycle: got err == %v, want one error for Next.Name", err)
+	}
+}
+
+type vFirst struct {
+	Name string `hp:"required"`
+}
+
+type vOuter struct {
+	First vFirst `hp:"-"`
+	Ptr   *vFirst
+}
+
+func TestValidateStructFirstField(t *testing.T) {
+	// Ptr points to First, which has the same address as the vOuter. It must still be validated.
+	o := &vOuter{}
+	o.Ptr = &o.First
+
+	err := ValidateStruct(o)
+	errs, ok := err.(ValidationErrors)
+	if !ok || len(errs) != 1 || errs[0].Path != "Ptr.Name" {
+		t.Errorf("TestValidateStructFirstField: got err == %v, want one error for Ptr.Name", err)
+	}
+}